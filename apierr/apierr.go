@@ -0,0 +1,220 @@
+// Package apierr defines the errors returned by the API and writes them as a uniform
+// JSON envelope:
+//
+//	{"code": "not_found", "message": "Car not found", "details": ..., "request_id": "..."}
+//
+// code is a stable, machine-readable identifier clients can branch on; message is meant
+// for people and may change. From maps repository and PostgreSQL errors to the matching
+// Error, so handlers can pass storage errors through without leaking database messages.
+package apierr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/hse-trpo-taxi/backend/requestid"
+	"github.com/hse-trpo-taxi/backend/validate"
+	"github.com/lib/pq"
+)
+
+// Code is a machine-readable error identifier.
+type Code string
+
+// Error codes.
+const (
+	CodeBadRequest       Code = "bad_request"
+	CodeValidation       Code = "validation_failed"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+	CodeNotFound         Code = "not_found"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	CodeConflict         Code = "conflict"
+	CodePrecondition     Code = "precondition_failed"
+	CodeUnsupportedMedia Code = "unsupported_media_type"
+	CodeInvalidReference Code = "invalid_reference"
+	CodeRateLimited      Code = "rate_limited"
+	CodeInternal         Code = "internal_error"
+	CodeUnavailable      Code = "unavailable"
+)
+
+// Error is an API error: an HTTP status, a code, a message and optional details.
+// Err, if set, is the underlying cause; it is logged but never sent to the client.
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details any
+	Err     error
+}
+
+// New returns an Error with the given status, code and message.
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithDetails returns a copy of e with the given details.
+func (e *Error) WithDetails(details any) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// BadRequest returns an HTTP 400 error for a malformed request.
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// Unauthorized returns an HTTP 401 error for a missing or invalid credential.
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden returns an HTTP 403 error for a caller without access to a resource.
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound returns an HTTP 404 error for a missing resource.
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict returns an HTTP 409 error for a request that conflicts with the resource's state.
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// PreconditionFailed returns an HTTP 412 error for a conditional request, such as one with
+// an If-Match header, whose condition no longer holds.
+func PreconditionFailed(message string) *Error {
+	return New(http.StatusPreconditionFailed, CodePrecondition, message)
+}
+
+// UnsupportedMediaType returns an HTTP 415 error for a request body of the wrong content type.
+func UnsupportedMediaType(message string) *Error {
+	return New(http.StatusUnsupportedMediaType, CodeUnsupportedMedia, message)
+}
+
+// TooManyRequests returns an HTTP 429 error for a rate-limited caller.
+func TooManyRequests(message string) *Error {
+	return New(http.StatusTooManyRequests, CodeRateLimited, message)
+}
+
+// Internal returns an HTTP 500 error caused by err. Its message is generic; err is only logged.
+func Internal(err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Internal server error", Err: err}
+}
+
+// From converts err into an *Error. An *Error anywhere in the chain is returned as is;
+// validate.Errors become HTTP 422 with the failing fields as details;
+// a repository.DuplicateError becomes HTTP 409 naming the duplicated field; repository and PostgreSQL errors are mapped to the matching status and code;
+// anything else becomes an internal error.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	var fields validate.Errors
+	if errors.As(err, &fields) {
+		return &Error{Status: http.StatusUnprocessableEntity, Code: CodeValidation, Message: "Validation failed", Details: fields, Err: err}
+	}
+	var dup *repository.DuplicateError
+	if errors.As(err, &dup) {
+		message := fmt.Sprintf("A %s with this %s already exists", dup.Entity, strings.ReplaceAll(dup.Field, "_", " "))
+		return &Error{Status: http.StatusConflict, Code: CodeConflict, Message: message, Details: map[string]string{"field": dup.Field}, Err: err}
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: "Record not found", Err: err}
+	case errors.Is(err, repository.ErrConflict):
+		return &Error{Status: http.StatusConflict, Code: CodeConflict, Message: "Record was modified by another request", Err: err}
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return &Error{Status: http.StatusServiceUnavailable, Code: CodeUnavailable, Message: "Request could not be completed in time", Err: err}
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return fromPQ(pqErr)
+	}
+	return Internal(err)
+}
+
+// fromPQ maps a PostgreSQL constraint or data error to an *Error naming the constraint
+// or column involved, and a statement cancelled by statement_timeout to HTTP 503 like
+// an expired context. Other PostgreSQL errors are internal.
+func fromPQ(err *pq.Error) *Error {
+	details := map[string]string{}
+	if err.Constraint != "" {
+		details["constraint"] = err.Constraint
+	}
+	if err.Column != "" {
+		details["column"] = err.Column
+	}
+
+	var e *Error
+	// Conditions are matched by the names lib/pq gives the SQLSTATE codes
+	switch err.Code.Name() {
+	case "unique_violation":
+		e = Conflict("Record already exists")
+	case "foreign_key_violation":
+		// The same code is raised for a reference to a missing row and for deleting a referenced row
+		if strings.Contains(err.Detail, "is still referenced") {
+			e = Conflict("Record is still referenced by other records")
+		} else {
+			e = New(http.StatusUnprocessableEntity, CodeInvalidReference, "Referenced record does not exist")
+		}
+	case "check_violation", "not_null_violation", "string_data_right_truncation", "numeric_value_out_of_range":
+		e = New(http.StatusUnprocessableEntity, CodeValidation, "Value is not allowed")
+	case "query_canceled":
+		e = New(http.StatusServiceUnavailable, CodeUnavailable, "Request could not be completed in time")
+	default:
+		return Internal(err)
+	}
+	e.Err = err
+	if len(details) > 0 {
+		e.Details = details
+	}
+	return e
+}
+
+// Response is the JSON body of an error response.
+type Response struct {
+	Code      Code   `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Write converts err with From and writes it as a JSON error response.
+// Server errors are logged with their cause; the logger adds the request ID from the request context.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	e := From(err)
+	id := requestid.FromContext(r.Context())
+	if e.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "Request failed", "method", r.Method, "path", r.URL.Path, "error", e)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(Response{Code: e.Code, Message: e.Message, Details: e.Details, RequestID: id})
+}
@@ -0,0 +1,207 @@
+// Package auth authenticates API requests with signed JWTs and authorizes them by role.
+//
+// Tokens are HS256-signed with one of a set of named HMAC keys; the key name is carried in
+// the "kid" header so keys can be rotated without invalidating tokens signed with the old one.
+// A token names a subject (the client or driver ID, or a staff account ID) and a Role.
+// Require wraps a handler with authentication and a route-level Rule; finer checks that need
+// the stored record, such as car ownership, are made by the handlers through FromContext.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Issuer is the "iss" claim of every token issued by the service.
+const Issuer = "hse-trpo-taxi"
+
+// Role is the kind of account a token was issued to.
+type Role string
+
+// Account roles.
+const (
+	// RoleClient is a passenger; the subject is a client ID
+	RoleClient Role = "client"
+	// RoleDriver is a driver; the subject is a driver ID
+	RoleDriver Role = "driver"
+	// RoleDispatcher is an operator who manages rides and drivers
+	RoleDispatcher Role = "dispatcher"
+	// RoleAdmin has unrestricted access
+	RoleAdmin Role = "admin"
+)
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleClient, RoleDriver, RoleDispatcher, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// Errors returned by Tokens.
+var (
+	// ErrInvalidToken means a token is malformed, expired, or not signed with a known key
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrNoKeys means no signing keys were configured
+	ErrNoKeys = errors.New("no signing keys")
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject is the ID of the client, driver or staff account
+	Subject int
+	// Role is the kind of account
+	Role Role
+}
+
+// Is reports whether the principal has one of roles.
+func (p Principal) Is(roles ...Role) bool {
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaff reports whether the principal is a dispatcher or admin.
+func (p Principal) IsStaff() bool {
+	return p.Is(RoleDispatcher, RoleAdmin)
+}
+
+// Owns reports whether the principal is the account of the given role and ID.
+func (p Principal) Owns(role Role, id int) bool {
+	return p.Role == role && p.Subject == id
+}
+
+// principalKey is the context key under which the Principal is stored.
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored by Require, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// claims is the JWT payload.
+type claims struct {
+	Role Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Tokens issues and verifies JWTs with a set of named HMAC keys.
+type Tokens struct {
+	keys       map[string][]byte
+	signingKey string
+	ttl        time.Duration
+}
+
+// NewTokens returns Tokens that sign with the key named signingKey, accept any key in keys,
+// and issue tokens valid for ttl.
+func NewTokens(keys map[string][]byte, signingKey string, ttl time.Duration) (*Tokens, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	if _, ok := keys[signingKey]; !ok {
+		return nil, fmt.Errorf("signing key %q is not among the configured keys", signingKey)
+	}
+	return &Tokens{keys: keys, signingKey: signingKey, ttl: ttl}, nil
+}
+
+// ParseKeys parses a comma-separated list of name:secret pairs, as used by the JWT_KEYS setting.
+// It returns the keys and the name of the first one, which is used for signing.
+func ParseKeys(s string) (map[string][]byte, string, error) {
+	keys := make(map[string][]byte)
+	var first string
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, secret, ok := strings.Cut(pair, ":")
+		if !ok || name == "" || secret == "" {
+			return nil, "", fmt.Errorf("key %q must have the form name:secret", name)
+		}
+		if len(secret) < 32 {
+			return nil, "", fmt.Errorf("key %q must be at least 32 bytes long", name)
+		}
+		if _, ok := keys[name]; ok {
+			return nil, "", fmt.Errorf("key %q is listed twice", name)
+		}
+		keys[name] = []byte(secret)
+		if first == "" {
+			first = name
+		}
+	}
+	if len(keys) == 0 {
+		return nil, "", ErrNoKeys
+	}
+	return keys, first, nil
+}
+
+// RandomKey returns a single random key named "ephemeral", for running without configured keys.
+// Tokens signed with it stop being valid when the process exits.
+func RandomKey() (map[string][]byte, string) {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return map[string][]byte{"ephemeral": secret}, "ephemeral"
+}
+
+// Issue returns a signed token for p that expires after the configured TTL.
+func (t *Tokens) Issue(p Principal) (string, time.Time, error) {
+	return t.IssueFor(p, t.ttl)
+}
+
+// IssueFor returns a signed token for p that expires after ttl.
+func (t *Tokens) IssueFor(p Principal, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expires := now.Add(ttl)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Role: p.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Subject:   strconv.Itoa(p.Subject),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+	})
+	token.Header["kid"] = t.signingKey
+	signed, err := token.SignedString(t.keys[t.signingKey])
+	return signed, expires, err
+}
+
+// Verify checks the signature, issuer and expiry of token and returns its principal.
+// Any failure is reported as ErrInvalidToken.
+func (t *Tokens) Verify(token string) (Principal, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := t.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(Issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	subject, err := strconv.Atoi(c.Subject)
+	if err != nil || !c.Role.Valid() {
+		return Principal{}, fmt.Errorf("%w: bad subject or role", ErrInvalidToken)
+	}
+	return Principal{Subject: subject, Role: c.Role}, nil
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/hse-trpo-taxi/backend/apierr"
+)
+
+// Rule decides whether an authenticated principal may make a request.
+type Rule func(p Principal, r *http.Request) bool
+
+// Authenticated allows any authenticated principal.
+func Authenticated(p Principal, r *http.Request) bool {
+	return true
+}
+
+// Roles allows principals with one of roles.
+func Roles(roles ...Role) Rule {
+	return func(p Principal, r *http.Request) bool {
+		return p.Is(roles...)
+	}
+}
+
+// Self allows a principal with the given role whose subject equals the route variable param,
+// e.g. a client requesting /api/clients/{id} with their own ID.
+func Self(role Role, param string) Rule {
+	return func(p Principal, r *http.Request) bool {
+		id, err := strconv.Atoi(mux.Vars(r)[param])
+		return err == nil && p.Owns(role, id)
+	}
+}
+
+// Any allows a request that at least one of rules allows.
+func Any(rules ...Rule) Rule {
+	return func(p Principal, r *http.Request) bool {
+		for _, rule := range rules {
+			if rule(p, r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Require wraps next so that it only runs for requests bearing a valid token whose
+// principal satisfies rule. The principal is stored in the request context.
+// Requests without a valid token get HTTP 401; those the rule rejects get HTTP 403.
+func (t *Tokens) Require(rule Rule, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			apierr.Write(w, r, apierr.Unauthorized("Authentication required"))
+			return
+		}
+		p, err := t.Verify(strings.TrimSpace(token))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			apierr.Write(w, r, apierr.Unauthorized("Invalid or expired token"))
+			return
+		}
+		if !rule(p, r) {
+			apierr.Write(w, r, apierr.Forbidden("Forbidden"))
+			return
+		}
+		next(w, r.WithContext(WithPrincipal(r.Context(), p)))
+	}
+}
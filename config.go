@@ -0,0 +1,22 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/hse-trpo-taxi/backend/config"
+)
+
+// configUsage describes the arguments accepted by the config subcommand.
+const configUsage = `usage: backend [flags] config print
+
+Prints the effective configuration, merged from the defaults, the config file,
+the environment and the flags, with the source of every setting. Secrets are redacted.`
+
+// runConfig implements the "config" subcommand.
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+	return cfg.Print(os.Stdout)
+}
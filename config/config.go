@@ -1,33 +1,383 @@
 // Package config provides configuration management for the taxi service backend.
-// It handles loading configuration from environment variables with sensible defaults.
+// Every setting has a default that can be overridden, from lowest to highest precedence,
+// by a YAML or TOML config file, an environment variable and a command line flag.
 package config
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/auth"
+	"github.com/hse-trpo-taxi/backend/cors"
+)
+
+// Sources of a setting's value, from lowest to highest precedence.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
 )
 
+// configFlag is the flag naming the config file; CONFIG_FILE is its environment variable.
+const configFlag = "config"
+
+// usage describes the command line; the flags are listed after it.
+const usage = `usage: backend [flags] [command]
+
+commands:
+  (none)        run the server
+  migrate ...   manage the database schema
+  token ...     issue an access token
+  config print  print the effective configuration
+
+Every flag can also be set in the config file under its name with "_" instead of "-"
+(server_port: "8080") or as an environment variable in upper case (SERVER_PORT=8080).
+Flags override environment variables, which override the config file.
+Empty environment variables are ignored.
+
+flags:`
+
 // Config holds the configuration settings for the taxi service.
 // It includes server and database connection parameters.
 type Config struct {
+	// File is the config file the settings were read from, or "" if there is none
+	File string
+	// ConfigWatchInterval is how often File is checked for changes to reload; 0 reloads only on SIGHUP
+	ConfigWatchInterval time.Duration
+
 	// ServerPort specifies the port on which the HTTP server will listen
 	ServerPort string
+	// HTTPReadTimeout bounds reading a whole request, including the body
+	HTTPReadTimeout time.Duration
+	// HTTPWriteTimeout bounds the time from the end of the request headers to the end of the response
+	HTTPWriteTimeout time.Duration
+	// HTTPIdleTimeout is how long a keep-alive connection may wait for the next request
+	HTTPIdleTimeout time.Duration
+	// ShutdownDelay is how long the server keeps serving after a stop signal while reporting
+	// itself as not ready, before it stops accepting connections
+	ShutdownDelay time.Duration
+	// ShutdownGracePeriod is how long requests in flight may take to finish during shutdown
+	ShutdownGracePeriod time.Duration
+	// LogFormat selects the log output: "json" (one JSON object per line) or "text"
+	LogFormat string
+	// LogLevel is the lowest level logged: "debug", "info", "warn" or "error"
+	LogLevel string
+	// TracingExporter selects where trace spans are sent: "otlp", "stdout" or "none"
+	TracingExporter string
+	// TracingEndpoint is the URL of the OTLP/HTTP collector; if empty, the OTEL_EXPORTER_OTLP_*
+	// variables or http://localhost:4318 apply
+	TracingEndpoint string
+	// TracingSampleRatio is the fraction (0-1) of new traces that are recorded
+	TracingSampleRatio float64
+	// TracingServiceName names the service in the traces
+	TracingServiceName string
+	// CORSOrigins lists the origins of browser applications allowed to call the API,
+	// comma-separated, or "*" for any origin
+	CORSOrigins string
+	// FeatureSurgePricing switches surge multipliers on; when off, every multiplier is 1
+	FeatureSurgePricing bool
+	// FeatureClientSignup lets clients register by logging in with a new phone number
+	FeatureClientSignup bool
+	// HealthCheckTimeout is how long the readiness checks, such as the database ping, may take
+	HealthCheckTimeout time.Duration
 	// DatabaseDSN contains the PostgreSQL connection string
 	DatabaseDSN string
+	// DBMaxOpenConns limits the open database connections; 0 means no limit
+	DBMaxOpenConns int
+	// DBMaxIdleConns is how many idle database connections are kept for reuse
+	DBMaxIdleConns int
+	// DBConnMaxLifetime is how long a database connection is reused before it is replaced
+	DBConnMaxLifetime time.Duration
+	// DBConnMaxIdleTime is how long a database connection may stay idle before it is closed
+	DBConnMaxIdleTime time.Duration
+	// DBStatementTimeout makes PostgreSQL cancel statements running longer; 0 means no limit
+	DBStatementTimeout time.Duration
+	// LocationStore selects where driver positions are kept: "postgres" (shared by all replicas)
+	// or "memory" (an in-process spatial index, suitable for a single replica)
+	LocationStore string
+	// DispatchStrategy selects how candidate drivers are ranked: "nearest" or "rating"
+	DispatchStrategy string
+	// DispatchRadius is the driver search radius around the pickup point in meters
+	DispatchRadius float64
+	// DispatchMinRating is the lowest driver rating that still receives ride offers
+	DispatchMinRating float64
+	// DispatchOfferTimeout is how long a driver has to answer a ride offer
+	DispatchOfferTimeout time.Duration
+	// DispatchMaxCandidates limits how many drivers are offered a single ride
+	DispatchMaxCandidates int
+	// SurgeZoneSize is the side of a square surge pricing zone in degrees
+	SurgeZoneSize float64
+	// SurgeWindow is how long a ride request counts as demand in its zone
+	SurgeWindow time.Duration
+	// SurgeInterval is how often surge multipliers are recomputed
+	SurgeInterval time.Duration
+	// SurgeSensitivity is how much the multiplier grows per unit of demand/supply ratio above 1
+	SurgeSensitivity float64
+	// SurgeCap is the highest surge multiplier
+	SurgeCap float64
+	// SurgeSmoothing is the weight (0-1] given to a newly computed multiplier against the previous one
+	SurgeSmoothing float64
+	// JWTKeys lists the HMAC keys for access tokens as comma-separated name:secret pairs;
+	// the first key signs new tokens and all of them are accepted
+	JWTKeys string
+	// JWTTTL is how long issued access tokens are valid
+	JWTTTL time.Duration
+	// SMSSender selects how login codes are delivered: "log" (the server log) or "file"
+	SMSSender string
+	// SMSFile is the file the "file" SMS sender appends messages to
+	SMSFile string
+	// OTPTTL is how long a login code stays valid
+	OTPTTL time.Duration
+	// OTPResendInterval is the minimum time between two login codes sent to the same phone number
+	OTPResendInterval time.Duration
+	// OTPMaxSends is how many login codes can be sent to a phone number per OTPSendWindow
+	OTPMaxSends int
+	// OTPSendWindow is the period over which OTPMaxSends is counted
+	OTPSendWindow time.Duration
+	// OTPMaxAttempts is how many verification attempts a single login code allows
+	OTPMaxAttempts int
+	// DeletedRetention is how long deleted clients, drivers and cars can be restored
+	// before they are purged; 0 keeps them forever
+	DeletedRetention time.Duration
+	// PurgeInterval is how often deleted records past DeletedRetention are purged
+	PurgeInterval time.Duration
+
+	// flags binds every setting to its field; sources records where each value came from
+	flags   *flag.FlagSet
+	sources map[string]string
+}
+
+// define registers every setting on flags, bound to its field in cfg and set to its default.
+// The DATABASE_URL default is filled in by Load, so the help output shows no password.
+func (cfg *Config) define(flags *flag.FlagSet) {
+	flags.StringVar(&cfg.File, configFlag, os.Getenv("CONFIG_FILE"), "YAML (.yaml, .yml) or TOML (.toml) config file; env CONFIG_FILE")
+
+	flags.DurationVar(&cfg.ConfigWatchInterval, "config-watch-interval", 10*time.Second, "how often the config file is checked for changes to reload, 0 for SIGHUP only")
+
+	flags.StringVar(&cfg.ServerPort, "server-port", "8080", "port the HTTP server listens on")
+	flags.DurationVar(&cfg.HTTPReadTimeout, "http-read-timeout", 15*time.Second, "time limit for reading a request, body included")
+	flags.DurationVar(&cfg.HTTPWriteTimeout, "http-write-timeout", 30*time.Second, "time limit from the end of the request headers to the end of the response")
+	flags.DurationVar(&cfg.HTTPIdleTimeout, "http-idle-timeout", 2*time.Minute, "how long a keep-alive connection waits for the next request")
+	flags.DurationVar(&cfg.ShutdownDelay, "shutdown-delay", 5*time.Second, "how long the server keeps serving after SIGTERM while /readyz fails")
+	flags.DurationVar(&cfg.ShutdownGracePeriod, "shutdown-grace-period", 20*time.Second, "how long requests in flight then have to finish")
+	flags.DurationVar(&cfg.HealthCheckTimeout, "health-check-timeout", 2*time.Second, "time limit for the dependency checks of /readyz and /health")
+	flags.StringVar(&cfg.CORSOrigins, "cors-origins", "", "comma-separated origins of browser applications allowed to call the API, or *")
+
+	flags.BoolVar(&cfg.FeatureSurgePricing, "feature-surge-pricing", true, "apply surge multipliers to fares")
+	flags.BoolVar(&cfg.FeatureClientSignup, "feature-client-signup", true, "register clients on their first login")
+
+	flags.StringVar(&cfg.LogFormat, "log-format", "json", "log output: json or text")
+	flags.StringVar(&cfg.LogLevel, "log-level", "info", "lowest level logged: debug, info, warn or error")
+
+	flags.StringVar(&cfg.TracingExporter, "tracing-exporter", "none", "where trace spans are sent: otlp, stdout or none")
+	flags.StringVar(&cfg.TracingEndpoint, "tracing-endpoint", "", "OTLP/HTTP collector URL (default OTEL_EXPORTER_OTLP_* or http://localhost:4318)")
+	flags.Float64Var(&cfg.TracingSampleRatio, "tracing-sample-ratio", 1, "fraction of new traces recorded")
+	flags.StringVar(&cfg.TracingServiceName, "tracing-service-name", "taxi-backend", "service name in the traces")
+
+	flags.StringVar(&cfg.DatabaseDSN, "database-url", "", "PostgreSQL connection string (default built from DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME and DB_SSLMODE)")
+	flags.IntVar(&cfg.DBMaxOpenConns, "db-max-open-conns", 25, "maximum open database connections, 0 for no limit")
+	flags.IntVar(&cfg.DBMaxIdleConns, "db-max-idle-conns", 10, "idle database connections kept for reuse")
+	flags.DurationVar(&cfg.DBConnMaxLifetime, "db-conn-max-lifetime", 30*time.Minute, "time after which a database connection is replaced, 0 for never")
+	flags.DurationVar(&cfg.DBConnMaxIdleTime, "db-conn-max-idle-time", 5*time.Minute, "idle time after which a database connection is closed, 0 for never")
+	flags.DurationVar(&cfg.DBStatementTimeout, "db-statement-timeout", 10*time.Second, "PostgreSQL statement_timeout, 0 for none")
+	flags.StringVar(&cfg.LocationStore, "location-store", "postgres", "where driver positions are kept: postgres or memory")
+
+	flags.StringVar(&cfg.DispatchStrategy, "dispatch-strategy", "nearest", "how candidate drivers are ranked: nearest or rating")
+	flags.Float64Var(&cfg.DispatchRadius, "dispatch-radius", 3000, "driver search radius around the pickup point in meters")
+	flags.Float64Var(&cfg.DispatchMinRating, "dispatch-min-rating", 0, "lowest driver rating that still receives ride offers")
+	flags.DurationVar(&cfg.DispatchOfferTimeout, "dispatch-offer-timeout", 15*time.Second, "how long a driver has to answer a ride offer")
+	flags.IntVar(&cfg.DispatchMaxCandidates, "dispatch-max-candidates", 10, "how many drivers are offered a single ride at most")
+
+	flags.Float64Var(&cfg.SurgeZoneSize, "surge-zone-size", 0.02, "side of a square surge pricing zone in degrees")
+	flags.DurationVar(&cfg.SurgeWindow, "surge-window", 10*time.Minute, "how long a ride request counts as demand in its zone")
+	flags.DurationVar(&cfg.SurgeInterval, "surge-interval", 30*time.Second, "how often surge multipliers are recomputed")
+	flags.Float64Var(&cfg.SurgeSensitivity, "surge-sensitivity", 0.5, "multiplier growth per unit of demand/supply ratio above 1")
+	flags.Float64Var(&cfg.SurgeCap, "surge-cap", 3, "highest surge multiplier")
+	flags.Float64Var(&cfg.SurgeSmoothing, "surge-smoothing", 0.3, "weight (0-1] of a newly computed multiplier against the previous one")
+
+	flags.StringVar(&cfg.JWTKeys, "jwt-keys", "", "access token keys as comma-separated name:secret pairs; the first one signs")
+	flags.DurationVar(&cfg.JWTTTL, "jwt-ttl", 24*time.Hour, "how long issued access tokens are valid")
+
+	flags.StringVar(&cfg.SMSSender, "sms-sender", "log", "how login codes are delivered: log or file")
+	flags.StringVar(&cfg.SMSFile, "sms-file", "sms.log", "file the file SMS sender appends messages to")
+	flags.DurationVar(&cfg.OTPTTL, "otp-ttl", 5*time.Minute, "how long a login code stays valid")
+	flags.DurationVar(&cfg.OTPResendInterval, "otp-resend-interval", time.Minute, "minimum time between two codes sent to the same phone number")
+	flags.IntVar(&cfg.OTPMaxSends, "otp-max-sends", 5, "codes sent to a phone number per otp-send-window at most")
+	flags.DurationVar(&cfg.OTPSendWindow, "otp-send-window", time.Hour, "period over which otp-max-sends is counted")
+	flags.IntVar(&cfg.OTPMaxAttempts, "otp-max-attempts", 5, "verification attempts a single code allows")
+
+	flags.DurationVar(&cfg.DeletedRetention, "deleted-retention", 90*24*time.Hour, "how long deleted records can be restored before they are purged, 0 for forever")
+	flags.DurationVar(&cfg.PurgeInterval, "purge-interval", time.Hour, "how often expired deleted records are purged")
 }
 
-// LoadConfig creates and returns a new Config instance with values loaded from environment variables.
-// If environment variables are not set, it uses sensible defaults.
-// SERVER_PORT defaults to "8080" and DATABASE_URL is constructed from individual database parameters.
-func LoadConfig() *Config {
-	config := &Config{
-		ServerPort:  getEnv("SERVER_PORT", "8080"),
-		DatabaseDSN: getEnv("DATABASE_URL", getDefaultPostgresURL()),
+// Load returns the configuration given by the flags at the start of args, the config file
+// they or CONFIG_FILE name, and the environment, together with the arguments after the flags,
+// such as a subcommand. It reports every invalid setting at once, joined into one error;
+// with -h it prints the settings and returns flag.ErrHelp.
+func Load(args []string) (*Config, []string, error) {
+	cfg := &Config{flags: flag.NewFlagSet("backend", flag.ContinueOnError), sources: map[string]string{}}
+	cfg.define(cfg.flags)
+	cfg.flags.Usage = func() {
+		fmt.Fprintln(cfg.flags.Output(), usage)
+		cfg.flags.PrintDefaults()
+	}
+	if err := cfg.flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	// The flags are parsed first to find the config file, and applied again last to win over it
+	fromFlags := map[*flag.Flag]string{}
+	cfg.flags.Visit(func(f *flag.Flag) { fromFlags[f] = f.Value.String() })
+
+	var errs []error
+	if cfg.File != "" {
+		values, err := readFile(cfg.File)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, key := range sortedKeys(values) {
+			f := cfg.flags.Lookup(strings.ReplaceAll(key, "_", "-"))
+			if f == nil || f.Name == configFlag {
+				errs = append(errs, fmt.Errorf("%s: unknown setting in %s", key, cfg.File))
+				continue
+			}
+			errs = append(errs, cfg.set(f, values[key], SourceFile, "file "+cfg.File))
+		}
+	}
+	cfg.flags.VisitAll(func(f *flag.Flag) {
+		if f.Name == configFlag {
+			return
+		}
+		// An empty variable counts as unset, so FOO= in a compose file or unit does not
+		// clear a setting from the file or its default
+		if value := os.Getenv(envName(f.Name)); value != "" {
+			errs = append(errs, cfg.set(f, value, SourceEnv, "env "+envName(f.Name)))
+		}
+	})
+	for f, value := range fromFlags {
+		errs = append(errs, cfg.set(f, value, SourceFlag, "flag -"+f.Name))
 	}
 
-	log.Printf("Configuration loaded: Port=%s", config.ServerPort)
-	return config
+	if cfg.DatabaseDSN == "" {
+		cfg.DatabaseDSN = defaultPostgresURL()
+	}
+	errs = append(errs, cfg.Validate())
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+	return cfg, cfg.flags.Args(), nil
+}
+
+// set sets the setting f to value, recording source as its origin. A value that does not
+// parse leaves the setting as it was and is described with the place it came from.
+func (cfg *Config) set(f *flag.Flag, value, source, from string) error {
+	previous := f.Value.String()
+	if err := f.Value.Set(value); err != nil {
+		f.Value.Set(previous)
+		return fmt.Errorf("%s: invalid value %q from %s", key(f.Name), value, from)
+	}
+	cfg.sources[f.Name] = source
+	return nil
+}
+
+// Validate checks every setting and returns all the problems found joined into one error,
+// each naming the setting as in the config file, or nil if the configuration is valid.
+func (cfg *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, name, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", name, fmt.Sprintf(format, args...)))
+		}
+	}
+	oneOf := func(value, name string, allowed ...string) {
+		check(slices.Contains(allowed, value), name, "%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+
+	check(cfg.ConfigWatchInterval >= 0, "config_watch_interval", "must not be negative")
+	check(validPort(cfg.ServerPort), "server_port", "%q is not a port number", cfg.ServerPort)
+	check(cfg.HTTPReadTimeout > 0, "http_read_timeout", "must be positive")
+	check(cfg.HTTPWriteTimeout > 0, "http_write_timeout", "must be positive")
+	check(cfg.HTTPIdleTimeout > 0, "http_idle_timeout", "must be positive")
+	check(cfg.ShutdownDelay >= 0, "shutdown_delay", "must not be negative")
+	check(cfg.ShutdownGracePeriod > 0, "shutdown_grace_period", "must be positive")
+	check(cfg.HealthCheckTimeout > 0, "health_check_timeout", "must be positive")
+	if _, err := cors.ParseOrigins(cfg.CORSOrigins); err != nil {
+		check(false, "cors_origins", "%v", err)
+	}
+
+	oneOf(cfg.LogFormat, "log_format", "json", "text")
+	var level slog.Level
+	check(level.UnmarshalText([]byte(cfg.LogLevel)) == nil, "log_level", "%q is not one of debug, info, warn, error", cfg.LogLevel)
+
+	oneOf(cfg.TracingExporter, "tracing_exporter", "otlp", "stdout", "none")
+	check(cfg.TracingSampleRatio >= 0 && cfg.TracingSampleRatio <= 1, "tracing_sample_ratio", "must be between 0 and 1")
+	check(cfg.TracingServiceName != "", "tracing_service_name", "must not be empty")
+
+	check(cfg.DatabaseDSN != "", "database_url", "must not be empty")
+	check(cfg.DBMaxOpenConns >= 0, "db_max_open_conns", "must not be negative")
+	check(cfg.DBMaxIdleConns >= 0, "db_max_idle_conns", "must not be negative")
+	check(cfg.DBMaxOpenConns == 0 || cfg.DBMaxIdleConns <= cfg.DBMaxOpenConns, "db_max_idle_conns", "must not exceed db_max_open_conns")
+	check(cfg.DBConnMaxLifetime >= 0, "db_conn_max_lifetime", "must not be negative")
+	check(cfg.DBConnMaxIdleTime >= 0, "db_conn_max_idle_time", "must not be negative")
+	check(cfg.DBStatementTimeout >= 0, "db_statement_timeout", "must not be negative")
+	oneOf(cfg.LocationStore, "location_store", "postgres", "memory")
+
+	oneOf(cfg.DispatchStrategy, "dispatch_strategy", "nearest", "rating")
+	check(cfg.DispatchRadius > 0, "dispatch_radius", "must be positive")
+	check(cfg.DispatchMinRating >= 0 && cfg.DispatchMinRating <= 5, "dispatch_min_rating", "must be between 0 and 5")
+	check(cfg.DispatchOfferTimeout > 0, "dispatch_offer_timeout", "must be positive")
+	check(cfg.DispatchMaxCandidates > 0, "dispatch_max_candidates", "must be positive")
+
+	check(cfg.SurgeZoneSize > 0, "surge_zone_size", "must be positive")
+	check(cfg.SurgeWindow > 0, "surge_window", "must be positive")
+	check(cfg.SurgeInterval > 0, "surge_interval", "must be positive")
+	check(cfg.SurgeSensitivity >= 0, "surge_sensitivity", "must not be negative")
+	check(cfg.SurgeCap >= 1, "surge_cap", "must be at least 1")
+	check(cfg.SurgeSmoothing > 0 && cfg.SurgeSmoothing <= 1, "surge_smoothing", "must be in (0, 1]")
+
+	if _, _, err := auth.ParseKeys(cfg.JWTKeys); err != nil && !errors.Is(err, auth.ErrNoKeys) {
+		check(false, "jwt_keys", "%v", err)
+	}
+	check(cfg.JWTTTL > 0, "jwt_ttl", "must be positive")
+
+	oneOf(cfg.SMSSender, "sms_sender", "log", "file")
+	check(cfg.SMSSender != "file" || cfg.SMSFile != "", "sms_file", "must be set for the file sender")
+	check(cfg.OTPTTL > 0, "otp_ttl", "must be positive")
+	check(cfg.OTPResendInterval >= 0, "otp_resend_interval", "must not be negative")
+	check(cfg.OTPMaxSends > 0, "otp_max_sends", "must be positive")
+	check(cfg.OTPSendWindow > 0, "otp_send_window", "must be positive")
+	check(cfg.OTPMaxAttempts > 0, "otp_max_attempts", "must be positive")
+
+	check(cfg.DeletedRetention >= 0, "deleted_retention", "must not be negative")
+	check(cfg.DeletedRetention == 0 || cfg.PurgeInterval > 0, "purge_interval", "must be positive")
+
+	return errors.Join(errs...)
+}
+
+// envName returns the environment variable of the setting with the given flag name,
+// e.g. HTTP_READ_TIMEOUT for http-read-timeout.
+func envName(flagName string) string {
+	return strings.ToUpper(key(flagName))
+}
+
+// key returns the config file key of the setting with the given flag name,
+// e.g. http_read_timeout for http-read-timeout.
+func key(flagName string) string {
+	return strings.ReplaceAll(flagName, "-", "_")
+}
+
+// validPort reports whether s is a TCP port number.
+func validPort(s string) bool {
+	port, err := strconv.Atoi(s)
+	return err == nil && port > 0 && port < 65536
 }
 
 // getEnv retrieves an environment variable value or returns a default value if not set.
@@ -40,7 +390,7 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
-// getDefaultPostgresURL constructs a PostgreSQL connection string from individual environment variables.
+// defaultPostgresURL constructs a PostgreSQL connection string from individual environment variables.
 // It uses the following environment variables with their defaults:
 // - DB_HOST (default: "localhost")
 // - DB_PORT (default: "5432")
@@ -48,7 +398,7 @@ func getEnv(key, defaultValue string) string {
 // - DB_PASSWORD (default: "postgres")
 // - DB_NAME (default: "taxi")
 // - DB_SSLMODE (default: "disable")
-func getDefaultPostgresURL() string {
+func defaultPostgresURL() string {
 	host := getEnv("DB_HOST", "localhost")
 	port := getEnv("DB_PORT", "5432")
 	user := getEnv("DB_USER", "postgres")
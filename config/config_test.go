@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadEnvironment(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("server_port: \"9090\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		env        string
+		wantPort   string
+		wantSource string
+	}{
+		{"empty variable is ignored", "", "9090", SourceFile},
+		{"variable overrides the file", "7070", "7070", SourceEnv},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SERVER_PORT", tt.env)
+			t.Setenv("LOG_LEVEL", "")
+			cfg, _, err := Load([]string{"-config", file})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.ServerPort != tt.wantPort || cfg.source("server-port") != tt.wantSource {
+				t.Errorf("server port = %q from %s, want %q from %s", cfg.ServerPort, cfg.source("server-port"), tt.wantPort, tt.wantSource)
+			}
+			if cfg.LogLevel == "" {
+				t.Error("empty LOG_LEVEL cleared the default log level")
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+)
+
+// redactions mask the secrets in the settings that contain them.
+var redactions = map[string]func(string) string{
+	"database-url": redactDSN,
+	"jwt-keys":     redactKeys,
+}
+
+// redacted is shown in place of a secret.
+const redacted = "REDACTED"
+
+// Print writes the effective configuration to w in the config file format, one setting per
+// line followed by where its value came from. Secrets are redacted, so the output is safe
+// to share but must be completed before it is used as a config file.
+func (cfg *Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if cfg.File != "" {
+		fmt.Fprintf(tw, "# config file: %s\n", cfg.File)
+	}
+	cfg.settings(func(f *flag.Flag, value, source string) {
+		fmt.Fprintf(tw, "%s: %q\t# %s\n", key(f.Name), value, source)
+	})
+	return tw.Flush()
+}
+
+// LogValue returns the settings with secrets redacted, for the startup log.
+func (cfg *Config) LogValue() slog.Value {
+	var attrs []slog.Attr
+	cfg.settings(func(f *flag.Flag, value, source string) {
+		attrs = append(attrs, slog.String(key(f.Name), value))
+	})
+	return slog.GroupValue(attrs...)
+}
+
+// settings calls fn with every setting in order of name, its value with secrets redacted
+// and the source of the value.
+func (cfg *Config) settings(fn func(f *flag.Flag, value, source string)) {
+	cfg.flags.VisitAll(func(f *flag.Flag) {
+		if f.Name == configFlag {
+			return
+		}
+		value := f.Value.String()
+		if redact, ok := redactions[f.Name]; ok && value != "" {
+			value = redact(value)
+		}
+		source, ok := cfg.sources[f.Name]
+		if !ok {
+			source = SourceDefault
+		}
+		fn(f, value, source)
+	})
+}
+
+// dsnPassword matches the password of a key=value connection string.
+var dsnPassword = regexp.MustCompile(`password=('(\\.|[^'])*'|\S*)`)
+
+// redactDSN masks the password of a PostgreSQL connection string in URL or key=value form.
+func redactDSN(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return redacted
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "password="+redacted)
+}
+
+// redactKeys masks the secrets of JWT keys, keeping their names.
+func redactKeys(keys string) string {
+	pairs := strings.Split(keys, ",")
+	for i, pair := range pairs {
+		name, _, _ := strings.Cut(strings.TrimSpace(pair), ":")
+		pairs[i] = name + ":" + redacted
+	}
+	return strings.Join(pairs, ",")
+}
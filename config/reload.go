@@ -0,0 +1,174 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// reloadable lists, by flag name, the settings that take effect without a restart.
+var reloadable = []string{
+	"log-level",
+	"otp-ttl", "otp-resend-interval", "otp-max-sends", "otp-send-window", "otp-max-attempts",
+	"feature-surge-pricing", "feature-client-signup",
+	"cors-origins",
+}
+
+// Reloader reloads the configuration while the server runs, on SIGHUP or when the config
+// file changes. Only the reloadable settings take effect: the log level, the login code
+// limits, the feature flags and the CORS origins. Changes to other settings are logged
+// as needing a restart, and a configuration that does not load or validate is rejected
+// as a whole, keeping the current one.
+type Reloader struct {
+	args []string
+
+	mu          sync.Mutex
+	current     *Config
+	subscribers []func(old, new *Config)
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// NewReloader returns a Reloader for cfg, which was loaded from the command line args.
+// The Reloader updates the reloadable settings of cfg in place; cfg should not be read
+// concurrently with a reload except through Subscribe.
+func NewReloader(cfg *Config, args []string) *Reloader {
+	return &Reloader{args: args, current: cfg}
+}
+
+// Subscribe calls apply with the value get selects from the configuration, such as the log
+// level or a component's settings, after every reload that changes it.
+func Subscribe[T any](r *Reloader, get func(*Config) T, apply func(T)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, func(old, new *Config) {
+		if value := get(new); !reflect.DeepEqual(get(old), value) {
+			apply(value)
+		}
+	})
+}
+
+// Reload loads the configuration again from the same command line, the config file and the
+// environment. If it is invalid, the error is returned and nothing changes. Otherwise the
+// reloadable settings are updated and the subscribers told about the changes.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, _, err := Load(r.args)
+	if err != nil {
+		return err
+	}
+
+	old := *r.current
+	var changed, restart []string
+	next.flags.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		current := r.current.flags.Lookup(f.Name)
+		if f.Name == configFlag || current.Value.String() == value {
+			return
+		}
+		if !slices.Contains(reloadable, f.Name) {
+			restart = append(restart, key(f.Name))
+			return
+		}
+		r.current.set(current, value, next.source(f.Name), "reload")
+		changed = append(changed, key(f.Name))
+	})
+	if len(restart) > 0 {
+		slog.Warn("Configuration changes need a restart to take effect", "settings", restart)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	for _, notify := range r.subscribers {
+		notify(&old, r.current)
+	}
+	slog.Info("Configuration reloaded", "changed", changed)
+	return nil
+}
+
+// Start reloads the configuration in the background on SIGHUP and, if it was read from
+// a file and ConfigWatchInterval is positive, whenever the file's modification time or
+// size changes, checked every ConfigWatchInterval.
+func (r *Reloader) Start() {
+	ctx, stop := context.WithCancel(context.Background())
+	r.stop = stop
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	file, interval := r.current.File, r.current.ConfigWatchInterval
+	var tick <-chan time.Time
+	if file != "" && interval > 0 {
+		ticker := time.NewTicker(interval)
+		context.AfterFunc(ctx, ticker.Stop)
+		tick = ticker.C
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer signal.Stop(hup)
+		state := statFile(file)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				r.reload("SIGHUP")
+			case <-tick:
+				if s := statFile(file); s != state {
+					state = s
+					r.reload("file change")
+				}
+			}
+		}
+	}()
+}
+
+// Stop ends the reloading started by Start and waits for a reload in progress to finish.
+func (r *Reloader) Stop() {
+	if r.stop != nil {
+		r.stop()
+	}
+	r.wg.Wait()
+}
+
+// reload runs Reload and logs a rejected configuration, naming what triggered the reload.
+func (r *Reloader) reload(trigger string) {
+	if err := r.Reload(); err != nil {
+		slog.Error("Configuration reload rejected, keeping the current configuration", "trigger", trigger, "error", err)
+	}
+}
+
+// fileState identifies a version of a file; it is zero if the file cannot be read.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// statFile returns the state of the file at path.
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}
+}
+
+// source returns where the value of the named setting came from.
+func (cfg *Config) source(name string) string {
+	if source, ok := cfg.sources[name]; ok {
+		return source
+	}
+	return SourceDefault
+}
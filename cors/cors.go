@@ -0,0 +1,98 @@
+// Package cors lets browser applications served from other origins, such as a dispatcher
+// console, call the API: it answers CORS preflight requests and marks the responses to
+// allowed origins. The allowed origins can be replaced while the server runs.
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// Any is the origin that allows every origin.
+const Any = "*"
+
+// The headers a cross-origin caller may send and read, and how long a browser may cache a preflight.
+const (
+	allowMethods  = "GET, POST, PUT, PATCH, DELETE"
+	allowHeaders  = "Authorization, Content-Type, If-Match, X-Request-ID, traceparent"
+	exposeHeaders = "ETag, X-Request-ID, Retry-After"
+	maxAge        = "600"
+)
+
+// CORS allows cross-origin requests from a set of origins.
+type CORS struct {
+	origins atomic.Pointer[[]string]
+}
+
+// New returns a CORS allowing the given origins; with none, no cross-origin request is allowed.
+func New(origins []string) *CORS {
+	c := &CORS{}
+	c.SetOrigins(origins)
+	return c
+}
+
+// SetOrigins replaces the allowed origins.
+func (c *CORS) SetOrigins(origins []string) {
+	c.origins.Store(&origins)
+}
+
+// ParseOrigins splits a comma-separated list of origins, such as
+// "https://console.example.com,http://localhost:3000", and checks that each is Any or
+// a scheme and host without a path.
+func ParseOrigins(s string) ([]string, error) {
+	var origins []string
+	for _, origin := range strings.Split(s, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+		if origin != Any {
+			u, err := url.Parse(origin)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+				return nil, fmt.Errorf("%q is not an origin such as https://example.com", origin)
+			}
+			origin = u.Scheme + "://" + strings.ToLower(u.Host)
+		}
+		origins = append(origins, origin)
+	}
+	return origins, nil
+}
+
+// Middleware returns next wrapped to allow requests from the allowed origins. A preflight
+// request from an allowed origin is answered with HTTP 204 without reaching next; other
+// requests are passed on, with the CORS headers added if their origin is allowed.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		if !c.allowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+			w.Header().Set("Access-Control-Max-Age", maxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowed reports whether requests from origin are allowed.
+func (c *CORS) allowed(origin string) bool {
+	origins := *c.origins.Load()
+	return slices.Contains(origins, Any) || slices.Contains(origins, strings.ToLower(origin))
+}
@@ -1,86 +1,96 @@
 // Package database provides database connection management and initialization for the taxi service.
-// It handles PostgreSQL database connections, table creation, and provides a global database instance.
+// It handles PostgreSQL database connections, versioned schema migrations, and provides a global database instance.
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// Config holds the connection pool and query settings.
+type Config struct {
+	// MaxOpenConns limits the open connections, in use or idle; 0 means no limit
+	MaxOpenConns int
+	// MaxIdleConns is how many idle connections are kept for reuse
+	MaxIdleConns int
+	// ConnMaxLifetime is how long a connection is reused before it is replaced; 0 means forever
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime is how long a connection may stay idle before it is closed; 0 means forever
+	ConnMaxIdleTime time.Duration
+	// StatementTimeout makes PostgreSQL cancel statements running longer; 0 means no limit
+	StatementTimeout time.Duration
+}
+
+// Validate reports the first setting that would make the pool misbehave.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.MaxOpenConns < 0, cfg.MaxIdleConns < 0:
+		return errors.New("connection limits must not be negative")
+	case cfg.MaxOpenConns > 0 && cfg.MaxIdleConns > cfg.MaxOpenConns:
+		return errors.New("idle connections must not exceed open connections")
+	case cfg.ConnMaxLifetime < 0, cfg.ConnMaxIdleTime < 0:
+		return errors.New("connection lifetimes must not be negative")
+	case cfg.StatementTimeout < 0:
+		return errors.New("statement timeout must not be negative")
+	}
+	return nil
+}
+
 // DB is the global database connection instance used throughout the application.
-// It is initialized by InitDB and should be closed using CloseDB when the application shuts down.
+// It is initialized by InitDB or OpenDB and should be closed using CloseDB when the application shuts down.
 var DB *sql.DB
 
-// InitDB initializes the database connection using the provided data source name.
-// It opens a PostgreSQL connection, verifies connectivity with a ping,
-// and creates the necessary tables for the taxi service.
-// Returns an error if the connection fails or table creation fails.
-func InitDB(dataSourceName string) error {
-	var err error
-	DB, err = sql.Open("postgres", dataSourceName)
+// OpenDB opens a PostgreSQL connection pool using the provided data source name, sized
+// and timed out as cfg says, and verifies connectivity with a ping, without touching the schema.
+// A statement_timeout given in the data source name takes precedence over cfg.StatementTimeout.
+// Returns an error if the connection fails.
+func OpenDB(dataSourceName string, cfg Config) error {
+	dsn, err := withStatementTimeout(dataSourceName, cfg.StatementTimeout)
+	if err != nil {
+		return fmt.Errorf("error parsing database URL: %v", err)
+	}
+	DB, err = sql.Open("postgres", dsn)
 	if err != nil {
 		return fmt.Errorf("error opening database: %v", err)
 	}
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
+	DB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	DB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	if err = DB.Ping(); err != nil {
 		return fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	log.Println("Database connection established")
-	return createTables()
+	slog.Info("Database connection established")
+	return nil
 }
 
-// createTables creates the necessary database tables for the taxi service.
-// It creates three tables: clients, drivers, and cars with their respective schemas.
-// The cars table has a foreign key reference to the drivers table.
-// Returns an error if any table creation fails.
-func createTables() error {
-	clientsTable := `
-	CREATE TABLE IF NOT EXISTS clients (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(255) NOT NULL,
-		phone VARCHAR(50) NOT NULL,
-		email VARCHAR(255) NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	driversTable := `
-	CREATE TABLE IF NOT EXISTS drivers (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(255) NOT NULL,
-		phone VARCHAR(50) NOT NULL,
-		license_number VARCHAR(50) NOT NULL,
-		rating REAL DEFAULT 0.0,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	carsTable := `
-	CREATE TABLE IF NOT EXISTS cars (
-		id SERIAL PRIMARY KEY,
-		driver_id INTEGER NOT NULL,
-		brand VARCHAR(100) NOT NULL,
-		model VARCHAR(100) NOT NULL,
-		year INTEGER NOT NULL,
-		license_plate VARCHAR(50) NOT NULL,
-		color VARCHAR(50) NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (driver_id) REFERENCES drivers(id)
-	);`
+// InitDB initializes the database connection using the provided data source name and settings.
+// It opens a PostgreSQL connection, verifies connectivity with a ping,
+// and applies all pending schema migrations (see Migrator).
+// Returns an error if the connection fails or a migration fails.
+func InitDB(dataSourceName string, cfg Config) error {
+	if err := OpenDB(dataSourceName, cfg); err != nil {
+		return err
+	}
 
-	tables := []string{clientsTable, driversTable, carsTable}
-	for _, table := range tables {
-		if _, err := DB.Exec(table); err != nil {
-			return fmt.Errorf("error creating table: %v", err)
-		}
+	migrator, err := NewMigrator(DB)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		return err
 	}
 
-	log.Println("Database tables created successfully")
+	slog.Info("Database schema is up to date")
 	return nil
 }
 
@@ -92,3 +102,19 @@ func CloseDB() {
 		DB.Close()
 	}
 }
+
+// withStatementTimeout returns dataSourceName with timeout set as the statement_timeout
+// run-time parameter of every connection, converting a postgres:// URL to the key=value
+// form first. Parameters given later in the result win, so the timeout goes first.
+func withStatementTimeout(dataSourceName string, timeout time.Duration) (string, error) {
+	if timeout == 0 {
+		return dataSourceName, nil
+	}
+	if strings.HasPrefix(dataSourceName, "postgres://") || strings.HasPrefix(dataSourceName, "postgresql://") {
+		var err error
+		if dataSourceName, err = pq.ParseURL(dataSourceName); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("statement_timeout=%d %s", timeout.Milliseconds(), dataSourceName), nil
+}
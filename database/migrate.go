@@ -0,0 +1,303 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationFiles holds the SQL migration scripts shipped with the binary.
+// Each migration is a pair of files named NNNN_description.up.sql and NNNN_description.down.sql.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the PostgreSQL advisory lock key held while migrations run,
+// so that several replicas starting at the same time apply each migration exactly once.
+const migrationLockID = 74_617_869
+
+// migrationFilePattern matches migration file names and captures the version, name and direction.
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	// Version is the unique, increasing number of the migration
+	Version int
+	// Name is the human-readable description taken from the file name
+	Name string
+	// Up is the SQL that applies the migration
+	Up string
+	// Down is the SQL that reverts the migration
+	Down string
+}
+
+// MigrationStatus describes whether a migration has been applied to the database.
+type MigrationStatus struct {
+	Migration
+	// Applied reports whether the migration is recorded in schema_migrations
+	Applied bool
+	// AppliedAt is the timestamp when the migration was applied, if it was
+	AppliedAt *time.Time
+}
+
+// LoadMigrations reads migration scripts from the root of fsys and returns them ordered by version.
+// Every migration must have both an up and a down script.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %v", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %v", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down scripts", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and reverts schema migrations, recording progress in the schema_migrations table.
+// All operations hold a PostgreSQL advisory lock for their duration.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator for db using the migrations embedded in the binary.
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	dir, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest returns the highest known migration version, or 0 if there are no migrations.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the most recently applied migration. It does nothing if no migration is applied.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				return runMigration(ctx, conn, m.migrations[i], false)
+			}
+		}
+		return nil
+	})
+}
+
+// To applies or reverts migrations until the database is at the given version.
+// Version 0 reverts every migration.
+func (m *Migrator) To(ctx context.Context, version int) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return m.migrate(ctx, conn, applied, version)
+	})
+}
+
+// Status returns every known migration together with whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Version returns the highest applied migration version, or 0 if none has been applied.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	var version int
+	err := m.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+// find returns the migration with the given version, or nil if there is none.
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// migrate reverts applied migrations above target in descending order,
+// then applies pending migrations up to target in ascending order.
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, applied map[int]time.Time, target int) error {
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= target {
+			continue
+		}
+		if err := runMigration(ctx, conn, migration, false); err != nil {
+			return err
+		}
+	}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > target {
+			continue
+		}
+		if err := runMigration(ctx, conn, migration, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withLock runs fn on a dedicated connection that holds the migration advisory lock.
+// It creates the schema_migrations table first if it does not exist.
+// The connection has no statement timeout, since waiting for the lock and migrating
+// large tables may take longer than any query should.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error acquiring connection: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+		return fmt.Errorf("error lifting statement timeout: %v", err)
+	}
+	defer conn.ExecContext(context.Background(), "RESET statement_timeout")
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("error acquiring migration lock: %v", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	_, err = conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return fmt.Errorf("error creating schema_migrations table: %v", err)
+	}
+
+	return fn(conn)
+}
+
+// appliedMigrations returns the applied migration versions mapped to the time they were applied.
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// runMigration applies (up) or reverts (down) a single migration in a transaction
+// together with the corresponding schema_migrations bookkeeping.
+func runMigration(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	script, record, args := migration.Down, "DELETE FROM schema_migrations WHERE version = $1", []any{migration.Version}
+	direction := "down"
+	if up {
+		script, record, args = migration.Up, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", []any{migration.Version, migration.Name}
+		direction = "up"
+	}
+	name := fmt.Sprintf("%d_%s (%s)", migration.Version, migration.Name, direction)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("error running migration %s: %v", name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("error recording migration %s: %v", name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing migration %s: %v", name, err)
+	}
+
+	slog.InfoContext(ctx, "Migration applied", "migration", name)
+	return nil
+}
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS clients (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	phone VARCHAR(50) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS drivers (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	phone VARCHAR(50) NOT NULL,
+	license_number VARCHAR(50) NOT NULL,
+	rating REAL DEFAULT 0.0,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS cars (
+	id SERIAL PRIMARY KEY,
+	driver_id INTEGER NOT NULL,
+	brand VARCHAR(100) NOT NULL,
+	model VARCHAR(100) NOT NULL,
+	year INTEGER NOT NULL,
+	license_plate VARCHAR(50) NOT NULL,
+	color VARCHAR(50) NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (driver_id) REFERENCES drivers(id)
+);
+
+-- Databases created before migrations existed already have these tables, with TIMESTAMP
+-- columns that CREATE TABLE IF NOT EXISTS leaves alone. Their values are wall-clock times of
+-- the service's time zone, which the conversion assumes the session time zone to be.
+ALTER TABLE clients ALTER COLUMN created_at TYPE TIMESTAMPTZ, ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+ALTER TABLE drivers ALTER COLUMN created_at TYPE TIMESTAMPTZ, ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+ALTER TABLE cars ALTER COLUMN created_at TYPE TIMESTAMPTZ, ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS rides (
+	id SERIAL PRIMARY KEY,
+	client_id INTEGER NOT NULL,
+	driver_id INTEGER,
+	car_id INTEGER,
+	pickup_lat DOUBLE PRECISION NOT NULL,
+	pickup_lon DOUBLE PRECISION NOT NULL,
+	dropoff_lat DOUBLE PRECISION NOT NULL,
+	dropoff_lon DOUBLE PRECISION NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'requested'
+		CHECK (status IN ('requested', 'accepted', 'in_progress', 'completed', 'cancelled')),
+	fare NUMERIC(10, 2),
+	requested_at TIMESTAMPTZ NOT NULL,
+	accepted_at TIMESTAMPTZ,
+	started_at TIMESTAMPTZ,
+	completed_at TIMESTAMPTZ,
+	cancelled_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (client_id) REFERENCES clients(id),
+	FOREIGN KEY (driver_id) REFERENCES drivers(id),
+	FOREIGN KEY (car_id) REFERENCES cars(id)
+);
+
+-- Surge pricing reads the rides still waiting for a driver on every recomputation.
+CREATE INDEX rides_requested_idx ON rides (requested_at) WHERE status = 'requested';
+
+-- Ride lists are paged by the sort column with the id as a tie-breaker.
+CREATE INDEX rides_requested_at_id_idx ON rides (requested_at, id);
+CREATE INDEX rides_status_id_idx ON rides (status, id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS driver_shifts;
+ALTER TABLE drivers DROP COLUMN IF EXISTS status;
@@ -0,0 +1,18 @@
+ALTER TABLE drivers
+	ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'offline'
+		CHECK (status IN ('offline', 'available', 'on_trip', 'on_break'));
+
+CREATE INDEX drivers_status_idx ON drivers (status);
+
+CREATE TABLE driver_shifts (
+	id SERIAL PRIMARY KEY,
+	driver_id INTEGER NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL,
+	ended_at TIMESTAMPTZ,
+	FOREIGN KEY (driver_id) REFERENCES drivers(id),
+	CHECK (ended_at IS NULL OR ended_at >= started_at)
+);
+
+-- A driver can have at most one open shift.
+CREATE UNIQUE INDEX driver_shifts_open_idx ON driver_shifts (driver_id) WHERE ended_at IS NULL;
+CREATE INDEX driver_shifts_driver_started_id_idx ON driver_shifts (driver_id, started_at, id);
@@ -0,0 +1,11 @@
+CREATE TABLE driver_locations (
+	driver_id INTEGER PRIMARY KEY,
+	lat DOUBLE PRECISION NOT NULL,
+	lon DOUBLE PRECISION NOT NULL,
+	heading DOUBLE PRECISION NOT NULL DEFAULT 0,
+	speed DOUBLE PRECISION NOT NULL DEFAULT 0,
+	recorded_at TIMESTAMPTZ NOT NULL,
+	FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX driver_locations_lat_lon_idx ON driver_locations (lat, lon);
@@ -0,0 +1,6 @@
+ALTER TABLE rides
+	DROP COLUMN tariff_id,
+	DROP COLUMN distance_m,
+	DROP COLUMN duration_s;
+
+DROP TABLE tariffs;
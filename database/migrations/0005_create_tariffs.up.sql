@@ -0,0 +1,31 @@
+CREATE TABLE tariffs (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	base_fare NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	per_km NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	per_minute NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	minimum_fare NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	waiting_per_minute NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	free_waiting_minutes INTEGER NOT NULL DEFAULT 0,
+	night_multiplier NUMERIC(4, 2) NOT NULL DEFAULT 1,
+	night_start_hour INTEGER NOT NULL DEFAULT 0 CHECK (night_start_hour BETWEEN 0 AND 23),
+	night_end_hour INTEGER NOT NULL DEFAULT 0 CHECK (night_end_hour BETWEEN 0 AND 23),
+	holiday_multiplier NUMERIC(4, 2) NOT NULL DEFAULT 1,
+	holidays DATE[] NOT NULL DEFAULT '{}',
+	timezone VARCHAR(64) NOT NULL DEFAULT 'Europe/Moscow',
+	is_default BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- At most one tariff can be the default.
+CREATE UNIQUE INDEX tariffs_default_idx ON tariffs (is_default) WHERE is_default;
+
+INSERT INTO tariffs (name, base_fare, per_km, per_minute, minimum_fare, waiting_per_minute, free_waiting_minutes,
+	night_multiplier, night_start_hour, night_end_hour, holiday_multiplier, is_default)
+VALUES ('Standard', 99, 12, 8, 199, 10, 3, 1.2, 23, 6, 1.3, TRUE);
+
+ALTER TABLE rides
+	ADD COLUMN tariff_id INTEGER REFERENCES tariffs(id),
+	ADD COLUMN distance_m DOUBLE PRECISION,
+	ADD COLUMN duration_s INTEGER;
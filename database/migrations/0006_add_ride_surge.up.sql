@@ -0,0 +1 @@
+ALTER TABLE rides ADD COLUMN surge_multiplier NUMERIC(4, 2) NOT NULL DEFAULT 1;
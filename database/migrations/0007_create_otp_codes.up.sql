@@ -0,0 +1,11 @@
+CREATE TABLE otp_codes (
+	phone VARCHAR(50) NOT NULL,
+	role VARCHAR(20) NOT NULL CHECK (role IN ('client', 'driver')),
+	code_hash VARCHAR(64) NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	sent_at TIMESTAMPTZ NOT NULL,
+	send_count INTEGER NOT NULL DEFAULT 1,
+	window_started_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (phone, role)
+);
@@ -0,0 +1,9 @@
+DROP INDEX cars_driver_id_idx;
+DROP INDEX cars_created_at_id_idx;
+DROP INDEX cars_year_id_idx;
+DROP INDEX cars_brand_id_idx;
+DROP INDEX drivers_created_at_id_idx;
+DROP INDEX drivers_rating_id_idx;
+DROP INDEX drivers_name_id_idx;
+DROP INDEX clients_created_at_id_idx;
+DROP INDEX clients_name_id_idx;
@@ -0,0 +1,10 @@
+-- Keyset pagination orders by (field, id); these indexes serve both the ORDER BY and the cursor condition.
+CREATE INDEX clients_name_id_idx ON clients (name, id);
+CREATE INDEX clients_created_at_id_idx ON clients (created_at, id);
+CREATE INDEX drivers_name_id_idx ON drivers (name, id);
+CREATE INDEX drivers_rating_id_idx ON drivers (rating, id);
+CREATE INDEX drivers_created_at_id_idx ON drivers (created_at, id);
+CREATE INDEX cars_brand_id_idx ON cars (brand, id);
+CREATE INDEX cars_year_id_idx ON cars (year, id);
+CREATE INDEX cars_created_at_id_idx ON cars (created_at, id);
+CREATE INDEX cars_driver_id_idx ON cars (driver_id);
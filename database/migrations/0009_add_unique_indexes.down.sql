@@ -0,0 +1,5 @@
+DROP INDEX cars_license_plate_key;
+DROP INDEX drivers_license_number_key;
+DROP INDEX drivers_phone_key;
+DROP INDEX clients_email_key;
+DROP INDEX clients_phone_key;
@@ -0,0 +1,42 @@
+-- Phone numbers, email addresses, license numbers and license plates must be unique.
+-- Values are compared in normalized form (see validate.NormalizePhone,
+-- validate.NormalizeLicensePlate and models.Normalize*): phone numbers without separators
+-- and with a Russian number's trunk prefix 8 replaced by +7, and plates in upper case with
+-- Latin look-alike letters read as Cyrillic and without separators, so "8 (999) 123-45-67"
+-- and "+79991234567", or "a 123 bc.77" and "А123ВС77", count as the same value.
+--
+-- Unique indexes cannot be built over duplicate data, so existing duplicates are
+-- listed first and the migration is aborted until they are merged or corrected.
+DO $$
+DECLARE
+	duplicates TEXT;
+BEGIN
+	SELECT string_agg(format('%s.%s = %L (ids %s)', tbl, field, value, ids), E'\n' ORDER BY tbl, field, value)
+	INTO duplicates
+	FROM (
+		SELECT 'clients' AS tbl, 'phone' AS field, regexp_replace(regexp_replace(phone, '[\s().-]', '', 'g'), '^8(\d{10})$', '+7\1') AS value, string_agg(id::text, ', ' ORDER BY id) AS ids
+		FROM clients GROUP BY 3 HAVING count(*) > 1
+		UNION ALL
+		SELECT 'clients', 'email', lower(btrim(email)), string_agg(id::text, ', ' ORDER BY id)
+		FROM clients WHERE btrim(email) <> '' GROUP BY 3 HAVING count(*) > 1
+		UNION ALL
+		SELECT 'drivers', 'phone', regexp_replace(regexp_replace(phone, '[\s().-]', '', 'g'), '^8(\d{10})$', '+7\1'), string_agg(id::text, ', ' ORDER BY id)
+		FROM drivers GROUP BY 3 HAVING count(*) > 1
+		UNION ALL
+		SELECT 'drivers', 'license_number', upper(regexp_replace(license_number, '[\s-]', '', 'g')), string_agg(id::text, ', ' ORDER BY id)
+		FROM drivers GROUP BY 3 HAVING count(*) > 1
+		UNION ALL
+		SELECT 'cars', 'license_plate', translate(upper(regexp_replace(license_plate, '[\s().-]', '', 'g')), 'ABEKMHOPCTYX', 'АВЕКМНОРСТУХ'), string_agg(id::text, ', ' ORDER BY id)
+		FROM cars GROUP BY 3 HAVING count(*) > 1
+	) d;
+
+	IF duplicates IS NOT NULL THEN
+		RAISE EXCEPTION 'cannot add unique indexes, resolve these duplicates first:%', E'\n' || duplicates;
+	END IF;
+END $$;
+
+CREATE UNIQUE INDEX clients_phone_key ON clients ((regexp_replace(regexp_replace(phone, '[\s().-]', '', 'g'), '^8(\d{10})$', '+7\1')));
+CREATE UNIQUE INDEX clients_email_key ON clients ((lower(btrim(email)))) WHERE btrim(email) <> '';
+CREATE UNIQUE INDEX drivers_phone_key ON drivers ((regexp_replace(regexp_replace(phone, '[\s().-]', '', 'g'), '^8(\d{10})$', '+7\1')));
+CREATE UNIQUE INDEX drivers_license_number_key ON drivers ((upper(regexp_replace(license_number, '[\s-]', '', 'g'))));
+CREATE UNIQUE INDEX cars_license_plate_key ON cars ((translate(upper(regexp_replace(license_plate, '[\s().-]', '', 'g')), 'ABEKMHOPCTYX', 'АВЕКМНОРСТУХ')));
@@ -0,0 +1,20 @@
+-- Reverting makes deleted rows visible again. The unique indexes are rebuilt over all
+-- rows, which fails if a deleted row shares a value with a live one.
+DROP INDEX cars_deleted_at_idx;
+DROP INDEX drivers_deleted_at_idx;
+DROP INDEX clients_deleted_at_idx;
+
+DROP INDEX cars_license_plate_key;
+DROP INDEX drivers_license_number_key;
+DROP INDEX drivers_phone_key;
+DROP INDEX clients_email_key;
+DROP INDEX clients_phone_key;
+CREATE UNIQUE INDEX clients_phone_key ON clients ((regexp_replace(regexp_replace(phone, '[\s().-]', '', 'g'), '^8(\d{10})$', '+7\1')));
+CREATE UNIQUE INDEX clients_email_key ON clients ((lower(btrim(email)))) WHERE btrim(email) <> '';
+CREATE UNIQUE INDEX drivers_phone_key ON drivers ((regexp_replace(regexp_replace(phone, '[\s().-]', '', 'g'), '^8(\d{10})$', '+7\1')));
+CREATE UNIQUE INDEX drivers_license_number_key ON drivers ((upper(regexp_replace(license_number, '[\s-]', '', 'g'))));
+CREATE UNIQUE INDEX cars_license_plate_key ON cars ((translate(upper(regexp_replace(license_plate, '[\s().-]', '', 'g')), 'ABEKMHOPCTYX', 'АВЕКМНОРСТУХ')));
+
+ALTER TABLE cars DROP COLUMN deleted_at;
+ALTER TABLE drivers DROP COLUMN deleted_at;
+ALTER TABLE clients DROP COLUMN deleted_at;
@@ -0,0 +1,23 @@
+-- Deleting a client, driver or car only sets deleted_at; the row is kept for ride
+-- history and disputes until it is purged after the retention period.
+ALTER TABLE clients ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE drivers ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE cars ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Deleted rows give up their unique values, so a new record may reuse them.
+-- Restoring a row whose value has been taken since fails with a unique violation.
+DROP INDEX clients_phone_key;
+DROP INDEX clients_email_key;
+DROP INDEX drivers_phone_key;
+DROP INDEX drivers_license_number_key;
+DROP INDEX cars_license_plate_key;
+CREATE UNIQUE INDEX clients_phone_key ON clients ((regexp_replace(regexp_replace(phone, '[\s().-]', '', 'g'), '^8(\d{10})$', '+7\1'))) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX clients_email_key ON clients ((lower(btrim(email)))) WHERE btrim(email) <> '' AND deleted_at IS NULL;
+CREATE UNIQUE INDEX drivers_phone_key ON drivers ((regexp_replace(regexp_replace(phone, '[\s().-]', '', 'g'), '^8(\d{10})$', '+7\1'))) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX drivers_license_number_key ON drivers ((upper(regexp_replace(license_number, '[\s-]', '', 'g')))) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX cars_license_plate_key ON cars ((translate(upper(regexp_replace(license_plate, '[\s().-]', '', 'g')), 'ABEKMHOPCTYX', 'АВЕКМНОРСТУХ'))) WHERE deleted_at IS NULL;
+
+-- The purge job looks up rows by deletion time.
+CREATE INDEX clients_deleted_at_idx ON clients (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX drivers_deleted_at_idx ON drivers (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX cars_deleted_at_idx ON cars (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,17 @@
+-- Every change made through the API to a client, driver, car, ride or tariff, written in the
+-- same transaction as the change. There are no foreign keys: events outlive purged records.
+CREATE TABLE audit_events (
+	id SERIAL PRIMARY KEY,
+	actor_id INTEGER,
+	actor_role VARCHAR(20) NOT NULL,
+	entity_type VARCHAR(20) NOT NULL,
+	entity_id INTEGER NOT NULL,
+	action VARCHAR(20) NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+	before JSONB,
+	after JSONB,
+	request_id VARCHAR(128) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+-- GET /api/audit?entity=...&id=... pages through one record's history.
+CREATE INDEX audit_events_entity_idx ON audit_events (entity_type, entity_id, id);
@@ -0,0 +1,408 @@
+// Package dispatch matches requested rides to drivers.
+//
+// When a ride is requested, the Dispatcher selects candidate drivers (available, owning a car,
+// near the pickup point and rated at least the configured minimum), ranks them with a pluggable
+// Strategy and offers the ride to one candidate at a time. A candidate who declines, or does not
+// answer within the offer timeout, is skipped and the next one receives the offer.
+//
+// Offers are held in memory by the replica that received the ride request. On startup a
+// replica resumes dispatching every ride still requested, so rides survive a restart; with
+// several replicas a ride may then be offered by two of them, and the first acceptance wins.
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// Errors returned by Respond and Assign.
+var (
+	// ErrOfferNotFound means the offer does not exist or is no longer pending
+	ErrOfferNotFound = errors.New("offer not found")
+	// ErrNotOfferedDriver means a driver tried to answer an offer made to someone else
+	ErrNotOfferedDriver = errors.New("offer was made to another driver")
+	// ErrCarNotOwned means the car used to accept a ride does not belong to the driver
+	ErrCarNotOwned = errors.New("car does not belong to driver")
+	// ErrDriverUnavailable means the driver is not in the available status
+	ErrDriverUnavailable = errors.New("driver is not available")
+	// ErrRideNotOpen means the ride is no longer waiting for a driver
+	ErrRideNotOpen = errors.New("ride is no longer open for acceptance")
+)
+
+// defaultLocationMaxAge is used when Config.LocationMaxAge is zero.
+const defaultLocationMaxAge = 2 * time.Minute
+
+// Config holds the dispatch tuning parameters.
+type Config struct {
+	// Radius is the search radius around the pickup point in meters
+	Radius float64
+	// MinRating is the lowest driver rating that still receives offers
+	MinRating float64
+	// OfferTimeout is how long a driver has to answer an offer
+	OfferTimeout time.Duration
+	// MaxCandidates limits how many drivers are considered per ride; zero means no limit
+	MaxCandidates int
+	// LocationMaxAge is how old a driver's last position may be; zero means two minutes
+	LocationMaxAge time.Duration
+}
+
+// Candidate is a driver who may be offered a ride.
+type Candidate struct {
+	// Driver is the candidate driver
+	Driver models.Driver
+	// Car is the driver's car that will be used for the ride
+	Car models.Car
+	// Location is the driver's latest position
+	Location models.DriverLocation
+	// Distance is the distance from the driver to the pickup point in meters
+	Distance float64
+}
+
+// Offer is a pending proposal for a driver to take a ride.
+type Offer struct {
+	// ID is the unique identifier for the offer
+	ID int `json:"id"`
+	// RideID is the ride being offered
+	RideID int `json:"ride_id"`
+	// DriverID is the driver receiving the offer
+	DriverID int `json:"driver_id"`
+	// CarID is the driver's car that will be used for the ride
+	CarID int `json:"car_id"`
+	// Distance is the distance from the driver to the pickup point in meters
+	Distance float64 `json:"distance_m"`
+	// ExpiresAt is the time after which the offer passes to the next candidate
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// response receives whether the offer was accepted; it is buffered so Respond never blocks
+	response chan bool
+}
+
+// Dispatcher offers requested rides to drivers. It is safe for concurrent use.
+type Dispatcher struct {
+	rides     repository.RideRepository
+	drivers   repository.DriverRepository
+	cars      repository.CarRepository
+	locations repository.LocationRepository
+	strategy  Strategy
+	cfg       Config
+
+	ctx  context.Context
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+
+	mu          sync.Mutex
+	nextOfferID int
+	offers      map[int]*Offer
+	active      map[int]context.CancelFunc
+}
+
+// New returns a Dispatcher that ranks candidates with strategy.
+func New(rides repository.RideRepository, drivers repository.DriverRepository, cars repository.CarRepository,
+	locations repository.LocationRepository, strategy Strategy, cfg Config) *Dispatcher {
+	if cfg.LocationMaxAge == 0 {
+		cfg.LocationMaxAge = defaultLocationMaxAge
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &Dispatcher{
+		rides:       rides,
+		drivers:     drivers,
+		cars:        cars,
+		locations:   locations,
+		strategy:    strategy,
+		cfg:         cfg,
+		ctx:         ctx,
+		stop:        stop,
+		nextOfferID: 1,
+		offers:      make(map[int]*Offer),
+		active:      make(map[int]context.CancelFunc),
+	}
+}
+
+// Dispatch starts offering ride to candidate drivers in the background.
+// It does nothing if the ride is already being dispatched.
+func (d *Dispatcher) Dispatch(ride models.Ride) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.active[ride.ID]; ok || d.ctx.Err() != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(d.ctx)
+	d.active[ride.ID] = cancel
+	d.wg.Add(1)
+	go d.run(ctx, ride)
+}
+
+// Resume dispatches every ride that is still waiting for a driver. Offers live only in
+// memory, so a restarted replica calls it to pick up the rides whose dispatch it lost.
+func (d *Dispatcher) Resume(ctx context.Context) error {
+	rides, _, err := d.rides.List(ctx, repository.RideFilter{Status: models.RideRequested}, repository.Page{})
+	if err != nil {
+		return err
+	}
+	for _, ride := range rides {
+		d.Dispatch(ride)
+	}
+	slog.InfoContext(ctx, "Resumed dispatch of requested rides", "rides", len(rides))
+	return nil
+}
+
+// Cancel stops dispatching the ride and withdraws its pending offer, if any.
+func (d *Dispatcher) Cancel(rideID int) {
+	d.mu.Lock()
+	cancel, ok := d.active[rideID]
+	d.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+// Stop cancels every running dispatch and waits for them to finish.
+func (d *Dispatcher) Stop() {
+	d.stop()
+	d.wg.Wait()
+}
+
+// PendingOffers returns the offers currently awaiting an answer from the given driver.
+func (d *Dispatcher) PendingOffers(driverID int) []Offer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	offers := []Offer{}
+	for _, offer := range d.offers {
+		if offer.DriverID == driverID {
+			offers = append(offers, *offer)
+		}
+	}
+	return offers
+}
+
+// Respond records a driver's answer to an offer. Accepting assigns the ride to the driver
+// as Assign does and returns the updated ride; declining passes the ride to the next candidate.
+func (d *Dispatcher) Respond(ctx context.Context, offerID, driverID int, accept bool) (models.Ride, error) {
+	d.mu.Lock()
+	offer, ok := d.offers[offerID]
+	if !ok {
+		d.mu.Unlock()
+		return models.Ride{}, ErrOfferNotFound
+	}
+	if offer.DriverID != driverID {
+		d.mu.Unlock()
+		return models.Ride{}, ErrNotOfferedDriver
+	}
+	delete(d.offers, offerID)
+	d.mu.Unlock()
+
+	if !accept {
+		offer.response <- false
+		return models.Ride{}, nil
+	}
+	ride, err := d.Assign(ctx, offer.RideID, offer.DriverID, offer.CarID)
+	offer.response <- err == nil
+	return ride, err
+}
+
+// Assign gives a requested ride to a driver and car: the driver moves from available to on_trip
+// and the ride from requested to accepted. Any running dispatch of the ride is stopped.
+// It returns ErrCarNotOwned, ErrDriverUnavailable, ErrRideNotOpen or repository.ErrNotFound
+// if the ride does not exist.
+func (d *Dispatcher) Assign(ctx context.Context, rideID, driverID, carID int) (models.Ride, error) {
+	car, err := d.cars.Get(ctx, carID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && car.DriverID != driverID) {
+		return models.Ride{}, ErrCarNotOwned
+	}
+	if err != nil {
+		return models.Ride{}, err
+	}
+
+	ride, err := d.rides.Get(ctx, rideID)
+	if err != nil {
+		return models.Ride{}, err
+	}
+	if !ride.Status.CanTransitionTo(models.RideAccepted) {
+		return models.Ride{}, ErrRideNotOpen
+	}
+
+	now := time.Now()
+	if err := d.drivers.SetStatus(ctx, driverID, models.DriverAvailable, models.DriverOnTrip, now); err != nil {
+		if errors.Is(err, repository.ErrConflict) || errors.Is(err, repository.ErrNotFound) {
+			return models.Ride{}, ErrDriverUnavailable
+		}
+		return models.Ride{}, err
+	}
+
+	from := ride.Status
+	ride.Status = models.RideAccepted
+	ride.DriverID = &driverID
+	ride.CarID = &carID
+	ride.AcceptedAt = &now
+	ride.UpdatedAt = now
+	if err := d.rides.Transition(ctx, &ride, from); err != nil {
+		// Callers normally run Assign in a transaction that rolls the status change back;
+		// this puts the driver back without one.
+		if err := d.drivers.SetStatus(ctx, driverID, models.DriverOnTrip, models.DriverAvailable, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "Failed to release driver after the ride could not be accepted", "ride_id", rideID, "driver_id", driverID, "error", err)
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			return models.Ride{}, ErrRideNotOpen
+		}
+		return models.Ride{}, err
+	}
+
+	d.Cancel(rideID)
+	return ride, nil
+}
+
+// Candidates returns the drivers eligible for ride, nearest first: available, with a fresh
+// position within the search radius of the pickup point, rated at least MinRating, and owning a car.
+// Only the drivers found near the pickup point are loaded, with their cars in one query.
+func (d *Dispatcher) Candidates(ctx context.Context, ride models.Ride) ([]Candidate, error) {
+	locations, err := d.locations.Nearby(ctx, repository.NearbyQuery{
+		Lat:    ride.PickupLat,
+		Lon:    ride.PickupLon,
+		Radius: d.cfg.Radius,
+		Since:  time.Now().Add(-d.cfg.LocationMaxAge),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) == 0 {
+		return []Candidate{}, nil
+	}
+	ids := make([]int, len(locations))
+	for i, loc := range locations {
+		ids[i] = loc.DriverID
+	}
+
+	available, _, err := d.drivers.List(ctx, repository.DriverFilter{Status: models.DriverAvailable, MinRating: d.cfg.MinRating, IDs: ids}, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]models.Driver, len(available))
+	for _, driver := range available {
+		byID[driver.ID] = driver
+	}
+
+	cars, _, err := d.cars.List(ctx, repository.CarFilter{DriverIDs: ids}, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	// The cars are ordered by ID, so each driver gets the car they registered first
+	carOf := make(map[int]models.Car, len(cars))
+	for _, car := range cars {
+		if _, ok := carOf[car.DriverID]; !ok {
+			carOf[car.DriverID] = car
+		}
+	}
+
+	candidates := []Candidate{}
+	for _, loc := range locations {
+		driver, ok := byID[loc.DriverID]
+		if !ok {
+			continue
+		}
+		car, ok := carOf[driver.ID]
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, Candidate{Driver: driver, Car: car, Location: loc.DriverLocation, Distance: loc.Distance})
+		if d.cfg.MaxCandidates > 0 && len(candidates) == d.cfg.MaxCandidates {
+			break
+		}
+	}
+	return candidates, nil
+}
+
+// run offers ride to each ranked candidate in turn until one accepts, the ride stops being
+// requested, the candidates run out, or ctx is cancelled.
+func (d *Dispatcher) run(ctx context.Context, ride models.Ride) {
+	defer d.wg.Done()
+	defer func() {
+		d.mu.Lock()
+		d.active[ride.ID]()
+		delete(d.active, ride.ID)
+		d.mu.Unlock()
+	}()
+
+	candidates, err := d.Candidates(ctx, ride)
+	if err != nil {
+		slog.ErrorContext(ctx, "Dispatch failed", "ride_id", ride.ID, "error", err)
+		return
+	}
+
+	for _, candidate := range d.strategy.Rank(ride, candidates) {
+		current, err := d.rides.Get(ctx, ride.ID)
+		if err != nil || current.Status != models.RideRequested {
+			return
+		}
+
+		offer := d.makeOffer(ride.ID, candidate)
+		if offer == nil {
+			continue
+		}
+		if d.await(ctx, offer) {
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+	slog.InfoContext(ctx, "No driver accepted the ride", "ride_id", ride.ID)
+}
+
+// makeOffer registers a pending offer of the ride to candidate.
+// It returns nil if the candidate is already considering another offer.
+func (d *Dispatcher) makeOffer(rideID int, candidate Candidate) *Offer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, offer := range d.offers {
+		if offer.DriverID == candidate.Driver.ID {
+			return nil
+		}
+	}
+	offer := &Offer{
+		ID:        d.nextOfferID,
+		RideID:    rideID,
+		DriverID:  candidate.Driver.ID,
+		CarID:     candidate.Car.ID,
+		Distance:  candidate.Distance,
+		ExpiresAt: time.Now().Add(d.cfg.OfferTimeout),
+		response:  make(chan bool, 1),
+	}
+	d.nextOfferID++
+	d.offers[offer.ID] = offer
+	return offer
+}
+
+// await waits for an answer to offer and reports whether it was accepted.
+// If the offer times out or ctx is cancelled first, the offer is withdrawn.
+func (d *Dispatcher) await(ctx context.Context, offer *Offer) bool {
+	timer := time.NewTimer(time.Until(offer.ExpiresAt))
+	defer timer.Stop()
+
+	select {
+	case accepted := <-offer.response:
+		return accepted
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	_, pending := d.offers[offer.ID]
+	delete(d.offers, offer.ID)
+	d.mu.Unlock()
+
+	if pending {
+		return false
+	}
+	// Respond took the offer just before it was withdrawn; wait for its outcome.
+	return <-offer.response
+}
@@ -0,0 +1,102 @@
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository/memory"
+)
+
+// testFleet holds the repositories of a dispatcher under test.
+type testFleet struct {
+	rides     *memory.RideRepository
+	drivers   *memory.DriverRepository
+	cars      *memory.CarRepository
+	locations *memory.LocationRepository
+	// n numbers the drivers and cars added, to make their phone numbers and plates unique
+	n int
+}
+
+func newTestFleet() *testFleet {
+	return &testFleet{
+		rides:     memory.NewRideRepository(),
+		drivers:   memory.NewDriverRepository(),
+		cars:      memory.NewCarRepository(),
+		locations: memory.NewLocationRepository(),
+	}
+}
+
+func (f *testFleet) dispatcher(t *testing.T) *Dispatcher {
+	d := New(f.rides, f.drivers, f.cars, f.locations, NearestFirst{}, Config{Radius: 3000, OfferTimeout: time.Minute})
+	t.Cleanup(d.Stop)
+	return d
+}
+
+// addDriver stores a driver in status at lat, lon with the given number of cars and returns its ID.
+func (f *testFleet) addDriver(t *testing.T, status models.DriverStatus, lat, lon float64, cars int) int {
+	t.Helper()
+	ctx := context.Background()
+	f.n++
+	driver := models.Driver{Name: "Ivan", Phone: fmt.Sprintf("+7999000%04d", f.n), LicenseNumber: fmt.Sprintf("77AB%06d", f.n), Status: status}
+	if err := f.drivers.Create(ctx, &driver); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < cars; i++ {
+		f.n++
+		car := models.Car{DriverID: driver.ID, Brand: "Kia", Model: "Rio", Year: 2020, LicensePlate: fmt.Sprintf("А%03dВС77", f.n), Color: "white"}
+		if err := f.cars.Create(ctx, &car); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.locations.Save(ctx, models.DriverLocation{DriverID: driver.ID, Lat: lat, Lon: lon, RecordedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	return driver.ID
+}
+
+func TestCandidates(t *testing.T) {
+	f := newTestFleet()
+	far := f.addDriver(t, models.DriverAvailable, 55.7600, 37.6200, 1)
+	near := f.addDriver(t, models.DriverAvailable, 55.7510, 37.6180, 2)
+	f.addDriver(t, models.DriverOnTrip, 55.7500, 37.6170, 1)
+	f.addDriver(t, models.DriverAvailable, 55.7505, 37.6175, 0)
+	f.addDriver(t, models.DriverAvailable, 55.9000, 37.8000, 1)
+
+	candidates, err := f.dispatcher(t).Candidates(context.Background(), models.Ride{PickupLat: 55.75, PickupLon: 37.617})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 2 || candidates[0].Driver.ID != near || candidates[1].Driver.ID != far {
+		t.Fatalf("candidates = %+v, want drivers %d and %d", candidates, near, far)
+	}
+	if candidates[0].Car.DriverID != near || candidates[0].Car.ID != 2 {
+		t.Errorf("car of driver %d = %+v, want their first car", near, candidates[0].Car)
+	}
+}
+
+func TestResume(t *testing.T) {
+	f := newTestFleet()
+	driver := f.addDriver(t, models.DriverAvailable, 55.7510, 37.6180, 1)
+	ride := models.Ride{ClientID: 1, PickupLat: 55.75, PickupLon: 37.617, Status: models.RideRequested, RequestedAt: time.Now()}
+	if err := f.rides.Create(context.Background(), &ride); err != nil {
+		t.Fatal(err)
+	}
+
+	d := f.dispatcher(t)
+	if err := d.Resume(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(d.PendingOffers(driver)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no offer made for the requested ride after Resume")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if offer := d.PendingOffers(driver)[0]; offer.RideID != ride.ID {
+		t.Errorf("offer = %+v, want one for ride %d", offer, ride.ID)
+	}
+}
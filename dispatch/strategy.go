@@ -0,0 +1,63 @@
+package dispatch
+
+import (
+	"sort"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// Strategy orders the candidate drivers for a ride. The dispatcher offers the ride
+// to candidates in the returned order.
+type Strategy interface {
+	// Rank returns candidates ordered from the most to the least preferred.
+	// It must not modify the input slice.
+	Rank(ride models.Ride, candidates []Candidate) []Candidate
+}
+
+// NearestFirst ranks candidates by distance to the pickup point.
+type NearestFirst struct{}
+
+// Rank orders candidates by ascending distance, breaking ties by driver ID.
+func (NearestFirst) Rank(ride models.Ride, candidates []Candidate) []Candidate {
+	return rankBy(candidates, func(c Candidate) float64 { return c.Distance })
+}
+
+// RatingWeighted ranks candidates by distance scaled by a penalty for a low rating,
+// so a well-rated driver slightly farther away can be preferred over a poorly rated one nearby.
+type RatingWeighted struct {
+	// Weight controls how much the rating matters. With weight w, a driver rated r
+	// is treated as if they were 1 + w*(5-r)/5 times farther away. Zero behaves like NearestFirst.
+	Weight float64
+}
+
+// Rank orders candidates by ascending weighted distance, breaking ties by driver ID.
+func (s RatingWeighted) Rank(ride models.Ride, candidates []Candidate) []Candidate {
+	return rankBy(candidates, func(c Candidate) float64 {
+		return c.Distance * (1 + s.Weight*(5-c.Driver.Rating)/5)
+	})
+}
+
+// NewStrategy returns the strategy with the given name: "nearest" or "rating".
+// The rating strategy uses a weight of 1. It returns false for an unknown name.
+func NewStrategy(name string) (Strategy, bool) {
+	switch name {
+	case "nearest":
+		return NearestFirst{}, true
+	case "rating":
+		return RatingWeighted{Weight: 1}, true
+	}
+	return nil, false
+}
+
+// rankBy returns a copy of candidates sorted by ascending score, breaking ties by driver ID.
+func rankBy(candidates []Candidate, score func(Candidate) float64) []Candidate {
+	ranked := append([]Candidate(nil), candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		si, sj := score(ranked[i]), score(ranked[j])
+		if si != sj {
+			return si < sj
+		}
+		return ranked[i].Driver.ID < ranked[j].Driver.ID
+	})
+	return ranked
+}
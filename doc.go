@@ -3,54 +3,255 @@ Package backend provides a RESTful API server for a taxi service management syst
 
 # Overview
 
-This service manages three core entities in a taxi service:
+This service manages five core entities in a taxi service:
   - Clients: Customers who request taxi rides
   - Drivers: Taxi drivers who provide transportation services
   - Cars: Vehicles associated with drivers
+  - Rides: Trips requested by clients and served by drivers
+  - Tariffs: Price lists that rides are charged by
 
 # Architecture
 
 The application follows a layered architecture:
 
   - main.go: Application entry point and HTTP server setup
-  - config/: Configuration management with environment variable support
-  - database/: PostgreSQL database connection and table management
+  - config/: Configuration layered from defaults, a YAML or TOML file, environment variables
+    and flags, with validation, secret redaction and hot reload
+  - config.go: The "config print" subcommand showing the effective configuration
+  - database/: PostgreSQL database connection and versioned schema migrations
+  - migrate.go: The "migrate" subcommand for managing the schema by hand
+  - token.go: The "token" subcommand for issuing access tokens to staff accounts
+  - auth/: JWT issuing and verification, roles and per-route authorization rules
+  - otp/: One-time SMS codes for phone number login, with pluggable senders
   - models/: Data structure definitions for all entities
-  - handlers/: HTTP request handlers implementing RESTful API endpoints
+  - repository/: Storage interfaces for all entities, with PostgreSQL (repository/postgres)
+    and in-memory (repository/memory) implementations
+  - dispatch/: Matching of requested rides to drivers with pluggable ranking strategies
+  - geo/: Great-circle distance and an in-memory grid index for radius searches
+  - pricing/: Fare calculation from a tariff, shared by estimates and completed rides,
+    and per-zone surge multipliers
+  - validate/: Declarative field rules used by the models' Validate methods
+  - purge/: Background removal of soft-deleted records after the retention period
+  - apierr/: Typed API errors, mapping of storage errors, and the JSON error envelope
+  - requestid/: X-Request-ID propagation
+  - cors/: Cross-origin requests from browser applications on allowed origins
+  - route/: Route template lookup and response recording shared by logging, tracing and metrics
+  - logging/: Structured logging with log/slog and the HTTP access log
+  - tracing/: OpenTelemetry tracing of HTTP requests with W3C trace context propagation
+  - server/: HTTP server with timeouts, readiness tracking and graceful shutdown
+  - health/: Liveness, readiness and detailed health endpoints
+  - metrics/: Prometheus metrics for HTTP routes, database queries and dispatch state
+  - handlers/: HTTP request handlers implementing RESTful API endpoints;
+    each handler receives its repository through a constructor, and the handlers of
+    audited records also an Auditor that writes changes together with their audit events
+
+# Authentication
+
+Every endpoint except the health endpoints, /metrics and the login endpoints requires an access token in the Authorization
+header ("Authorization: Bearer <token>"). Tokens are HS256 JWTs signed with one
+of the keys in JWT_KEYS and name a role and a subject ID:
+
+  - client: a passenger; sees and edits only their own client record and rides
+  - driver: sees and edits only their own driver record, cars, shifts and rides
+  - dispatcher: manages clients, drivers, cars and rides
+  - admin: everything a dispatcher can do, plus deleting records and managing tariffs
+
+Staff tokens are issued with the token subcommand:
+
+	backend token admin
+	backend token -ttl 8h dispatcher 7
+
+A missing, malformed, expired or wrongly signed token gets 401 Unauthorized;
+a valid token without access to the route or record gets 403 Forbidden.
+Keys can be rotated by putting a new key first in JWT_KEYS and keeping the old
+one until the tokens it signed have expired.
+
+Clients and drivers log in with a one-time code sent by SMS to their phone number:
+
+	POST   /api/auth/otp/request - Send a code ({"phone": .., "role": "client" or "driver"})
+	POST   /api/auth/otp/verify  - Exchange the code for a token ({"phone": .., "role": .., "code": .., "name": ..})
+
+A client is registered on their first login with the name given in the request,
+which is then required (422 without it; the code is used up and a new one is needed),
+unless FEATURE_CLIENT_SIGNUP is off, in which case an unknown number gets 403
+Forbidden; a driver must already exist. Codes
+are six digits, valid for OTP_TTL, single-use and stored only as hashes. Sending
+is limited per number to one code per OTP_RESEND_INTERVAL and OTP_MAX_SENDS per
+OTP_SEND_WINDOW (429 with Retry-After), and a code is locked after
+OTP_MAX_ATTEMPTS wrong guesses.
 
 # API Endpoints
 
+## Lists
+
+The client, driver, car, ride, tariff, shift and audit lists are paginated with cursors and return
+
+	{"items": [...], "next_cursor": "..."}
+
+where next_cursor is null on the last page. They accept:
+
+  - limit: page size, 1-500 (default: 50)
+  - sort: a field to order by, prefixed with "-" for descending order (default: id);
+    clients sort by id, name or created_at, drivers by id, name, rating or
+    created_at, cars by id, brand, year or created_at, rides by id or
+    requested_at, tariffs by id or name, shifts by id or started_at, and audit
+    events by id or created_at
+  - cursor: the next_cursor of the previous page, with the same sort and filters
+
+Pages are selected by keyset ((field, id) > (last value, last id)) rather than
+offset, so deep pages are as cheap as the first and rows inserted or deleted
+between requests do not shift them.
+
 ## Client Management
 
-	GET    /api/clients      - List all clients
+	GET    /api/clients      - List clients (?name=.., ?phone=..)
 	GET    /api/clients/{id} - Get client by ID
 	POST   /api/clients      - Create new client
 	PUT    /api/clients/{id} - Update client
-	DELETE /api/clients/{id} - Delete client
+	PATCH  /api/clients/{id} - Update some fields of a client (JSON Merge Patch)
+	DELETE /api/clients/{id} - Delete client (soft, see Deletion)
+	POST   /api/clients/{id}/restore - Restore a deleted client (admin)
 
 ## Driver Management
 
-	GET    /api/drivers      - List all drivers
-	GET    /api/drivers/{id} - Get driver by ID
-	POST   /api/drivers      - Create new driver
-	PUT    /api/drivers/{id} - Update driver
-	DELETE /api/drivers/{id} - Delete driver
+	GET    /api/drivers              - List drivers (?status=available, ?min_rating=..)
+	GET    /api/drivers/{id}         - Get driver by ID
+	POST   /api/drivers              - Create new driver
+	PUT    /api/drivers/{id}         - Update driver
+	PATCH  /api/drivers/{id}         - Update some fields of a driver (JSON Merge Patch)
+	DELETE /api/drivers/{id}         - Delete driver (soft, see Deletion)
+	POST   /api/drivers/{id}/restore - Restore a deleted driver (admin)
+	POST   /api/drivers/{id}/online  - Start a shift, or return from a break
+	POST   /api/drivers/{id}/offline - End the current shift
+	POST   /api/drivers/{id}/break   - Pause taking rides within the current shift
+	GET    /api/drivers/{id}/shifts  - List shifts (?from=..&to=.. as RFC 3339)
+	POST   /api/drivers/{id}/location - Push a GPS ping (lat, lon, heading, speed, timestamp)
+	GET    /api/drivers/nearby       - Available drivers near ?lat=..&lon=..&radius=.. (meters), nearest first
+	GET    /api/drivers/{id}/offers  - Ride offers awaiting the driver's answer
+
+A driver is offline, available, on_trip or on_break. Accepting a ride moves the
+driver from available to on_trip; completing or cancelling it moves them back in
+the same transaction, so the ride keeps its status if the driver cannot be released.
 
 ## Car Management
 
-	GET    /api/cars         - List all cars
+	GET    /api/cars         - List cars (?driver_id=.., ?brand=.., ?year=..)
 	GET    /api/cars/{id}    - Get car by ID
 	POST   /api/cars         - Create new car
 	PUT    /api/cars/{id}    - Update car
-	DELETE /api/cars/{id}    - Delete car
+	PATCH  /api/cars/{id}    - Update some fields of a car (JSON Merge Patch)
+	DELETE /api/cars/{id}    - Delete car (soft, see Deletion)
+	POST   /api/cars/{id}/restore - Restore a deleted car (admin)
+
+## Ride Management
+
+	GET    /api/rides               - List rides (?status=requested)
+	GET    /api/rides/{id}          - Get ride by ID
+	POST   /api/rides               - Request a new ride (optional tariff_id, else the default tariff)
+	POST   /api/rides/{id}/accept   - Driver accepts a requested ride
+	POST   /api/rides/{id}/start    - Driver picks up the client
+	POST   /api/rides/{id}/complete - Driver drops off the client (optional distance_m, waiting_s)
+	POST   /api/rides/{id}/cancel   - Cancel a requested or accepted ride
+
+Ride status moves through a fixed state machine:
+
+	requested -> accepted -> in_progress -> completed
+	requested -> cancelled
+	accepted  -> cancelled
+
+Any other transition is rejected with 409 Conflict. The fare of a completed ride
+is calculated from its tariff, the time between start and completion, and the
+reported distance (or the estimated road distance between pickup and drop-off).
+
+## Dispatch
+
+A newly requested ride is dispatched automatically. Candidate drivers are
+available, have a fresh position within DISPATCH_RADIUS of the pickup point,
+are rated at least DISPATCH_MIN_RATING and own a car. They are ranked by the
+configured strategy (nearest-first or rating-weighted) and offered the ride one
+at a time; a driver who declines or does not answer within DISPATCH_OFFER_TIMEOUT
+is skipped. Offers are kept in memory, so on startup every ride still requested
+is dispatched again.
+
+	POST   /api/offers/{id}/accept  - Driver takes the offered ride ({"driver_id": ..})
+	POST   /api/offers/{id}/decline - Driver turns the offer down ({"driver_id": ..})
+
+## Tariffs and Fares
+
+	GET    /api/tariffs         - List tariffs
+	GET    /api/tariffs/{id}    - Get tariff by ID
+	POST   /api/tariffs         - Create new tariff
+	PUT    /api/tariffs/{id}    - Update tariff
+	DELETE /api/tariffs/{id}    - Delete tariff (409 if rides use it)
+	POST   /api/fares/estimate  - Itemised fare for a pickup/drop-off pair
+	GET    /api/surge           - Surge state of the zone containing ?lat=..&lon=..
+
+A fare is base_fare + per_km * km + per_minute * minutes + waiting_per_minute *
+minutes waited beyond free_waiting_minutes, scaled by the night or holiday
+multiplier (the larger one if both apply, judged in the tariff's time zone)
+and raised to minimum_fare if lower. Estimates assume a road distance of 1.3x
+the straight line and an average speed of 25 km/h.
+
+The result is then multiplied by the surge multiplier of the pickup zone. The
+map is divided into square zones of SURGE_ZONE_SIZE degrees; every
+SURGE_INTERVAL each zone's target multiplier is computed from the ride
+requests made there within SURGE_WINDOW that are still waiting for a driver
+and the available drivers there:
+
+	target = min(SURGE_CAP, max(1, 1 + SURGE_SENSITIVITY * (requests / drivers - 1)))
+
+The published multiplier moves towards the target by SURGE_SMOOTHING per
+recomputation and is rounded to 0.1, so prices do not flap. A ride keeps the
+multiplier in effect when it was requested.
+
+## Audit Log
+
+	GET    /api/audit           - Audit events (admin), filtered by ?entity=..&id=..
 
 ## Health Check
 
-	GET    /health           - Service health status
+	GET    /livez            - Liveness: always 200 while the process answers
+	GET    /readyz           - Readiness: per-dependency checks (PostgreSQL ping, shutdown), 200 or 503
+	GET    /health           - Readiness checks plus build version, uptime, migration version
+	                           and connection pool statistics
+
+The build version is set with go build -ldflags "-X main.version=1.2.3" and defaults to "dev".
+
+## Metrics
+
+	GET    /metrics          - Prometheus metrics
+
+On SIGINT or SIGTERM the server shuts down gracefully: /readyz starts failing at once so
+load balancers stop routing requests to it, after SHUTDOWN_DELAY it stops accepting
+connections, and it waits up to SHUTDOWN_GRACE_PERIOD for the requests in flight before
+stopping the background workers and closing the database. A second signal exits immediately.
 
 # Configuration
 
-The service uses environment variables for configuration:
+Every setting has a default, overridden in turn by a YAML or TOML config file (named by
+the -config flag or CONFIG_FILE), an environment variable and a command line flag. The file
+key of a setting is its environment variable in lower case and the flag the same with dashes,
+e.g. http_read_timeout, HTTP_READ_TIMEOUT and -http-read-timeout. An empty environment
+variable counts as unset. Flags go before the subcommand:
+
+	backend -config config.yaml -log-level debug
+	backend -config config.yaml config print   - effective settings and where each came from
+
+All settings are validated at startup and every problem, such as an unknown file key or a
+negative timeout, is reported together before the server refuses to start. The effective
+configuration is logged at startup; the DATABASE_URL password and the JWT_KEYS secrets are
+redacted there and in config print.
+
+## Reload
+
+On SIGHUP, and when the config file changes (checked every CONFIG_WATCH_INTERVAL), the
+configuration is loaded and validated again from the same flags, file and environment.
+The log level, the OTP limits (OTP_TTL, OTP_RESEND_INTERVAL, OTP_MAX_SENDS, OTP_SEND_WINDOW,
+OTP_MAX_ATTEMPTS), the FEATURE_* flags and CORS_ORIGINS take effect at once; changes to
+other settings are logged as needing a restart. An invalid configuration is rejected with
+an error in the log and the running one is kept.
+
+The settings, by their environment variables:
 
 Database Configuration:
   - DATABASE_URL: Complete PostgreSQL connection string
@@ -60,12 +261,85 @@ Database Configuration:
   - DB_PASSWORD: Database password (default: postgres)
   - DB_NAME: Database name (default: taxi)
   - DB_SSLMODE: SSL mode (default: disable)
+  - DB_MAX_OPEN_CONNS: Maximum open connections, 0 for no limit (default: 25)
+  - DB_MAX_IDLE_CONNS: Idle connections kept for reuse (default: 10)
+  - DB_CONN_MAX_LIFETIME: Time after which a connection is replaced (default: 30m)
+  - DB_CONN_MAX_IDLE_TIME: Idle time after which a connection is closed (default: 5m)
+  - DB_STATEMENT_TIMEOUT: PostgreSQL statement_timeout of every connection, 0 for none (default: 10s);
+    one set in DATABASE_URL takes precedence. Migrations run without it.
 
 Server Configuration:
   - SERVER_PORT: HTTP server port (default: 8080)
+  - HTTP_READ_TIMEOUT: Time limit for reading a request, body included (default: 15s)
+  - HTTP_WRITE_TIMEOUT: Time limit from the end of the request headers to the end of the response (default: 30s)
+  - HTTP_IDLE_TIMEOUT: How long a keep-alive connection waits for the next request (default: 2m)
+  - SHUTDOWN_DELAY: How long the server keeps serving after SIGTERM while /readyz fails (default: 5s)
+  - SHUTDOWN_GRACE_PERIOD: How long requests in flight then have to finish (default: 20s)
+  - HEALTH_CHECK_TIMEOUT: Time limit for the dependency checks of /readyz and /health (default: 2s)
+  - LOG_FORMAT: Log output, "json" or "text" (default: json)
+  - LOG_LEVEL: Lowest level logged: debug, info, warn or error (default: info)
+  - TRACING_EXPORTER: Where trace spans are sent: otlp, stdout or none (default: none)
+  - TRACING_ENDPOINT: OTLP/HTTP collector URL (default: OTEL_EXPORTER_OTLP_* or http://localhost:4318)
+  - TRACING_SAMPLE_RATIO: Fraction of new traces recorded (default: 1)
+  - TRACING_SERVICE_NAME: Service name in the traces (default: taxi-backend)
+  - LOCATION_STORE: Where driver positions are kept, "postgres" or "memory" (default: postgres)
+  - CORS_ORIGINS: Origins of browser applications allowed to call the API, separated by
+    commas, or * for any (default: none)
+  - CONFIG_WATCH_INTERVAL: How often the config file is checked for changes to reload,
+    0 to reload only on SIGHUP (default: 10s)
+
+Feature Flags:
+  - FEATURE_SURGE_PRICING: Apply surge multipliers; when off every multiplier is 1 (default: true)
+  - FEATURE_CLIENT_SIGNUP: Register clients on their first login (default: true)
+
+Authentication Configuration:
+  - JWT_KEYS: Token signing keys as name:secret pairs separated by commas, secrets
+    at least 32 bytes; the first key signs. If unset, a random key is generated and
+    tokens become invalid on restart
+  - JWT_TTL: Lifetime of issued tokens (default: 24h)
+
+Phone Login Configuration:
+  - SMS_SENDER: How codes are delivered, "log" (server log) or "file" (default: log)
+  - SMS_FILE: File the "file" sender appends messages to (default: sms.log)
+  - OTP_TTL: Code lifetime (default: 5m)
+  - OTP_RESEND_INTERVAL: Minimum time between codes to one number (default: 60s)
+  - OTP_MAX_SENDS: Codes per number per OTP_SEND_WINDOW (default: 5)
+  - OTP_SEND_WINDOW: Period over which sends are counted (default: 1h)
+  - OTP_MAX_ATTEMPTS: Verification attempts per code (default: 5)
+
+Deletion Configuration:
+  - DELETED_RETENTION: How long deleted clients, drivers and cars are kept before they
+    are purged; 0 keeps them forever (default: 2160h, i.e. 90 days)
+  - PURGE_INTERVAL: How often records past DELETED_RETENTION are purged (default: 1h)
+
+Dispatch Configuration:
+  - DISPATCH_STRATEGY: Candidate ranking, "nearest" or "rating" (default: nearest)
+  - DISPATCH_RADIUS: Driver search radius in meters (default: 3000)
+  - DISPATCH_MIN_RATING: Lowest driver rating that receives offers (default: 0)
+  - DISPATCH_OFFER_TIMEOUT: Time a driver has to answer an offer (default: 15s)
+  - DISPATCH_MAX_CANDIDATES: Maximum number of drivers offered a single ride (default: 10)
+
+Surge Pricing Configuration:
+  - SURGE_ZONE_SIZE: Side of a surge zone in degrees (default: 0.02)
+  - SURGE_WINDOW: How long a ride request waiting for a driver counts as demand (default: 10m)
+  - SURGE_INTERVAL: How often multipliers are recomputed (default: 30s)
+  - SURGE_SENSITIVITY: Multiplier growth per unit of request/driver ratio above 1 (default: 0.5)
+  - SURGE_CAP: Highest multiplier (default: 3)
+  - SURGE_SMOOTHING: Share of the gap to the target closed per recomputation, in (0, 1] (default: 0.3)
 
 # Database Schema
 
+The schema is managed by ordered, versioned migrations embedded in the binary
+(database/migrations/NNNN_name.up.sql and NNNN_name.down.sql). Applied versions
+are recorded in the schema_migrations table. The server applies pending migrations
+on startup while holding a PostgreSQL advisory lock, so replicas starting at the
+same time do not race. Migrations can also be run by hand:
+
+	backend migrate up       - apply all pending migrations
+	backend migrate down     - revert the most recently applied migration
+	backend migrate status   - list migrations and whether they are applied
+	backend migrate to N     - migrate up or down to version N
+
 The service uses PostgreSQL with the following tables:
 
 	clients:
@@ -73,8 +347,8 @@ The service uses PostgreSQL with the following tables:
 	  - name (VARCHAR(255) NOT NULL)
 	  - phone (VARCHAR(50) NOT NULL)
 	  - email (VARCHAR(255) NOT NULL)
-	  - created_at (TIMESTAMP DEFAULT CURRENT_TIMESTAMP)
-	  - updated_at (TIMESTAMP DEFAULT CURRENT_TIMESTAMP)
+	  - created_at (TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP)
+	  - updated_at (TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP)
 
 	drivers:
 	  - id (SERIAL PRIMARY KEY)
@@ -82,8 +356,15 @@ The service uses PostgreSQL with the following tables:
 	  - phone (VARCHAR(50) NOT NULL)
 	  - license_number (VARCHAR(50) NOT NULL)
 	  - rating (REAL DEFAULT 0.0)
-	  - created_at (TIMESTAMP DEFAULT CURRENT_TIMESTAMP)
-	  - updated_at (TIMESTAMP DEFAULT CURRENT_TIMESTAMP)
+	  - status (VARCHAR(20) NOT NULL DEFAULT 'offline')
+	  - created_at (TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP)
+	  - updated_at (TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP)
+
+	driver_shifts:
+	  - id (SERIAL PRIMARY KEY)
+	  - driver_id (INTEGER NOT NULL, FOREIGN KEY to drivers.id)
+	  - started_at (TIMESTAMPTZ NOT NULL)
+	  - ended_at (TIMESTAMPTZ, NULL while the shift is open)
 
 	cars:
 	  - id (SERIAL PRIMARY KEY)
@@ -93,8 +374,62 @@ The service uses PostgreSQL with the following tables:
 	  - year (INTEGER NOT NULL)
 	  - license_plate (VARCHAR(50) NOT NULL)
 	  - color (VARCHAR(50) NOT NULL)
-	  - created_at (TIMESTAMP DEFAULT CURRENT_TIMESTAMP)
-	  - updated_at (TIMESTAMP DEFAULT CURRENT_TIMESTAMP)
+	  - created_at (TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP)
+	  - updated_at (TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP)
+
+	driver_locations:
+	  - driver_id (INTEGER PRIMARY KEY, FOREIGN KEY to drivers.id)
+	  - lat, lon (DOUBLE PRECISION NOT NULL)
+	  - heading, speed (DOUBLE PRECISION NOT NULL DEFAULT 0)
+	  - recorded_at (TIMESTAMPTZ NOT NULL)
+
+	tariffs:
+	  - id (SERIAL PRIMARY KEY)
+	  - name (VARCHAR(100) NOT NULL)
+	  - base_fare, per_km, per_minute, minimum_fare, waiting_per_minute (NUMERIC(10, 2) NOT NULL)
+	  - free_waiting_minutes (INTEGER NOT NULL)
+	  - night_multiplier, holiday_multiplier (NUMERIC(4, 2) NOT NULL DEFAULT 1)
+	  - night_start_hour, night_end_hour (INTEGER NOT NULL, 0-23)
+	  - holidays (DATE[] NOT NULL)
+	  - timezone (VARCHAR(64) NOT NULL DEFAULT 'Europe/Moscow')
+	  - is_default (BOOLEAN NOT NULL, at most one tariff)
+	  - created_at (TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP)
+	  - updated_at (TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP)
+
+	otp_codes:
+	  - phone, role (PRIMARY KEY; role is client or driver)
+	  - code_hash (VARCHAR(64) NOT NULL, SHA-256 of the code)
+	  - expires_at, sent_at, window_started_at (TIMESTAMPTZ NOT NULL)
+	  - attempts, send_count (INTEGER NOT NULL)
+
+	rides:
+	  - id (SERIAL PRIMARY KEY)
+	  - client_id (INTEGER NOT NULL, FOREIGN KEY to clients.id)
+	  - driver_id (INTEGER, FOREIGN KEY to drivers.id)
+	  - car_id (INTEGER, FOREIGN KEY to cars.id)
+	  - pickup_lat, pickup_lon (DOUBLE PRECISION NOT NULL)
+	  - dropoff_lat, dropoff_lon (DOUBLE PRECISION NOT NULL)
+	  - status (VARCHAR(20) NOT NULL DEFAULT 'requested')
+	  - tariff_id (INTEGER, FOREIGN KEY to tariffs.id)
+	  - surge_multiplier (NUMERIC(4, 2) NOT NULL DEFAULT 1)
+	  - fare (NUMERIC(10, 2))
+	  - distance_m (DOUBLE PRECISION), duration_s (INTEGER)
+	  - requested_at (TIMESTAMPTZ NOT NULL)
+	  - accepted_at, started_at, completed_at, cancelled_at (TIMESTAMPTZ)
+	  - created_at (TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP)
+	  - updated_at (TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP)
+
+	audit_events:
+	  - id (SERIAL PRIMARY KEY)
+	  - actor_id (INTEGER), actor_role (VARCHAR(20) NOT NULL)
+	  - entity_type (VARCHAR(20) NOT NULL), entity_id (INTEGER NOT NULL)
+	  - action (VARCHAR(20) NOT NULL: create, update, delete or restore)
+	  - before, after (JSONB)
+	  - request_id (VARCHAR(128) NOT NULL)
+	  - created_at (TIMESTAMPTZ NOT NULL)
+
+Timestamps are stored with their time zone, so they denote the same instant whatever
+the time zones of the service and the database session.
 
 # Usage Example
 
@@ -105,28 +440,128 @@ Starting the server:
 Creating a client:
 
 	curl -X POST http://localhost:8080/api/clients \
+	  -H "Authorization: Bearer $TOKEN" \
 	  -H "Content-Type: application/json" \
 	  -d '{"name":"John Doe","phone":"+1234567890","email":"john@example.com"}'
 
-Listing all drivers:
+Listing the best rated drivers, 20 per page:
 
-	curl http://localhost:8080/api/drivers
+	curl -H "Authorization: Bearer $TOKEN" "http://localhost:8080/api/drivers?limit=20&sort=-rating"
 
 # Dependencies
 
+  - github.com/golang-jwt/jwt/v5: JSON Web Token signing and verification
   - github.com/gorilla/mux: HTTP router and URL matcher
   - github.com/lib/pq: PostgreSQL driver for Go
+  - github.com/prometheus/client_golang: Prometheus metrics and their HTTP handler
+  - go.opentelemetry.io/otel: OpenTelemetry tracing API, SDK and OTLP and stdout exporters
 
 # Error Handling
 
-All endpoints return appropriate HTTP status codes:
-  - 200: Success
-  - 201: Created
-  - 204: No Content (for successful deletions)
-  - 400: Bad Request (invalid input)
-  - 404: Not Found
-  - 500: Internal Server Error (database or server errors)
-
-Error responses include descriptive error messages in the response body.
+Errors are returned as JSON with a stable machine-readable code:
+
+	{"code": "not_found", "message": "Car not found", "details": ..., "request_id": "..."}
+
+details is optional. request_id is also sent in the X-Request-ID header, which is
+taken from the request if the client set one, and is logged with server errors.
+
+  - 400 bad_request: malformed JSON, path or query parameter
+  - 401 unauthorized: missing or invalid access token or login code
+  - 403 forbidden: the caller's role or identity does not allow the request
+  - 404 not_found: unknown record or route
+  - 405 method_not_allowed: the route does not support the method
+  - 409 conflict: illegal status transition, duplicate unique field (named in
+    details as {"field": ..}), record still referenced or changed concurrently
+  - 412 precondition_failed: If-Match does not match the record's current ETag
+  - 415 unsupported_media_type: a PATCH body that is not a JSON Merge Patch
+  - 422 invalid_reference: a referenced record (e.g. a car's driver) does not exist
+  - 422 validation_failed: request fields failed validation (listed in details as
+    {"field": .., "message": ..}) or a value violates a database constraint
+  - 429 rate_limited: login codes requested or guessed too often
+  - 500 internal_error: unexpected failure; the cause is only logged
+  - 503 unavailable: the request could not be completed in time, e.g. a statement
+    exceeded DB_STATEMENT_TIMEOUT
+
+PostgreSQL error messages are never sent to clients.
+
+# Validation
+
+Request bodies are validated before they reach the database; unknown fields and
+values of the wrong type are rejected as well. Clients need a name, a phone
+number and optionally an email address; drivers a name, phone number, license
+number and a rating from 0 to 5; cars a driver, brand, model, color, a model year
+from 1970 to next year and a Russian license plate. Phone numbers are stored in
+E.164 form ("8 (999) 123-45-67" becomes "+79991234567") and plates in upper-case
+Cyrillic without spaces ("a 123 bc 77" becomes "А123ВС77"); both private and
+yellow taxi plate formats are accepted.
+
+# Uniqueness
+
+Client phone numbers and email addresses, driver phone and license numbers, and
+car license plates are unique, compared in normalized form: phone numbers in
+E.164 form, with a leading 8 read as +7, email addresses ignoring case, license numbers ignoring case,
+spaces and dashes, and plates also treating Latin and Cyrillic look-alike
+letters as equal. A duplicate is rejected with 409 naming the field. Migration
+0009 refuses to add the unique indexes while duplicates exist and lists them.
+
+# Partial Updates and ETags
+
+PATCH on a client, driver or car applies a JSON Merge Patch (RFC 7396): fields in
+the patch replace the stored ones, null resets a field, and missing fields keep
+their values; the result is validated like a new record. PUT replaces the whole
+record and returns 404 if it does not exist. Responses carrying a single client,
+driver or car have an ETag derived from updated_at; sending it back in If-Match
+makes PUT or PATCH fail with 412 if the record has changed since. Writes are
+conditional on the updated_at that was read, so a concurrent change is never
+silently overwritten: without If-Match it is reported as 409.
+
+# Deletion
+
+Deleting a client, driver or car sets its deleted_at instead of removing the row,
+so rides keep their history for disputes. Deleted records disappear from lists and
+lookups and no longer hold on to their unique values; admins can see them with
+?include_deleted=true and bring them back with POST .../{id}/restore. Records
+deleted longer than DELETED_RETENTION ago are purged by a background job, except
+those that rides (or, for drivers, cars) still refer to.
+
+# Audit Log
+
+Every create, update, delete and restore of a client, driver, car, ride or tariff made
+through the API stores an audit event in the same transaction as the change, so a
+change is never stored without its event. The event names the caller's account and
+role, the record, its JSON before and after the change, and the X-Request-ID of the
+request. This includes a client registered on their first phone login, with no account
+named, and driver status changes: going online, offline or on break, and being put on
+or released from a trip, which is recorded together with the ride change. A ride is
+recorded when it is requested and on every status change: accepted directly or through a
+dispatch offer, started, completed and cancelled. Admins read the log with GET /api/audit.
+
+# Logging
+
+Logs are written to stderr with log/slog, as JSON by default. Every request produces an
+access log record with its method, route template, path, status, latency, response size
+and request ID, taken from the X-Request-ID header or generated. Records that handlers log
+with the request context, such as database errors, carry the same request_id, which is
+also returned in error responses. Requests to /livez, /readyz and /metrics are logged at
+debug level.
+
+# Tracing
+
+With TRACING_EXPORTER set to otlp or stdout, every request is recorded as an OpenTelemetry
+server span named after its route template, continuing the trace of the caller's W3C
+traceparent header. Every statement run by the PostgreSQL repositories becomes a child
+span named after its operation and table, with the statement text but not its arguments.
+Log records written during a request carry its trace_id and span_id. With the none
+exporter nothing is recorded, but traceparent headers are still understood.
+
+# Metrics
+
+GET /metrics serves Prometheus metrics without authentication, so it should only be
+reachable from inside the network. HTTP requests are counted and timed by method, route
+template (e.g. /api/drivers/{id}) and status; requests matching no route share the
+"unmatched" route. Every statement run by the PostgreSQL repositories is timed by
+operation and table, with a separate error counter, and the connection pool statistics
+are exported as go_sql_* metrics. The taxi_drivers and taxi_rides_active gauges count
+drivers per status and unfinished rides per status with a database query on each scrape.
 */
 package main
@@ -0,0 +1,171 @@
+// Package geo provides great-circle distance calculations and an in-memory spatial index
+// for finding points near a location. Coordinates are WGS 84 degrees and distances are meters.
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// EarthRadius is the mean radius of the Earth in meters.
+const EarthRadius = 6371000.0
+
+// Point is a location on the Earth's surface.
+type Point struct {
+	// Lat is the latitude in degrees
+	Lat float64
+	// Lon is the longitude in degrees
+	Lon float64
+}
+
+// Distance returns the great-circle distance between a and b in meters using the haversine formula.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLon := radians(b.Lon - a.Lon)
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Box is a latitude/longitude rectangle.
+type Box struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// BoundingBox returns a rectangle containing every point within radius meters of center.
+// Near the poles, or when the circle crosses the antimeridian, the box spans all longitudes.
+func BoundingBox(center Point, radius float64) Box {
+	dLat := degrees(radius / EarthRadius)
+	box := Box{
+		MinLat: math.Max(-90, center.Lat-dLat),
+		MaxLat: math.Min(90, center.Lat+dLat),
+		MinLon: -180,
+		MaxLon: 180,
+	}
+	if box.MinLat == -90 || box.MaxLat == 90 {
+		return box
+	}
+
+	dLon := degrees(math.Asin(math.Min(1, math.Sin(radius/EarthRadius)/math.Cos(radians(center.Lat)))))
+	if center.Lon-dLon >= -180 && center.Lon+dLon <= 180 {
+		box.MinLon = center.Lon - dLon
+		box.MaxLon = center.Lon + dLon
+	}
+	return box
+}
+
+// Match is a point found by Index.Within.
+type Match struct {
+	// ID is the identifier the point was stored under
+	ID int
+	// Point is the stored location
+	Point Point
+	// Distance is the distance from the query center in meters
+	Distance float64
+}
+
+// cell identifies a grid cell of an Index.
+type cell struct {
+	lat, lon int
+}
+
+// Index is a uniform latitude/longitude grid of points keyed by an integer ID.
+// Radius queries only inspect the cells overlapping the query's bounding box.
+// Index is not safe for concurrent use.
+type Index struct {
+	cellSize float64
+	points   map[int]Point
+	cells    map[cell]map[int]struct{}
+}
+
+// NewIndex returns an empty Index with square cells of cellSize degrees.
+// A cell size close to the typical query radius works best; 0.01 degrees is roughly 1.1 km.
+func NewIndex(cellSize float64) *Index {
+	return &Index{
+		cellSize: cellSize,
+		points:   make(map[int]Point),
+		cells:    make(map[cell]map[int]struct{}),
+	}
+}
+
+// Set stores or moves the point with the given ID.
+func (ix *Index) Set(id int, p Point) {
+	ix.Remove(id)
+	c := ix.cellOf(p)
+	if ix.cells[c] == nil {
+		ix.cells[c] = make(map[int]struct{})
+	}
+	ix.cells[c][id] = struct{}{}
+	ix.points[id] = p
+}
+
+// Remove deletes the point with the given ID. It does nothing if the ID is not stored.
+func (ix *Index) Remove(id int) {
+	p, ok := ix.points[id]
+	if !ok {
+		return
+	}
+	c := ix.cellOf(p)
+	delete(ix.cells[c], id)
+	if len(ix.cells[c]) == 0 {
+		delete(ix.cells, c)
+	}
+	delete(ix.points, id)
+}
+
+// Len returns the number of stored points.
+func (ix *Index) Len() int {
+	return len(ix.points)
+}
+
+// Within returns the points within radius meters of center, nearest first.
+func (ix *Index) Within(center Point, radius float64) []Match {
+	box := BoundingBox(center, radius)
+	lo := ix.cellOf(Point{Lat: box.MinLat, Lon: box.MinLon})
+	hi := ix.cellOf(Point{Lat: box.MaxLat, Lon: box.MaxLon})
+
+	var matches []Match
+	visit := func(c cell) {
+		for id := range ix.cells[c] {
+			p := ix.points[id]
+			if d := Distance(center, p); d <= radius {
+				matches = append(matches, Match{ID: id, Point: p, Distance: d})
+			}
+		}
+	}
+	if (hi.lat-lo.lat+1)*(hi.lon-lo.lon+1) > len(ix.cells) {
+		// The box covers more cells than are populated, so scanning the populated ones is cheaper.
+		for c := range ix.cells {
+			if c.lat >= lo.lat && c.lat <= hi.lat && c.lon >= lo.lon && c.lon <= hi.lon {
+				visit(c)
+			}
+		}
+	} else {
+		for lat := lo.lat; lat <= hi.lat; lat++ {
+			for lon := lo.lon; lon <= hi.lon; lon++ {
+				visit(cell{lat: lat, lon: lon})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	return matches
+}
+
+// cellOf returns the grid cell containing p.
+func (ix *Index) cellOf(p Point) cell {
+	return cell{
+		lat: int(math.Floor(p.Lat / ix.cellSize)),
+		lon: int(math.Floor(p.Lon / ix.cellSize)),
+	}
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
@@ -0,0 +1,206 @@
+package geo
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64
+	}{
+		{"same point", Point{55.7558, 37.6173}, Point{55.7558, 37.6173}, 0},
+		{"one degree of latitude", Point{0, 0}, Point{1, 0}, 111195},
+		{"across the antimeridian", Point{0, 179.999}, Point{0, -179.999}, 222},
+		{"pole to pole", Point{90, 0}, Point{-90, 0}, math.Pi * EarthRadius},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Distance(tt.a, tt.b); math.Abs(got-tt.want) > 1 {
+				t.Errorf("Distance(%v, %v) = %.1f, want %.1f", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	tests := []struct {
+		name       string
+		center     Point
+		radius     float64
+		allLons    bool
+		wantMinLat float64
+		wantMaxLat float64
+	}{
+		{"mid latitude", Point{55.7558, 37.6173}, 1000, false, 55.7468, 55.7648},
+		{"crosses the antimeridian", Point{0, 179.999}, 1000, true, -0.009, 0.009},
+		{"reaches the pole", Point{89.995, 10}, 1000, true, 89.986, 90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := BoundingBox(tt.center, tt.radius)
+			if got := box.MinLon == -180 && box.MaxLon == 180; got != tt.allLons {
+				t.Errorf("box %+v spans all longitudes: %v, want %v", box, got, tt.allLons)
+			}
+			if math.Abs(box.MinLat-tt.wantMinLat) > 1e-3 || math.Abs(box.MaxLat-tt.wantMaxLat) > 1e-3 {
+				t.Errorf("box %+v latitudes, want [%v, %v]", box, tt.wantMinLat, tt.wantMaxLat)
+			}
+		})
+	}
+}
+
+func TestIndexWithin(t *testing.T) {
+	tests := []struct {
+		name   string
+		points map[int]Point
+		center Point
+		radius float64
+		want   []int
+	}{
+		{
+			name:   "empty index",
+			center: Point{55.75, 37.61},
+			radius: 1000,
+		},
+		{
+			name: "point in a neighbouring cell",
+			points: map[int]Point{
+				1: {55.7599, 37.61}, // cell 5575
+				2: {55.7601, 37.61}, // cell 5576, 22 m away
+			},
+			center: Point{55.7599, 37.61},
+			radius: 100,
+			want:   []int{1, 2},
+		},
+		{
+			name: "point on a cell boundary",
+			points: map[int]Point{
+				1: {55.76, 37.62},
+			},
+			center: Point{55.7595, 37.6195},
+			radius: 100,
+			want:   []int{1},
+		},
+		{
+			name: "points beyond the radius in overlapping cells are excluded",
+			points: map[int]Point{
+				1: {55.7500, 37.6100},
+				2: {55.7590, 37.6100}, // 1 km north
+				3: {55.7500, 37.6260}, // 1 km east
+			},
+			center: Point{55.75, 37.61},
+			radius: 900,
+			want:   []int{1},
+		},
+		{
+			name: "nearest first, ties by ID",
+			points: map[int]Point{
+				4: {55.752, 37.61},
+				3: {55.748, 37.61},
+				2: {55.751, 37.61},
+				1: {55.75, 37.61},
+			},
+			center: Point{55.75, 37.61},
+			radius: 1000,
+			want:   []int{1, 2, 3, 4},
+		},
+		{
+			name: "across the antimeridian",
+			points: map[int]Point{
+				1: {0, -179.999},
+				2: {0, 179.995},
+				3: {0, -179.9},
+			},
+			center: Point{0, 179.999},
+			radius: 1000,
+			want:   []int{1, 2},
+		},
+		{
+			name: "near the pole",
+			points: map[int]Point{
+				1: {89.999, -170},
+				2: {89.999, 10},
+				3: {89.9, 10},
+			},
+			center: Point{89.9995, 10},
+			radius: 500,
+			want:   []int{2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ix := NewIndex(0.01)
+			for id, p := range tt.points {
+				ix.Set(id, p)
+			}
+			var got []int
+			for _, m := range ix.Within(tt.center, tt.radius) {
+				got = append(got, m.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Within(%v, %v) = %v, want %v", tt.center, tt.radius, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestIndexWithinMatchesScan checks Within against a scan of every point, for sparse and
+// dense indexes, which Within searches differently.
+func TestIndexWithinMatchesScan(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for _, n := range []int{5, 2000} {
+		ix := NewIndex(0.01)
+		points := make(map[int]Point)
+		for id := range n {
+			p := Point{Lat: 55.7 + rnd.Float64()*0.1, Lon: 37.5 + rnd.Float64()*0.2}
+			points[id] = p
+			ix.Set(id, p)
+		}
+
+		for range 20 {
+			center := Point{Lat: 55.7 + rnd.Float64()*0.1, Lon: 37.5 + rnd.Float64()*0.2}
+			radius := 200 + rnd.Float64()*3000
+
+			var want []int
+			for id, p := range points {
+				if Distance(center, p) <= radius {
+					want = append(want, id)
+				}
+			}
+			var got []int
+			for _, m := range ix.Within(center, radius) {
+				got = append(got, m.ID)
+			}
+			slices.Sort(want)
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Fatalf("%d points: Within(%v, %.0f) found %d, scan found %d", n, center, radius, len(got), len(want))
+			}
+		}
+	}
+}
+
+func TestIndexSetAndRemove(t *testing.T) {
+	ix := NewIndex(0.01)
+	ix.Set(1, Point{55.75, 37.61})
+	ix.Set(1, Point{55.85, 37.61})
+	if ix.Len() != 1 {
+		t.Fatalf("Len() = %d after moving a point, want 1", ix.Len())
+	}
+	if got := ix.Within(Point{55.75, 37.61}, 500); len(got) != 0 {
+		t.Errorf("point still found at its old position: %v", got)
+	}
+	if got := ix.Within(Point{55.85, 37.61}, 500); len(got) != 1 {
+		t.Errorf("point not found at its new position: %v", got)
+	}
+
+	ix.Remove(1)
+	ix.Remove(2)
+	if ix.Len() != 0 || len(ix.cells) != 0 {
+		t.Errorf("Len() = %d, cells = %d after removal, want 0", ix.Len(), len(ix.cells))
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/auth"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/hse-trpo-taxi/backend/requestid"
+)
+
+// auditedEntities are the entity types the audit log has events for.
+var auditedEntities = []string{"car", "client", "driver", "ride", "tariff"}
+
+// Auditor makes changes to records together with the audit events describing them.
+// It is shared by the handlers of the audited entities.
+type Auditor struct {
+	tx     repository.Transactor
+	events repository.AuditRepository
+}
+
+// NewAuditor returns an Auditor that runs changes in transactions started by tx
+// and stores their audit events in events.
+func NewAuditor(tx repository.Transactor, events repository.AuditRepository) *Auditor {
+	return &Auditor{tx: tx, events: events}
+}
+
+// record calls write in a transaction and, if it succeeds, stores an event of action on the
+// entity of the given type in the same transaction, attributed to the caller of r.
+// id, before and after point to the entity's ID and to its versions before and after the
+// change; before is nil for a create and after for a tariff, which is deleted for good.
+// They are read once write has returned, so write may fill them in. The error of write is
+// returned unchanged.
+func (a *Auditor) record(r *http.Request, entity string, action models.AuditAction, id *int, before, after any, write func(ctx context.Context) error) error {
+	return a.tx.InTx(r.Context(), func(ctx context.Context) error {
+		if err := write(ctx); err != nil {
+			return err
+		}
+
+		event := models.AuditEvent{
+			EntityType: entity,
+			EntityID:   *id,
+			Action:     action,
+			RequestID:  requestid.FromContext(ctx),
+			CreatedAt:  time.Now(),
+		}
+		if p, ok := auth.FromContext(ctx); ok {
+			event.ActorID, event.ActorRole = &p.Subject, string(p.Role)
+		}
+		var err error
+		if before != nil {
+			if event.Before, err = json.Marshal(before); err != nil {
+				return err
+			}
+		}
+		if after != nil {
+			if event.After, err = json.Marshal(after); err != nil {
+				return err
+			}
+		}
+		return a.events.Record(ctx, &event)
+	})
+}
+
+// softDeleter is the part of the client, driver and car repositories used to delete and restore records.
+type softDeleter[T any] interface {
+	Get(ctx context.Context, id int) (T, error)
+	GetIncludingDeleted(ctx context.Context, id int) (T, error)
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) error
+}
+
+// auditedDelete marks the record with the given ID in repo as deleted and records the change.
+// It returns repository.ErrNotFound if the record is missing or already deleted.
+func auditedDelete[T any](r *http.Request, a *Auditor, repo softDeleter[T], entity string, id int) error {
+	var before, after T
+	return a.record(r, entity, models.AuditDelete, &id, &before, &after, func(ctx context.Context) error {
+		var err error
+		if before, err = repo.Get(ctx, id); err != nil {
+			return err
+		}
+		if err := repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		after, err = repo.GetIncludingDeleted(ctx, id)
+		return err
+	})
+}
+
+// auditedRestore clears the deletion mark of the record with the given ID in repo, records
+// the change and returns the restored record. It fails like the repository's Restore method.
+func auditedRestore[T any](r *http.Request, a *Auditor, repo softDeleter[T], entity string, id int) (T, error) {
+	var before, after T
+	err := a.record(r, entity, models.AuditRestore, &id, &before, &after, func(ctx context.Context) error {
+		var err error
+		if before, err = repo.GetIncludingDeleted(ctx, id); err != nil {
+			return err
+		}
+		if err := repo.Restore(ctx, id); err != nil {
+			return err
+		}
+		after, err = repo.Get(ctx, id)
+		return err
+	})
+	return after, err
+}
+
+// auditedDriverStatus calls change, which moves the driver with the given ID to another
+// status and may make accompanying changes such as opening a shift, and records the
+// change to the driver. It returns the driver as stored after the change; the error of
+// change is returned unchanged.
+func auditedDriverStatus(r *http.Request, a *Auditor, drivers repository.DriverRepository, id int, change func(ctx context.Context) error) (models.Driver, error) {
+	var before, after models.Driver
+	err := a.record(r, "driver", models.AuditUpdate, &id, &before, &after, func(ctx context.Context) error {
+		var err error
+		if before, err = drivers.GetIncludingDeleted(ctx, id); errors.Is(err, repository.ErrNotFound) {
+			// Let change report the missing driver in its own terms, e.g. dispatch.ErrCarNotOwned
+			if err := change(ctx); err != nil {
+				return err
+			}
+			return err
+		} else if err != nil {
+			return err
+		}
+		if err := change(ctx); err != nil {
+			return err
+		}
+		after, err = drivers.GetIncludingDeleted(ctx, id)
+		return err
+	})
+	return after, err
+}
+
+// auditedAssign calls assign, which gives the ride with the given ID to the driver with the
+// given ID as dispatch.Dispatcher.Assign does, and records the changes to the ride and the
+// driver. It returns the accepted ride; the error of assign is returned unchanged.
+func auditedAssign(r *http.Request, a *Auditor, rides repository.RideRepository, drivers repository.DriverRepository,
+	rideID, driverID int, assign func(ctx context.Context) (models.Ride, error)) (models.Ride, error) {
+	var before, after models.Ride
+	_, err := auditedDriverStatus(r, a, drivers, driverID, func(ctx context.Context) error {
+		return a.record(r.WithContext(ctx), "ride", models.AuditUpdate, &rideID, &before, &after, func(ctx context.Context) error {
+			var err error
+			if before, err = rides.Get(ctx, rideID); errors.Is(err, repository.ErrNotFound) {
+				// Let assign report the missing ride or offer in its own terms
+				if _, err := assign(ctx); err != nil {
+					return err
+				}
+				return err
+			} else if err != nil {
+				return err
+			}
+			after, err = assign(ctx)
+			return err
+		})
+	})
+	return after, err
+}
+
+// AuditHandler serves the /api/audit endpoint.
+type AuditHandler struct {
+	repo repository.AuditRepository
+}
+
+// NewAuditHandler returns an AuditHandler that reads the audit log from repo.
+func NewAuditHandler(repo repository.AuditRepository) *AuditHandler {
+	return &AuditHandler{repo: repo}
+}
+
+// GetAuditEvents handles GET /api/audit requests.
+// It returns a page of audit events as a listResponse, oldest first by default. The optional
+// entity query parameter (client, driver, car, ride or tariff) selects the events of one kind of
+// record, and id, which requires entity, those of a single record. Paging and ordering are
+// controlled by limit, cursor and sort (id or created_at, prefixed with "-" for descending order).
+// Returns HTTP 400 if a query parameter is invalid, or HTTP 500 if there's a database error.
+func (h *AuditHandler) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, err := parsePage(query, repository.AuditSortFields)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	filter := repository.AuditFilter{EntityType: query.Get("entity")}
+	if filter.EntityType != "" && !slices.Contains(auditedEntities, filter.EntityType) {
+		writeError(w, r, apierr.BadRequest("Query parameter entity must be one of "+strings.Join(auditedEntities, ", ")))
+		return
+	}
+	if filter.EntityID, err = queryInt(query, "id"); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if filter.EntityID != 0 && filter.EntityType == "" {
+		writeError(w, r, apierr.BadRequest("Query parameter id requires entity"))
+		return
+	}
+
+	events, next, err := h.repo.List(r.Context(), filter, page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writePage(w, events, page, repository.AuditSortFields, next)
+}
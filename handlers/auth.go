@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/auth"
+)
+
+// principal returns the authenticated caller of r. Routes are wrapped with auth.Tokens.Require,
+// so every handler sees a principal; a handler mounted without it gets the zero Principal,
+// which has no role and fails every ownership check.
+func principal(r *http.Request) auth.Principal {
+	p, _ := auth.FromContext(r.Context())
+	return p
+}
+
+// forbidden writes an HTTP 403 response for a caller acting on someone else's record.
+func forbidden(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, apierr.Forbidden("Forbidden"))
+}
@@ -1,148 +1,297 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/hse-trpo-taxi/backend/database"
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/auth"
 	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
 )
 
+// CarHandler serves the /api/cars endpoints.
+// Dispatchers and admins may manage any car; a driver sees and manages only their own cars.
+type CarHandler struct {
+	repo  repository.CarRepository
+	audit *Auditor
+}
+
+// NewCarHandler returns a CarHandler that stores cars in repo and records changes to them with audit.
+func NewCarHandler(repo repository.CarRepository, audit *Auditor) *CarHandler {
+	return &CarHandler{repo: repo, audit: audit}
+}
+
 // GetCars handles GET /api/cars requests.
-// It retrieves all cars from the database and returns them as a JSON array.
-// Returns HTTP 500 if there's a database error.
-func GetCars(w http.ResponseWriter, r *http.Request) {
-	rows, err := database.DB.Query("SELECT id, driver_id, brand, model, year, license_plate, color, created_at, updated_at FROM cars")
+// It returns a page of cars as a listResponse. The optional driver_id, brand (ignoring case)
+// and year query parameters filter the result; a driver only receives their own cars.
+// Paging and ordering are controlled by limit, cursor and sort (id, brand, year or
+// created_at, prefixed with "-" for descending order). Deleted cars are left out unless
+// an admin passes include_deleted=true.
+// Returns HTTP 400 if a query parameter is invalid, HTTP 403 if a driver asks for another
+// driver's cars or a non-admin for deleted cars, or HTTP 500 if there's a database error.
+func (h *CarHandler) GetCars(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, err := parsePage(query, repository.CarSortFields)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	defer rows.Close()
-
-	cars := []models.Car{}
-	for rows.Next() {
-		var car models.Car
-		if err := rows.Scan(&car.ID, &car.DriverID, &car.Brand, &car.Model, &car.Year, &car.LicensePlate, &car.Color, &car.CreatedAt, &car.UpdatedAt); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	filter := repository.CarFilter{Brand: query.Get("brand")}
+	if filter.DriverID, err = queryInt(query, "driver_id"); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if filter.Year, err = queryInt(query, "year"); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if filter.IncludeDeleted, err = includeDeleted(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if p := principal(r); p.Is(auth.RoleDriver) {
+		if filter.DriverID != 0 && filter.DriverID != p.Subject {
+			forbidden(w, r)
 			return
 		}
-		cars = append(cars, car)
+		filter.DriverID = p.Subject
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(cars)
+	cars, next, err := h.repo.List(r.Context(), filter, page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writePage(w, cars, page, repository.CarSortFields, next)
 }
 
 // GetCar handles GET /api/cars/{id} requests.
-// It retrieves a specific car by ID and returns it as JSON.
-// Returns HTTP 400 if the ID is invalid, HTTP 404 if the car is not found,
-// or HTTP 500 if there's a database error.
-func GetCar(w http.ResponseWriter, r *http.Request) {
+// It retrieves a specific car by ID and returns it as JSON with an ETag header,
+// which can be sent back in If-Match to update the car only if it hasn't changed.
+// A deleted car is only returned to an admin passing include_deleted=true.
+// Returns HTTP 400 if the ID is invalid, HTTP 403 if a driver asks for another driver's car
+// or a non-admin for deleted cars, HTTP 404 if the car is not found, or HTTP 500 if there's a database error.
+func (h *CarHandler) GetCar(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid car ID", http.StatusBadRequest)
+		writeError(w, r, apierr.BadRequest("Invalid car ID"))
 		return
 	}
-
-	var car models.Car
-	err = database.DB.QueryRow("SELECT id, driver_id, brand, model, year, license_plate, color, created_at, updated_at FROM cars WHERE id = ?", id).
-		Scan(&car.ID, &car.DriverID, &car.Brand, &car.Model, &car.Year, &car.LicensePlate, &car.Color, &car.CreatedAt, &car.UpdatedAt)
-
+	include, err := includeDeleted(r)
 	if err != nil {
-		http.Error(w, "Car not found", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(car)
+	car, ok := h.loadOwned(w, r, id, include)
+	if !ok {
+		return
+	}
+
+	writeRecord(w, http.StatusOK, car, car.UpdatedAt)
 }
 
 // CreateCar handles POST /api/cars requests.
-// It creates a new car with the provided JSON data.
+// It creates a new car with the provided JSON data, validated by models.Car.Validate;
+// the license plate is stored in canonical Cyrillic form.
 // The created_at and updated_at timestamps are automatically set.
-// The driver_id must reference an existing driver.
+// The driver_id must reference an existing driver; a driver may only register cars for themselves.
 // Returns the created car with HTTP 201 on success,
-// HTTP 400 if the request body is invalid, or HTTP 500 if there's a database error.
-func CreateCar(w http.ResponseWriter, r *http.Request) {
+// HTTP 400 if the request body is malformed, HTTP 422 with the failing fields if it is invalid,
+// HTTP 403 if a driver registers a car for someone else,
+// HTTP 409 if another car has the same license plate, or HTTP 500 if there's a database error.
+func (h *CarHandler) CreateCar(w http.ResponseWriter, r *http.Request) {
 	var car models.Car
-	if err := json.NewDecoder(r.Body).Decode(&car); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &car); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if p := principal(r); !p.IsStaff() && !p.Owns(auth.RoleDriver, car.DriverID) {
+		forbidden(w, r)
 		return
 	}
 
 	car.CreatedAt = time.Now()
 	car.UpdatedAt = time.Now()
+	car.DeletedAt = nil
 
-	result, err := database.DB.Exec("INSERT INTO cars (driver_id, brand, model, year, license_plate, color, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		car.DriverID, car.Brand, car.Model, car.Year, car.LicensePlate, car.Color, car.CreatedAt, car.UpdatedAt)
+	err := h.audit.record(r, "car", models.AuditCreate, &car.ID, nil, &car, func(ctx context.Context) error {
+		return h.repo.Create(ctx, &car)
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	id, _ := result.LastInsertId()
-	car.ID = int(id)
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(car)
+	writeRecord(w, http.StatusCreated, car, car.UpdatedAt)
 }
 
 // UpdateCar handles PUT /api/cars/{id} requests.
-// It updates an existing car with the provided JSON data.
+// It replaces an existing car with the provided JSON data, validated like in CreateCar.
 // The updated_at timestamp is automatically set to the current time.
-// The driver_id must reference an existing driver if changed.
-// Returns the updated car as JSON on success,
-// HTTP 400 if the ID or request body is invalid, or HTTP 500 if there's a database error.
-func UpdateCar(w http.ResponseWriter, r *http.Request) {
+// The driver_id must reference an existing driver if changed; a driver may only update
+// their own cars and cannot hand them to another driver.
+// If the request has an If-Match header, the car is only updated if its ETag matches.
+// Returns the updated car as JSON with its new ETag on success,
+// HTTP 400 if the ID or request body is malformed, HTTP 422 with the failing fields if the body is invalid,
+// HTTP 403 if the car belongs to another driver,
+// HTTP 404 if the car is not found, HTTP 409 if another car has the same license plate,
+// HTTP 412 if the car has changed since the ETag in If-Match was issued,
+// or HTTP 500 if there's a database error.
+func (h *CarHandler) UpdateCar(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, func(current models.Car) (models.Car, error) {
+		var car models.Car
+		err := decodeJSON(r, &car)
+		return car, err
+	})
+}
+
+// PatchCar handles PATCH /api/cars/{id} requests.
+// It applies the JSON Merge Patch (RFC 7396) in the request body to an existing car,
+// so fields missing from the patch keep their values, and validates the result like in CreateCar.
+// The same ownership rules as for UpdateCar apply.
+// If-Match, the response and the status codes are the same as for UpdateCar,
+// plus HTTP 415 if the body is not application/merge-patch+json or application/json.
+func (h *CarHandler) PatchCar(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, func(current models.Car) (models.Car, error) {
+		var car models.Car
+		err := decodePatch(r, current, &car)
+		return car, err
+	})
+}
+
+// update loads the car named in the URL, checks ownership and If-Match against it, builds
+// its new version with change and stores it unless the car was modified in the meantime.
+func (h *CarHandler) update(w http.ResponseWriter, r *http.Request, change func(current models.Car) (models.Car, error)) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid car ID", http.StatusBadRequest)
+		writeError(w, r, apierr.BadRequest("Invalid car ID"))
 		return
 	}
 
-	var car models.Car
-	if err := json.NewDecoder(r.Body).Decode(&car); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	current, ok := h.loadOwned(w, r, id, false)
+	if !ok {
+		return
+	}
+	if err := checkIfMatch(r, current.UpdatedAt); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
+	car, err := change(current)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if p := principal(r); !p.IsStaff() && !p.Owns(auth.RoleDriver, car.DriverID) {
+		forbidden(w, r)
+		return
+	}
+	car.ID = id
+	car.CreatedAt = current.CreatedAt
 	car.UpdatedAt = time.Now()
+	car.DeletedAt = nil
 
-	_, err = database.DB.Exec("UPDATE cars SET driver_id = ?, brand = ?, model = ?, year = ?, license_plate = ?, color = ?, updated_at = ? WHERE id = ?",
-		car.DriverID, car.Brand, car.Model, car.Year, car.LicensePlate, car.Color, car.UpdatedAt, id)
+	err = h.audit.record(r, "car", models.AuditUpdate, &id, &current, &car, func(ctx context.Context) error {
+		return h.repo.Update(ctx, &car, current.UpdatedAt)
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, updateError(r, err, "Car not found"))
 		return
 	}
 
-	car.ID = id
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(car)
+	writeRecord(w, http.StatusOK, car, car.UpdatedAt)
 }
 
 // DeleteCar handles DELETE /api/cars/{id} requests.
-// It removes a car from the database by ID.
+// It marks a car as deleted: the car is hidden from the API but kept, together with its
+// rides, until it is purged after the retention period, and can be restored by an admin until then.
+// A driver may only delete their own cars.
 // Returns HTTP 204 (No Content) on successful deletion,
-// HTTP 400 if the ID is invalid, or HTTP 500 if there's a database error.
-func DeleteCar(w http.ResponseWriter, r *http.Request) {
+// HTTP 400 if the ID is invalid, HTTP 403 if the car belongs to another driver,
+// HTTP 404 if the car is not found or already deleted, or HTTP 500 if there's a database error.
+func (h *CarHandler) DeleteCar(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid car ID", http.StatusBadRequest)
+		writeError(w, r, apierr.BadRequest("Invalid car ID"))
 		return
 	}
+	if !principal(r).IsStaff() {
+		if _, ok := h.loadOwned(w, r, id, false); !ok {
+			return
+		}
+	}
 
-	_, err = database.DB.Exec("DELETE FROM cars WHERE id = ?", id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := auditedDelete(r, h.audit, h.repo, "car", id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Car not found"))
+			return
+		}
+		writeError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// RestoreCar handles POST /api/cars/{id}/restore requests.
+// It undoes DeleteCar for a car that has not been purged yet.
+// Returns the restored car as JSON on success,
+// HTTP 400 if the ID is invalid, HTTP 404 if the car is not found,
+// HTTP 409 if the car is not deleted or another car has taken its license plate,
+// or HTTP 500 if there's a database error.
+func (h *CarHandler) RestoreCar(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid car ID"))
+		return
+	}
+
+	car, err := auditedRestore(r, h.audit, h.repo, "car", id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			writeError(w, r, apierr.NotFound("Car not found"))
+		case errors.Is(err, repository.ErrConflict):
+			writeError(w, r, apierr.Conflict("Car is not deleted"))
+		default:
+			writeError(w, r, err)
+		}
+		return
+	}
+
+	writeRecord(w, http.StatusOK, car, car.UpdatedAt)
+}
+
+// loadOwned returns the car with the given ID if the caller is staff or the car's driver.
+// Otherwise it writes an HTTP 403, 404 or 500 response and returns false.
+// A deleted car is only found if includeDeleted is set.
+func (h *CarHandler) loadOwned(w http.ResponseWriter, r *http.Request, id int, includeDeleted bool) (models.Car, bool) {
+	get := h.repo.Get
+	if includeDeleted {
+		get = h.repo.GetIncludingDeleted
+	}
+	car, err := get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Car not found"))
+			return car, false
+		}
+		writeError(w, r, err)
+		return car, false
+	}
+	if p := principal(r); !p.IsStaff() && !p.Owns(auth.RoleDriver, car.DriverID) {
+		forbidden(w, r)
+		return car, false
+	}
+	return car, true
+}
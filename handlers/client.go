@@ -1,149 +1,260 @@
 // Package handlers provides HTTP request handlers for the taxi service API.
 // It contains handlers for managing clients, drivers, and cars through RESTful endpoints.
-// All handlers use JSON for request and response formatting and follow standard HTTP status codes.
+// All handlers use JSON for request and response formatting and follow standard HTTP status codes;
+// errors are written with apierr, so clients get a uniform envelope with a machine-readable code.
+// Handlers access storage only through the interfaces in the repository package,
+// which are supplied via the New*Handler constructors.
 package handlers
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/hse-trpo-taxi/backend/database"
+	"github.com/hse-trpo-taxi/backend/apierr"
 	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/hse-trpo-taxi/backend/validate"
 )
 
+// ClientHandler serves the /api/clients endpoints.
+type ClientHandler struct {
+	repo  repository.ClientRepository
+	audit *Auditor
+}
+
+// NewClientHandler returns a ClientHandler that stores clients in repo and records changes to them with audit.
+func NewClientHandler(repo repository.ClientRepository, audit *Auditor) *ClientHandler {
+	return &ClientHandler{repo: repo, audit: audit}
+}
+
 // GetClients handles GET /api/clients requests.
-// It retrieves all clients from the database and returns them as a JSON array.
-// Returns HTTP 500 if there's a database error.
-func GetClients(w http.ResponseWriter, r *http.Request) {
-	rows, err := database.DB.Query("SELECT id, name, phone, email, created_at, updated_at FROM clients")
+// It returns a page of clients as a listResponse. The optional name query parameter
+// matches part of the name ignoring case, and phone matches the whole phone number in any
+// formatting. Paging and ordering are controlled by limit, cursor and sort (id, name or
+// created_at, prefixed with "-" for descending order). Deleted clients are left out unless
+// an admin passes include_deleted=true.
+// Returns HTTP 400 if a query parameter is invalid, HTTP 403 if a non-admin asks for deleted
+// clients, or HTTP 500 if there's a database error.
+func (h *ClientHandler) GetClients(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, err := parsePage(query, repository.ClientSortFields)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	defer rows.Close()
-
-	clients := []models.Client{}
-	for rows.Next() {
-		var client models.Client
-		if err := rows.Scan(&client.ID, &client.Name, &client.Phone, &client.Email, &client.CreatedAt, &client.UpdatedAt); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		clients = append(clients, client)
+	filter := repository.ClientFilter{Name: query.Get("name"), Phone: query.Get("phone")}
+	if phone, ok := validate.NormalizePhone(filter.Phone); ok {
+		filter.Phone = phone
+	}
+	if filter.IncludeDeleted, err = includeDeleted(r); err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(clients)
+	clients, next, err := h.repo.List(r.Context(), filter, page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writePage(w, clients, page, repository.ClientSortFields, next)
 }
 
 // GetClient handles GET /api/clients/{id} requests.
-// It retrieves a specific client by ID and returns it as JSON.
-// Returns HTTP 400 if the ID is invalid, HTTP 404 if the client is not found,
-// or HTTP 500 if there's a database error.
-func GetClient(w http.ResponseWriter, r *http.Request) {
+// It retrieves a specific client by ID and returns it as JSON with an ETag header,
+// which can be sent back in If-Match to update the client only if it hasn't changed.
+// A deleted client is only returned to an admin passing include_deleted=true.
+// Returns HTTP 400 if the ID is invalid, HTTP 403 if a non-admin asks for deleted clients,
+// HTTP 404 if the client is not found, or HTTP 500 if there's a database error.
+func (h *ClientHandler) GetClient(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		writeError(w, r, apierr.BadRequest("Invalid client ID"))
+		return
+	}
+	include, err := includeDeleted(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	var client models.Client
-	err = database.DB.QueryRow("SELECT id, name, phone, email, created_at, updated_at FROM clients WHERE id = ?", id).
-		Scan(&client.ID, &client.Name, &client.Phone, &client.Email, &client.CreatedAt, &client.UpdatedAt)
-
+	get := h.repo.Get
+	if include {
+		get = h.repo.GetIncludingDeleted
+	}
+	client, err := get(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Client not found", http.StatusNotFound)
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Client not found"))
+			return
+		}
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(client)
+	writeRecord(w, http.StatusOK, client, client.UpdatedAt)
 }
 
 // CreateClient handles POST /api/clients requests.
-// It creates a new client with the provided JSON data.
+// It creates a new client with the provided JSON data, validated by models.Client.Validate;
+// the phone number is stored in E.164 form.
 // The created_at and updated_at timestamps are automatically set.
 // Returns the created client with HTTP 201 on success,
-// HTTP 400 if the request body is invalid, or HTTP 500 if there's a database error.
-func CreateClient(w http.ResponseWriter, r *http.Request) {
+// HTTP 400 if the request body is malformed, HTTP 422 with the failing fields if it is invalid,
+// HTTP 409 if the phone number or email belongs to another client, or HTTP 500 if there's a database error.
+func (h *ClientHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
 	var client models.Client
-	if err := json.NewDecoder(r.Body).Decode(&client); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &client); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	client.CreatedAt = time.Now()
 	client.UpdatedAt = time.Now()
+	client.DeletedAt = nil
 
-	result, err := database.DB.Exec("INSERT INTO clients (name, phone, email, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
-		client.Name, client.Phone, client.Email, client.CreatedAt, client.UpdatedAt)
+	err := h.audit.record(r, "client", models.AuditCreate, &client.ID, nil, &client, func(ctx context.Context) error {
+		return h.repo.Create(ctx, &client)
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	id, _ := result.LastInsertId()
-	client.ID = int(id)
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(client)
+	writeRecord(w, http.StatusCreated, client, client.UpdatedAt)
 }
 
 // UpdateClient handles PUT /api/clients/{id} requests.
-// It updates an existing client with the provided JSON data.
+// It replaces an existing client with the provided JSON data, validated like in CreateClient.
 // The updated_at timestamp is automatically set to the current time.
-// Returns the updated client as JSON on success,
-// HTTP 400 if the ID or request body is invalid, or HTTP 500 if there's a database error.
-func UpdateClient(w http.ResponseWriter, r *http.Request) {
+// If the request has an If-Match header, the client is only updated if its ETag matches.
+// Returns the updated client as JSON with its new ETag on success,
+// HTTP 400 if the ID or request body is malformed, HTTP 422 with the failing fields if the body is invalid,
+// HTTP 404 if the client is not found, HTTP 409 if the phone number or email belongs to another client,
+// HTTP 412 if the client has changed since the ETag in If-Match was issued,
+// or HTTP 500 if there's a database error.
+func (h *ClientHandler) UpdateClient(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, func(current models.Client) (models.Client, error) {
+		var client models.Client
+		err := decodeJSON(r, &client)
+		return client, err
+	})
+}
+
+// PatchClient handles PATCH /api/clients/{id} requests.
+// It applies the JSON Merge Patch (RFC 7396) in the request body to an existing client,
+// so fields missing from the patch keep their values, and validates the result like in CreateClient.
+// If-Match, the response and the status codes are the same as for UpdateClient,
+// plus HTTP 415 if the body is not application/merge-patch+json or application/json.
+func (h *ClientHandler) PatchClient(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, func(current models.Client) (models.Client, error) {
+		var client models.Client
+		err := decodePatch(r, current, &client)
+		return client, err
+	})
+}
+
+// update loads the client named in the URL, checks If-Match against it, builds its new
+// version with change and stores it unless the client was modified in the meantime.
+func (h *ClientHandler) update(w http.ResponseWriter, r *http.Request, change func(current models.Client) (models.Client, error)) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		writeError(w, r, apierr.BadRequest("Invalid client ID"))
 		return
 	}
 
-	var client models.Client
-	if err := json.NewDecoder(r.Body).Decode(&client); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	current, err := h.repo.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Client not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+	if err := checkIfMatch(r, current.UpdatedAt); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
+	client, err := change(current)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	client.ID = id
+	client.CreatedAt = current.CreatedAt
 	client.UpdatedAt = time.Now()
+	client.DeletedAt = nil
 
-	_, err = database.DB.Exec("UPDATE clients SET name = ?, phone = ?, email = ?, updated_at = ? WHERE id = ?",
-		client.Name, client.Phone, client.Email, client.UpdatedAt, id)
+	err = h.audit.record(r, "client", models.AuditUpdate, &id, &current, &client, func(ctx context.Context) error {
+		return h.repo.Update(ctx, &client, current.UpdatedAt)
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, updateError(r, err, "Client not found"))
 		return
 	}
 
-	client.ID = id
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(client)
+	writeRecord(w, http.StatusOK, client, client.UpdatedAt)
 }
 
 // DeleteClient handles DELETE /api/clients/{id} requests.
-// It removes a client from the database by ID.
+// It marks a client as deleted: the client is hidden from the API but kept, together with
+// their rides, until it is purged after the retention period, and can be restored until then.
 // Returns HTTP 204 (No Content) on successful deletion,
-// HTTP 400 if the ID is invalid, or HTTP 500 if there's a database error.
-func DeleteClient(w http.ResponseWriter, r *http.Request) {
+// HTTP 400 if the ID is invalid, HTTP 404 if the client is not found or already deleted,
+// or HTTP 500 if there's a database error.
+func (h *ClientHandler) DeleteClient(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		writeError(w, r, apierr.BadRequest("Invalid client ID"))
 		return
 	}
 
-	_, err = database.DB.Exec("DELETE FROM clients WHERE id = ?", id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := auditedDelete(r, h.audit, h.repo, "client", id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Client not found"))
+			return
+		}
+		writeError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// RestoreClient handles POST /api/clients/{id}/restore requests.
+// It undoes DeleteClient for a client that has not been purged yet.
+// Returns the restored client as JSON on success,
+// HTTP 400 if the ID is invalid, HTTP 404 if the client is not found,
+// HTTP 409 if the client is not deleted or another client has taken their phone number or email,
+// or HTTP 500 if there's a database error.
+func (h *ClientHandler) RestoreClient(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid client ID"))
+		return
+	}
+
+	client, err := auditedRestore(r, h.audit, h.repo, "client", id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			writeError(w, r, apierr.NotFound("Client not found"))
+		case errors.Is(err, repository.ErrConflict):
+			writeError(w, r, apierr.Conflict("Client is not deleted"))
+		default:
+			writeError(w, r, err)
+		}
+		return
+	}
+
+	writeRecord(w, http.StatusOK, client, client.UpdatedAt)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/validate"
+)
+
+func TestCreateClient(t *testing.T) {
+	s := newTestServer(t)
+
+	var created models.Client
+	decode(t, s.do(admin, "POST", "/api/clients", `{"name": "Anna", "phone": "8 (999) 123-45-67"}`), http.StatusCreated, &created)
+	if created.ID == 0 || created.Phone != "+79991234567" {
+		t.Errorf("created %+v, want an ID and phone +79991234567", created)
+	}
+
+	var conflict apierr.Response
+	decode(t, s.do(admin, "POST", "/api/clients", `{"name": "Boris", "phone": "+7 999 123 45 67"}`), http.StatusConflict, &conflict)
+	if details, _ := conflict.Details.(map[string]any); details["field"] != "phone" {
+		t.Errorf("conflict details = %v, want field phone", conflict.Details)
+	}
+
+	var page listResponse[models.Client]
+	decode(t, s.do(admin, "GET", "/api/clients?phone=89991234567", ""), http.StatusOK, &page)
+	if len(page.Items) != 1 || page.Items[0].ID != created.ID {
+		t.Errorf("clients with phone 89991234567 = %+v, want client %d", page.Items, created.ID)
+	}
+
+	events := s.auditEvents(t)
+	if len(events) != 1 || events[0].EntityType != "client" || events[0].Action != models.AuditCreate || events[0].EntityID != created.ID {
+		t.Errorf("audit events = %+v, want the creation of client %d", events, created.ID)
+	}
+	if events[0].ActorID == nil || *events[0].ActorID != admin.Subject || events[0].ActorRole != string(admin.Role) {
+		t.Errorf("audit event actor = %v %q, want the admin", events[0].ActorID, events[0].ActorRole)
+	}
+}
+
+func TestCreateClientValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		fields []string
+	}{
+		{"malformed JSON", `{"name": `, http.StatusBadRequest, nil},
+		{"unknown field", `{"name": "Anna", "phone": "+79991234567", "age": 30}`, http.StatusUnprocessableEntity, []string{"age"}},
+		{"missing name", `{"phone": "+79991234567"}`, http.StatusUnprocessableEntity, []string{"name"}},
+		{"invalid phone and email", `{"name": "Anna", "phone": "12", "email": "anna@"}`, http.StatusUnprocessableEntity, []string{"phone", "email"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			w := s.do(admin, "POST", "/api/clients", tt.body)
+			var fields []string
+			if tt.status == http.StatusUnprocessableEntity {
+				var resp struct {
+					Details []validate.FieldError `json:"details"`
+				}
+				decode(t, w, tt.status, &resp)
+				for _, fe := range resp.Details {
+					fields = append(fields, fe.Field)
+				}
+			} else {
+				decode(t, w, tt.status, nil)
+			}
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("failing fields = %v, want %v", fields, tt.fields)
+			}
+			if events := s.auditEvents(t); len(events) != 0 {
+				t.Errorf("audit events = %+v, want none", events)
+			}
+		})
+	}
+}
+
+func TestDeleteAndRestoreClient(t *testing.T) {
+	s := newTestServer(t)
+	var client models.Client
+	decode(t, s.do(admin, "POST", "/api/clients", `{"name": "Anna", "phone": "+79991234567"}`), http.StatusCreated, &client)
+	path := fmt.Sprintf("/api/clients/%d", client.ID)
+
+	decode(t, s.do(admin, "DELETE", path, ""), http.StatusNoContent, nil)
+	decode(t, s.do(admin, "GET", path, ""), http.StatusNotFound, nil)
+	decode(t, s.do(admin, "DELETE", path, ""), http.StatusNotFound, nil)
+
+	// The phone number of a deleted client is free again
+	var other models.Client
+	decode(t, s.do(admin, "POST", "/api/clients", `{"name": "Boris", "phone": "89991234567"}`), http.StatusCreated, &other)
+	decode(t, s.do(admin, "POST", path+"/restore", ""), http.StatusConflict, nil)
+	decode(t, s.do(admin, "DELETE", fmt.Sprintf("/api/clients/%d", other.ID), ""), http.StatusNoContent, nil)
+
+	var restored models.Client
+	decode(t, s.do(admin, "POST", path+"/restore", ""), http.StatusOK, &restored)
+	if restored.ID != client.ID || restored.DeletedAt != nil {
+		t.Errorf("restored %+v, want client %d without deleted_at", restored, client.ID)
+	}
+	decode(t, s.do(admin, "GET", path, ""), http.StatusOK, nil)
+
+	var actions []models.AuditAction
+	for _, e := range s.auditEvents(t) {
+		if e.EntityID == client.ID {
+			actions = append(actions, e.Action)
+		}
+	}
+	if want := []models.AuditAction{models.AuditCreate, models.AuditDelete, models.AuditRestore}; !slices.Equal(actions, want) {
+		t.Errorf("audited actions = %v, want %v", actions, want)
+	}
+}
+
+func TestPatchClientIfMatch(t *testing.T) {
+	s := newTestServer(t)
+	w := s.do(admin, "POST", "/api/clients", `{"name": "Anna", "phone": "+79991234567"}`)
+	var client models.Client
+	decode(t, w, http.StatusCreated, &client)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag on the created client")
+	}
+	path := fmt.Sprintf("/api/clients/%d", client.ID)
+
+	var patched models.Client
+	decode(t, s.do(admin, "PATCH", path, `{"name": "Anna K."}`, "If-Match", etag), http.StatusOK, &patched)
+	if patched.Name != "Anna K." || patched.Phone != client.Phone {
+		t.Errorf("patched %+v, want the new name and the old phone", patched)
+	}
+	decode(t, s.do(admin, "PATCH", path, `{"name": "Anna"}`, "If-Match", etag), http.StatusPreconditionFailed, nil)
+}
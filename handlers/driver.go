@@ -1,147 +1,453 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/hse-trpo-taxi/backend/database"
+	"github.com/hse-trpo-taxi/backend/apierr"
 	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
 )
 
+// DriverHandler serves the /api/drivers endpoints, including availability and shift tracking.
+type DriverHandler struct {
+	repo   repository.DriverRepository
+	shifts repository.ShiftRepository
+	audit  *Auditor
+}
+
+// NewDriverHandler returns a DriverHandler that stores drivers in repo and their shifts in shifts.
+// Changes to drivers are recorded in the audit log by audit.
+func NewDriverHandler(repo repository.DriverRepository, shifts repository.ShiftRepository, audit *Auditor) *DriverHandler {
+	return &DriverHandler{repo: repo, shifts: shifts, audit: audit}
+}
+
 // GetDrivers handles GET /api/drivers requests.
-// It retrieves all drivers from the database and returns them as a JSON array.
-// Returns HTTP 500 if there's a database error.
-func GetDrivers(w http.ResponseWriter, r *http.Request) {
-	rows, err := database.DB.Query("SELECT id, name, phone, license_number, rating, created_at, updated_at FROM drivers")
+// It returns a page of drivers as a listResponse. The optional status query parameter
+// (e.g. ?status=available) limits the result to drivers with that status, and min_rating to
+// drivers rated at least that high. Paging and ordering are controlled by limit, cursor and
+// sort (id, name, rating or created_at, prefixed with "-" for descending order).
+// Deleted drivers are left out unless an admin passes include_deleted=true.
+// Returns HTTP 400 if a query parameter is invalid, HTTP 403 if a non-admin asks for deleted
+// drivers, or HTTP 500 if there's a database error.
+func (h *DriverHandler) GetDrivers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, err := parsePage(query, repository.DriverSortFields)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	defer rows.Close()
-
-	drivers := []models.Driver{}
-	for rows.Next() {
-		var driver models.Driver
-		if err := rows.Scan(&driver.ID, &driver.Name, &driver.Phone, &driver.LicenseNumber, &driver.Rating, &driver.CreatedAt, &driver.UpdatedAt); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		drivers = append(drivers, driver)
+	filter := repository.DriverFilter{Status: models.DriverStatus(query.Get("status"))}
+	if filter.Status != "" && !filter.Status.Valid() {
+		writeError(w, r, apierr.BadRequest("Invalid driver status"))
+		return
+	}
+	if filter.MinRating, err = queryFloat(query, "min_rating"); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if filter.IncludeDeleted, err = includeDeleted(r); err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(drivers)
+	drivers, next, err := h.repo.List(r.Context(), filter, page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writePage(w, drivers, page, repository.DriverSortFields, next)
 }
 
 // GetDriver handles GET /api/drivers/{id} requests.
-// It retrieves a specific driver by ID and returns it as JSON.
-// Returns HTTP 400 if the ID is invalid, HTTP 404 if the driver is not found,
-// or HTTP 500 if there's a database error.
-func GetDriver(w http.ResponseWriter, r *http.Request) {
+// It retrieves a specific driver by ID and returns it as JSON with an ETag header,
+// which can be sent back in If-Match to update the driver only if it hasn't changed.
+// A deleted driver is only returned to an admin passing include_deleted=true.
+// Returns HTTP 400 if the ID is invalid, HTTP 403 if a non-admin asks for deleted drivers,
+// HTTP 404 if the driver is not found, or HTTP 500 if there's a database error.
+func (h *DriverHandler) GetDriver(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid driver ID", http.StatusBadRequest)
+		writeError(w, r, apierr.BadRequest("Invalid driver ID"))
+		return
+	}
+	include, err := includeDeleted(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	var driver models.Driver
-	err = database.DB.QueryRow("SELECT id, name, phone, license_number, rating, created_at, updated_at FROM drivers WHERE id = ?", id).
-		Scan(&driver.ID, &driver.Name, &driver.Phone, &driver.LicenseNumber, &driver.Rating, &driver.CreatedAt, &driver.UpdatedAt)
-
+	get := h.repo.Get
+	if include {
+		get = h.repo.GetIncludingDeleted
+	}
+	driver, err := get(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Driver not found", http.StatusNotFound)
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Driver not found"))
+			return
+		}
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(driver)
+	writeRecord(w, http.StatusOK, driver, driver.UpdatedAt)
 }
 
 // CreateDriver handles POST /api/drivers requests.
-// It creates a new driver with the provided JSON data.
-// The created_at and updated_at timestamps are automatically set.
+// It creates a new driver with the provided JSON data, validated by models.Driver.Validate;
+// the phone number is stored in E.164 form.
+// The created_at and updated_at timestamps are automatically set,
+// and the driver starts offline regardless of the status in the request body.
 // Returns the created driver with HTTP 201 on success,
-// HTTP 400 if the request body is invalid, or HTTP 500 if there's a database error.
-func CreateDriver(w http.ResponseWriter, r *http.Request) {
+// HTTP 400 if the request body is malformed, HTTP 422 with the failing fields if it is invalid,
+// HTTP 409 if the phone number or license number belongs to another driver, or HTTP 500 if there's a database error.
+func (h *DriverHandler) CreateDriver(w http.ResponseWriter, r *http.Request) {
 	var driver models.Driver
-	if err := json.NewDecoder(r.Body).Decode(&driver); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &driver); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
+	driver.Status = models.DriverOffline
 	driver.CreatedAt = time.Now()
 	driver.UpdatedAt = time.Now()
+	driver.DeletedAt = nil
 
-	result, err := database.DB.Exec("INSERT INTO drivers (name, phone, license_number, rating, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
-		driver.Name, driver.Phone, driver.LicenseNumber, driver.Rating, driver.CreatedAt, driver.UpdatedAt)
+	err := h.audit.record(r, "driver", models.AuditCreate, &driver.ID, nil, &driver, func(ctx context.Context) error {
+		return h.repo.Create(ctx, &driver)
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	id, _ := result.LastInsertId()
-	driver.ID = int(id)
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(driver)
+	writeRecord(w, http.StatusCreated, driver, driver.UpdatedAt)
 }
 
 // UpdateDriver handles PUT /api/drivers/{id} requests.
-// It updates an existing driver with the provided JSON data.
+// It replaces the profile of an existing driver with the provided JSON data, validated like in CreateDriver.
 // The updated_at timestamp is automatically set to the current time.
-// Returns the updated driver as JSON on success,
-// HTTP 400 if the ID or request body is invalid, or HTTP 500 if there's a database error.
-func UpdateDriver(w http.ResponseWriter, r *http.Request) {
+// The status is not changed; use the online, offline and break endpoints instead.
+// A driver editing their own profile cannot change their rating.
+// If the request has an If-Match header, the driver is only updated if its ETag matches.
+// Returns the updated driver as JSON with its new ETag on success,
+// HTTP 400 if the ID or request body is malformed, HTTP 422 with the failing fields if the body is invalid,
+// HTTP 404 if the driver is not found,
+// HTTP 409 if the phone number or license number belongs to another driver,
+// HTTP 412 if the driver has changed since the ETag in If-Match was issued,
+// or HTTP 500 if there's a database error.
+func (h *DriverHandler) UpdateDriver(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, func(current models.Driver) (models.Driver, error) {
+		var driver models.Driver
+		err := decodeJSON(r, &driver)
+		return driver, err
+	})
+}
+
+// PatchDriver handles PATCH /api/drivers/{id} requests.
+// It applies the JSON Merge Patch (RFC 7396) in the request body to the profile of an
+// existing driver, so fields missing from the patch keep their values, and validates the
+// result like in CreateDriver. The same restrictions as for UpdateDriver apply.
+// If-Match, the response and the status codes are the same as for UpdateDriver,
+// plus HTTP 415 if the body is not application/merge-patch+json or application/json.
+func (h *DriverHandler) PatchDriver(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, func(current models.Driver) (models.Driver, error) {
+		var driver models.Driver
+		err := decodePatch(r, current, &driver)
+		return driver, err
+	})
+}
+
+// update loads the driver named in the URL, checks If-Match against it, builds its new
+// version with change and stores it unless the driver was modified in the meantime.
+func (h *DriverHandler) update(w http.ResponseWriter, r *http.Request, change func(current models.Driver) (models.Driver, error)) {
+	current, ok := h.loadDriver(w, r)
+	if !ok {
+		return
+	}
+	if err := checkIfMatch(r, current.UpdatedAt); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	driver, err := change(current)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	driver.ID = current.ID
+	driver.CreatedAt = current.CreatedAt
+	driver.UpdatedAt = time.Now()
+	driver.DeletedAt = nil
+
+	// Ratings come from clients, so drivers editing their own profile cannot change theirs
+	if !principal(r).IsStaff() {
+		driver.Rating = current.Rating
+	}
+
+	err = h.audit.record(r, "driver", models.AuditUpdate, &driver.ID, &current, &driver, func(ctx context.Context) error {
+		return h.repo.Update(ctx, &driver, current.UpdatedAt)
+	})
+	if err != nil {
+		writeError(w, r, updateError(r, err, "Driver not found"))
+		return
+	}
+
+	writeRecord(w, http.StatusOK, driver, driver.UpdatedAt)
+}
+
+// DeleteDriver handles DELETE /api/drivers/{id} requests.
+// It marks a driver as deleted: the driver is hidden from the API and from dispatch but kept,
+// together with their rides and cars, until it is purged after the retention period,
+// and can be restored until then.
+// Returns HTTP 204 (No Content) on successful deletion,
+// HTTP 400 if the ID is invalid, HTTP 404 if the driver is not found or already deleted,
+// or HTTP 500 if there's a database error.
+func (h *DriverHandler) DeleteDriver(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid driver ID", http.StatusBadRequest)
+		writeError(w, r, apierr.BadRequest("Invalid driver ID"))
 		return
 	}
 
-	var driver models.Driver
-	if err := json.NewDecoder(r.Body).Decode(&driver); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := auditedDelete(r, h.audit, h.repo, "driver", id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Driver not found"))
+			return
+		}
+		writeError(w, r, err)
 		return
 	}
 
-	driver.UpdatedAt = time.Now()
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	_, err = database.DB.Exec("UPDATE drivers SET name = ?, phone = ?, license_number = ?, rating = ?, updated_at = ? WHERE id = ?",
-		driver.Name, driver.Phone, driver.LicenseNumber, driver.Rating, driver.UpdatedAt, id)
+// RestoreDriver handles POST /api/drivers/{id}/restore requests.
+// It undoes DeleteDriver for a driver that has not been purged yet.
+// Returns the restored driver as JSON on success,
+// HTTP 400 if the ID is invalid, HTTP 404 if the driver is not found,
+// HTTP 409 if the driver is not deleted or another driver has taken their phone or license number,
+// or HTTP 500 if there's a database error.
+func (h *DriverHandler) RestoreDriver(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, apierr.BadRequest("Invalid driver ID"))
+		return
+	}
+
+	driver, err := auditedRestore(r, h.audit, h.repo, "driver", id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			writeError(w, r, apierr.NotFound("Driver not found"))
+		case errors.Is(err, repository.ErrConflict):
+			writeError(w, r, apierr.Conflict("Driver is not deleted"))
+		default:
+			writeError(w, r, err)
+		}
+		return
+	}
+
+	writeRecord(w, http.StatusOK, driver, driver.UpdatedAt)
+}
+
+// GoOnline handles POST /api/drivers/{id}/online requests.
+// An offline driver opens a new shift and becomes available; a driver on break becomes available
+// within the current shift.
+// Returns the updated driver as JSON on success, HTTP 400 if the ID is invalid,
+// HTTP 404 if the driver is not found, HTTP 409 if the driver is already online,
+// or HTTP 500 if there's a database error.
+func (h *DriverHandler) GoOnline(w http.ResponseWriter, r *http.Request) {
+	driver, ok := h.loadDriver(w, r)
+	if !ok {
+		return
+	}
+
+	switch driver.Status {
+	case models.DriverOffline:
+		ok := h.setStatus(w, r, &driver, models.DriverAvailable, func(ctx context.Context, now time.Time) error {
+			shift := models.Shift{DriverID: driver.ID, StartedAt: now}
+			if err := h.shifts.Open(ctx, &shift); err != nil && !errors.Is(err, repository.ErrConflict) {
+				return err
+			}
+			return nil
+		})
+		if !ok {
+			return
+		}
+	case models.DriverOnBreak:
+		if !h.setStatus(w, r, &driver, models.DriverAvailable, nil) {
+			return
+		}
+	default:
+		writeError(w, r, apierr.Conflict("Driver is already online"))
 		return
 	}
 
-	driver.ID = id
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(driver)
 }
 
-// DeleteDriver handles DELETE /api/drivers/{id} requests.
-// It removes a driver from the database by ID.
-// Note: This operation may fail if the driver has associated cars due to foreign key constraints.
-// Returns HTTP 204 (No Content) on successful deletion,
-// HTTP 400 if the ID is invalid, or HTTP 500 if there's a database error.
-func DeleteDriver(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+// GoOffline handles POST /api/drivers/{id}/offline requests.
+// An available driver or a driver on break becomes offline and their open shift is closed.
+// Returns the updated driver as JSON on success, HTTP 400 if the ID is invalid,
+// HTTP 404 if the driver is not found, HTTP 409 if the driver is already offline or is on a trip,
+// or HTTP 500 if there's a database error.
+func (h *DriverHandler) GoOffline(w http.ResponseWriter, r *http.Request) {
+	driver, ok := h.loadDriver(w, r)
+	if !ok {
+		return
+	}
+
+	switch driver.Status {
+	case models.DriverAvailable, models.DriverOnBreak:
+		ok := h.setStatus(w, r, &driver, models.DriverOffline, func(ctx context.Context, now time.Time) error {
+			if _, err := h.shifts.Close(ctx, driver.ID, now); err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+			return nil
+		})
+		if !ok {
+			return
+		}
+	case models.DriverOnTrip:
+		writeError(w, r, apierr.Conflict("Driver cannot go offline during a trip"))
+		return
+	default:
+		writeError(w, r, apierr.Conflict("Driver is already offline"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(driver)
+}
+
+// TakeBreak handles POST /api/drivers/{id}/break requests.
+// An available driver stops receiving rides without closing their shift.
+// Returns the updated driver as JSON on success, HTTP 400 if the ID is invalid,
+// HTTP 404 if the driver is not found, HTTP 409 if the driver is not available,
+// or HTTP 500 if there's a database error.
+func (h *DriverHandler) TakeBreak(w http.ResponseWriter, r *http.Request) {
+	driver, ok := h.loadDriver(w, r)
+	if !ok {
+		return
+	}
+
+	if driver.Status != models.DriverAvailable {
+		writeError(w, r, apierr.Conflict("Driver is not available"))
+		return
+	}
+	if !h.setStatus(w, r, &driver, models.DriverOnBreak, nil) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(driver)
+}
+
+// GetDriverShifts handles GET /api/drivers/{id}/shifts requests.
+// It returns a page of the driver's shift history as a listResponse.
+// The optional from and to query parameters (RFC 3339 timestamps) limit the result
+// to shifts overlapping that period, which is what payroll needs. Paging and ordering are
+// controlled by limit, cursor and sort (id or started_at, prefixed with "-" for descending order).
+// Returns HTTP 400 if the ID or a query parameter is invalid, HTTP 404 if the driver is not found,
+// or HTTP 500 if there's a database error.
+func (h *DriverHandler) GetDriverShifts(w http.ResponseWriter, r *http.Request) {
+	driver, ok := h.loadDriver(w, r)
+	if !ok {
+		return
+	}
+	page, err := parsePage(r.URL.Query(), repository.ShiftSortFields)
 	if err != nil {
-		http.Error(w, "Invalid driver ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	_, err = database.DB.Exec("DELETE FROM drivers WHERE id = ?", id)
+	var filter repository.ShiftFilter
+	if filter.From, err = parseTimeParam(r, "from"); err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid from timestamp"))
+		return
+	}
+	if filter.To, err = parseTimeParam(r, "to"); err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid to timestamp"))
+		return
+	}
+
+	shifts, next, err := h.shifts.ListByDriver(r.Context(), driver.ID, filter, page)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
+	writePage(w, shifts, page, repository.ShiftSortFields, next)
+}
 
-	w.WriteHeader(http.StatusNoContent)
+// loadDriver reads the driver identified by the {id} route variable.
+// On failure it writes the error response and returns false.
+func (h *DriverHandler) loadDriver(w http.ResponseWriter, r *http.Request) (models.Driver, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid driver ID"))
+		return models.Driver{}, false
+	}
+
+	driver, err := h.repo.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Driver not found"))
+			return models.Driver{}, false
+		}
+		writeError(w, r, err)
+		return models.Driver{}, false
+	}
+	return driver, true
+}
+
+// setStatus moves driver from its current status to next and records the change; also, if not
+// nil, makes the accompanying change, such as opening a shift, in the same transaction and with
+// the same timestamp. driver is updated to the stored version. On failure, including a concurrent
+// status change, it writes the error response and returns false.
+func (h *DriverHandler) setStatus(w http.ResponseWriter, r *http.Request, driver *models.Driver, next models.DriverStatus, also func(ctx context.Context, now time.Time) error) bool {
+	now := time.Now()
+	updated, err := auditedDriverStatus(r, h.audit, h.repo, driver.ID, func(ctx context.Context) error {
+		if err := h.repo.SetStatus(ctx, driver.ID, driver.Status, next, now); err != nil {
+			return err
+		}
+		if also != nil {
+			return also(ctx, now)
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			writeError(w, r, apierr.NotFound("Driver not found"))
+		case errors.Is(err, repository.ErrConflict):
+			writeError(w, r, apierr.Conflict("Driver status was changed by another request"))
+		default:
+			writeError(w, r, err)
+		}
+		return false
+	}
+	*driver = updated
+	return true
+}
+
+// parseTimeParam parses the named query parameter as an RFC 3339 timestamp.
+// A missing parameter yields the zero time.
+func parseTimeParam(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
 }
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+func TestDriverStatusLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	var driver models.Driver
+	decode(t, s.do(admin, "POST", "/api/drivers", `{"name": "Ivan", "phone": "+79990000001", "license_number": "77 AB 123456"}`), http.StatusCreated, &driver)
+	path := fmt.Sprintf("/api/drivers/%d", driver.ID)
+
+	steps := []struct {
+		action string
+		status int
+		want   models.DriverStatus
+	}{
+		{"online", http.StatusOK, models.DriverAvailable},
+		{"online", http.StatusConflict, models.DriverAvailable},
+		{"break", http.StatusOK, models.DriverOnBreak},
+		{"offline", http.StatusOK, models.DriverOffline},
+		{"break", http.StatusConflict, models.DriverOffline},
+	}
+	for _, step := range steps {
+		w := s.do(admin, "POST", path+"/"+step.action, "")
+		if w.Code != step.status {
+			t.Fatalf("%s: status = %d, want %d; body: %s", step.action, w.Code, step.status, w.Body)
+		}
+		var current models.Driver
+		decode(t, s.do(admin, "GET", path, ""), http.StatusOK, &current)
+		if current.Status != step.want {
+			t.Errorf("after %s: status = %q, want %q", step.action, current.Status, step.want)
+		}
+	}
+
+	var shifts listResponse[models.Shift]
+	decode(t, s.do(admin, "GET", path+"/shifts", ""), http.StatusOK, &shifts)
+	if len(shifts.Items) != 1 || shifts.Items[0].EndedAt == nil {
+		t.Errorf("shifts = %+v, want one closed shift", shifts.Items)
+	}
+
+	var creates, updates int
+	for _, e := range s.auditEvents(t) {
+		if e.EntityType != "driver" || e.EntityID != driver.ID {
+			continue
+		}
+		switch e.Action {
+		case models.AuditCreate:
+			creates++
+		case models.AuditUpdate:
+			updates++
+		}
+	}
+	if creates != 1 || updates != 3 {
+		t.Errorf("audit events: %d creates and %d updates, want 1 and 3", creates, updates)
+	}
+}
+
+func TestDriverStatusNotFound(t *testing.T) {
+	s := newTestServer(t)
+	for _, action := range []string{"online", "offline", "break"} {
+		decode(t, s.do(admin, "POST", "/api/drivers/42/"+action, ""), http.StatusNotFound, nil)
+	}
+	if events := s.auditEvents(t); len(events) != 0 {
+		t.Errorf("audit events = %+v, want none", events)
+	}
+}
+
+func TestGoOnlineTimestamps(t *testing.T) {
+	s := newTestServer(t)
+	var driver models.Driver
+	decode(t, s.do(admin, "POST", "/api/drivers", `{"name": "Ivan", "phone": "+79990000001", "license_number": "77 AB 123456"}`), http.StatusCreated, &driver)
+	path := fmt.Sprintf("/api/drivers/%d", driver.ID)
+
+	var online, stored models.Driver
+	decode(t, s.do(admin, "POST", path+"/online", ""), http.StatusOK, &online)
+	decode(t, s.do(admin, "GET", path, ""), http.StatusOK, &stored)
+	var shifts listResponse[models.Shift]
+	decode(t, s.do(admin, "GET", path+"/shifts", ""), http.StatusOK, &shifts)
+	if len(shifts.Items) != 1 {
+		t.Fatalf("shifts = %+v, want one", shifts.Items)
+	}
+	if !online.UpdatedAt.Equal(stored.UpdatedAt) || !stored.UpdatedAt.Equal(shifts.Items[0].StartedAt) {
+		t.Errorf("updated_at returned %v, stored %v, shift started %v, want all equal", online.UpdatedAt, stored.UpdatedAt, shifts.Items[0].StartedAt)
+	}
+}
+
+func TestDeletedDriverStatus(t *testing.T) {
+	s := newTestServer(t)
+	ride, driver := s.acceptRide(t)
+	decode(t, s.do(admin, "DELETE", fmt.Sprintf("/api/drivers/%d", driver.ID), ""), http.StatusNoContent, nil)
+
+	path := fmt.Sprintf("/api/rides/%d", ride.ID)
+	decode(t, s.do(admin, "POST", path+"/start", ""), http.StatusOK, nil)
+	decode(t, s.do(admin, "POST", path+"/complete", ""), http.StatusOK, nil)
+	if stored, err := s.drivers.GetIncludingDeleted(context.Background(), driver.ID); err != nil || stored.Status != models.DriverOnTrip {
+		t.Errorf("deleted driver = %+v, %v, want the status left as on_trip", stored, err)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/validate"
+)
+
+// writeError writes err as a JSON error response; see apierr.Write.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	apierr.Write(w, r, err)
+}
+
+// validator is implemented by request bodies that check their own fields, such as models.Client.
+type validator interface {
+	Validate() error
+}
+
+// decodeJSON decodes the JSON request body into v and, if v implements validator, validates it.
+// Unknown fields, values of the wrong type and failed validation rules are reported as
+// validate.Errors (HTTP 422); a body that is not JSON at all is an HTTP 400 *apierr.Error.
+func decodeJSON(r *http.Request, v any) error {
+	return decodeJSONFrom(r.Body, v)
+}
+
+// decodeJSONFrom is decodeJSON for a body read from body.
+func decodeJSONFrom(body io.Reader, v any) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return validate.Errors{{Field: typeErr.Field, Message: "must be a " + jsonTypeName(typeErr.Type.Kind().String())}}
+		}
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			if unquoted, err := strconv.Unquote(field); err == nil {
+				field = unquoted
+			}
+			return validate.Errors{{Field: field, Message: "is not a known field"}}
+		}
+		return apierr.BadRequest("Invalid request body").WithDetails(err.Error())
+	}
+	if v, ok := v.(validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// jsonTypeName returns the JSON name of the Go kind a value was expected to have.
+func jsonTypeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "bool":
+		return "boolean"
+	case kind == "slice", kind == "array":
+		return "list"
+	case kind == "struct", kind == "map":
+		return "object"
+	}
+	return kind
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/geo"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/pricing"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// FareHandler serves the /api/fares and /api/surge endpoints.
+type FareHandler struct {
+	tariffs repository.TariffRepository
+	surge   *pricing.Surge
+}
+
+// NewFareHandler returns a FareHandler that prices trips with the tariffs in tariffs
+// and the multipliers of surge.
+func NewFareHandler(tariffs repository.TariffRepository, surge *pricing.Surge) *FareHandler {
+	return &FareHandler{tariffs: tariffs, surge: surge}
+}
+
+// fareEstimateRequest is the request body of POST /api/fares/estimate.
+type fareEstimateRequest struct {
+	PickupLat  float64    `json:"pickup_lat"`
+	PickupLon  float64    `json:"pickup_lon"`
+	DropoffLat float64    `json:"dropoff_lat"`
+	DropoffLon float64    `json:"dropoff_lon"`
+	TariffID   *int       `json:"tariff_id"`
+	PickupTime *time.Time `json:"pickup_time"`
+}
+
+// EstimateFare handles POST /api/fares/estimate requests.
+// It predicts the trip between the pickup and drop-off points and prices it with the
+// requested tariff, or the default tariff if none is given, applying the current surge
+// multiplier at the pickup point. The pickup time, which defaults to now, selects night
+// and holiday pricing.
+// Returns the fare breakdown as JSON on success, HTTP 400 if the request body or coordinates
+// are invalid, HTTP 404 if the tariff is not found, or HTTP 500 if there's a database error.
+func (h *FareHandler) EstimateFare(w http.ResponseWriter, r *http.Request) {
+	var req fareEstimateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !validCoordinates(req.PickupLat, req.PickupLon) || !validCoordinates(req.DropoffLat, req.DropoffLon) {
+		writeError(w, r, apierr.BadRequest("Invalid pickup or dropoff coordinates"))
+		return
+	}
+
+	tariff, err := resolveTariff(r.Context(), h.tariffs, req.TariffID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Tariff not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	start := time.Now()
+	if req.PickupTime != nil {
+		start = *req.PickupTime
+	}
+	pickup := geo.Point{Lat: req.PickupLat, Lon: req.PickupLon}
+	trip := pricing.EstimateTrip(pickup, geo.Point{Lat: req.DropoffLat, Lon: req.DropoffLon}, start)
+	trip.Surge = h.surge.Multiplier(pickup)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pricing.Calculate(tariff, trip))
+}
+
+// GetSurge handles GET /api/surge requests.
+// It returns the surge state of the zone containing ?lat=..&lon=..: the current multiplier,
+// the ride requests and available drivers counted in the zone, and when it was last recomputed.
+// Returns HTTP 400 if the coordinates are missing or invalid.
+func (h *FareHandler) GetSurge(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	lat, latErr := strconv.ParseFloat(query.Get("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(query.Get("lon"), 64)
+	if latErr != nil || lonErr != nil || !validCoordinates(lat, lon) {
+		writeError(w, r, apierr.BadRequest("Valid lat and lon query parameters are required"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.surge.Zone(geo.Point{Lat: lat, Lon: lon}))
+}
+
+// resolveTariff returns the tariff with the given ID, or the default tariff if id is nil.
+// It returns repository.ErrNotFound if that tariff does not exist.
+func resolveTariff(ctx context.Context, tariffs repository.TariffRepository, id *int) (models.Tariff, error) {
+	if id != nil {
+		return tariffs.Get(ctx, *id)
+	}
+	return tariffs.Default(ctx)
+}
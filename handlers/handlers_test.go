@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hse-trpo-taxi/backend/auth"
+	"github.com/hse-trpo-taxi/backend/dispatch"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/otp"
+	"github.com/hse-trpo-taxi/backend/pricing"
+	"github.com/hse-trpo-taxi/backend/repository/memory"
+)
+
+// Callers the tests make requests as.
+var (
+	admin     = auth.Principal{Role: auth.RoleAdmin, Subject: 1}
+	anonymous = auth.Principal{}
+)
+
+// testServer serves the client, driver, car, ride, offer, login and audit endpoints on the in-memory repositories.
+type testServer struct {
+	router  *mux.Router
+	clients *memory.ClientRepository
+	drivers *testDrivers
+	events  *memory.AuditRepository
+	logins  *OTPHandler
+	sms     *testSender
+}
+
+// newTestServer returns a testServer with empty repositories. Routes are registered as
+// in main, but without authorization rules; each request carries the principal it is made as.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	s := &testServer{
+		router:  mux.NewRouter(),
+		clients: memory.NewClientRepository(),
+		drivers: &testDrivers{DriverRepository: memory.NewDriverRepository()},
+		events:  memory.NewAuditRepository(),
+		sms:     &testSender{},
+	}
+	auditor := NewAuditor(memory.NewTransactor(), s.events)
+	keys, signingKey := auth.RandomKey()
+	tokens, err := auth.NewTokens(keys, signingKey, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := otp.NewService(memory.NewOTPRepository(), s.sms, otp.Config{
+		TTL: 5 * time.Minute, ResendInterval: time.Minute, MaxSends: 5, SendWindow: time.Hour, MaxAttempts: 5,
+	})
+
+	carRepo, rideRepo, locationRepo := memory.NewCarRepository(), memory.NewRideRepository(), memory.NewLocationRepository()
+	dispatcher := dispatch.New(rideRepo, s.drivers, carRepo, locationRepo, dispatch.NearestFirst{}, dispatch.Config{
+		Radius: 3000, OfferTimeout: time.Minute,
+	})
+	t.Cleanup(dispatcher.Stop)
+
+	clients := NewClientHandler(s.clients, auditor)
+	drivers := NewDriverHandler(s.drivers, memory.NewShiftRepository(), auditor)
+	cars := NewCarHandler(carRepo, auditor)
+	rides := NewRideHandler(rideRepo, s.drivers, memory.NewTariffRepository(), pricing.NewSurge(pricing.SurgeConfig{}), dispatcher, auditor)
+	offers := NewOfferHandler(dispatcher, rideRepo, s.drivers, auditor)
+	locations := NewLocationHandler(locationRepo, s.drivers)
+	s.logins = NewOTPHandler(codes, s.clients, s.drivers, tokens, auditor)
+	audit := NewAuditHandler(s.events)
+
+	s.router.HandleFunc("/api/auth/otp/request", s.logins.RequestCode).Methods("POST")
+	s.router.HandleFunc("/api/auth/otp/verify", s.logins.VerifyCode).Methods("POST")
+	s.router.HandleFunc("/api/clients", clients.GetClients).Methods("GET")
+	s.router.HandleFunc("/api/clients/{id}", clients.GetClient).Methods("GET")
+	s.router.HandleFunc("/api/clients", clients.CreateClient).Methods("POST")
+	s.router.HandleFunc("/api/clients/{id}", clients.PatchClient).Methods("PATCH")
+	s.router.HandleFunc("/api/clients/{id}", clients.DeleteClient).Methods("DELETE")
+	s.router.HandleFunc("/api/clients/{id}/restore", clients.RestoreClient).Methods("POST")
+	s.router.HandleFunc("/api/drivers/nearby", locations.GetNearbyDrivers).Methods("GET")
+	s.router.HandleFunc("/api/drivers", drivers.CreateDriver).Methods("POST")
+	s.router.HandleFunc("/api/drivers/{id}", drivers.GetDriver).Methods("GET")
+	s.router.HandleFunc("/api/drivers/{id}", drivers.DeleteDriver).Methods("DELETE")
+	s.router.HandleFunc("/api/drivers/{id}/online", drivers.GoOnline).Methods("POST")
+	s.router.HandleFunc("/api/drivers/{id}/offline", drivers.GoOffline).Methods("POST")
+	s.router.HandleFunc("/api/drivers/{id}/break", drivers.TakeBreak).Methods("POST")
+	s.router.HandleFunc("/api/drivers/{id}/shifts", drivers.GetDriverShifts).Methods("GET")
+	s.router.HandleFunc("/api/drivers/{id}/location", locations.UpdateLocation).Methods("POST")
+	s.router.HandleFunc("/api/drivers/{id}/offers", offers.GetDriverOffers).Methods("GET")
+	s.router.HandleFunc("/api/cars", cars.CreateCar).Methods("POST")
+	s.router.HandleFunc("/api/rides/{id}", rides.GetRide).Methods("GET")
+	s.router.HandleFunc("/api/rides", rides.RequestRide).Methods("POST")
+	s.router.HandleFunc("/api/rides/{id}/accept", rides.AcceptRide).Methods("POST")
+	s.router.HandleFunc("/api/rides/{id}/start", rides.StartRide).Methods("POST")
+	s.router.HandleFunc("/api/rides/{id}/complete", rides.CompleteRide).Methods("POST")
+	s.router.HandleFunc("/api/rides/{id}/cancel", rides.CancelRide).Methods("POST")
+	s.router.HandleFunc("/api/offers/{id}/accept", offers.AcceptOffer).Methods("POST")
+	s.router.HandleFunc("/api/audit", audit.GetAuditEvents).Methods("GET")
+	return s
+}
+
+// do makes a request as p and returns the response. A non-empty body is sent as JSON.
+func (s *testServer) do(p auth.Principal, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	if p.Role != "" {
+		r = r.WithContext(auth.WithPrincipal(r.Context(), p))
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, r)
+	return w
+}
+
+// auditEvents returns the audit events stored so far, oldest first.
+func (s *testServer) auditEvents(t *testing.T) []models.AuditEvent {
+	t.Helper()
+	var page listResponse[models.AuditEvent]
+	decode(t, s.do(admin, "GET", "/api/audit", ""), http.StatusOK, &page)
+	return page.Items
+}
+
+// decode checks the status of w and decodes its JSON body into v, if v is not nil.
+func decode(t *testing.T, w *httptest.ResponseRecorder, status int, v any) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, status, w.Body)
+	}
+	if v != nil {
+		if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+			t.Fatalf("decoding %s: %v", w.Body, err)
+		}
+	}
+}
+
+// testDrivers is the driver repository of a testServer. While failStatus is set, SetStatus
+// returns it instead of changing the status.
+type testDrivers struct {
+	*memory.DriverRepository
+	failStatus error
+}
+
+func (d *testDrivers) SetStatus(ctx context.Context, id int, from, to models.DriverStatus, updatedAt time.Time) error {
+	if d.failStatus != nil {
+		return d.failStatus
+	}
+	return d.DriverRepository.SetStatus(ctx, id, from, to, updatedAt)
+}
+
+// testSender is an otp.Sender that keeps the last code sent to each phone number.
+type testSender struct {
+	mu    sync.Mutex
+	codes map[string]string
+}
+
+var codePattern = regexp.MustCompile(`\d{6}`)
+
+func (s *testSender) Send(ctx context.Context, phone, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.codes == nil {
+		s.codes = make(map[string]string)
+	}
+	s.codes[phone] = codePattern.FindString(message)
+	return nil
+}
+
+// code returns the last code sent to phone.
+func (s *testSender) code(phone string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.codes[phone]
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/auth"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// Page size limits of the list endpoints.
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// listResponse is the response body of the list endpoints. NextCursor is passed back
+// as ?cursor= to fetch the following page and is null on the last page.
+type listResponse[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// cursorToken is the content of an opaque cursor. It records the sort it was issued for,
+// so a cursor cannot be replayed against a differently ordered list.
+type cursorToken struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// parsePage reads the limit, sort and cursor query parameters shared by the list endpoints.
+// sort names one of fields, prefixed with "-" for descending order; limit defaults to
+// defaultPageLimit and may not exceed maxPageLimit. Invalid parameters are reported as HTTP 400 errors.
+func parsePage(query url.Values, fields repository.SortFields) (repository.Page, error) {
+	page := repository.Page{Limit: defaultPageLimit}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, apierr.BadRequest(fmt.Sprintf("Limit must be between 1 and %d", maxPageLimit))
+		}
+		page.Limit = limit
+	}
+
+	sort := query.Get("sort")
+	page.Sort, page.Desc = strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+	kind, ok := fields[page.Field()]
+	if !ok {
+		return page, apierr.BadRequest(fmt.Sprintf("Sort must be one of %s, optionally prefixed with -", sortFieldNames(fields)))
+	}
+
+	if s := query.Get("cursor"); s != "" {
+		var token cursorToken
+		raw, err := base64.RawURLEncoding.DecodeString(s)
+		if err == nil {
+			err = json.Unmarshal(raw, &token)
+		}
+		if err != nil {
+			return page, apierr.BadRequest("Invalid cursor")
+		}
+		if token.Sort != sort {
+			return page, apierr.BadRequest("Cursor was issued for a different sort")
+		}
+		value, err := kind.Parse(token.Value)
+		if err != nil {
+			return page, apierr.BadRequest("Invalid cursor")
+		}
+		page.After = &repository.Cursor{Value: value, ID: token.ID}
+	}
+	return page, nil
+}
+
+// sortFieldNames returns the names of fields in a stable order for error messages.
+func sortFieldNames(fields repository.SortFields) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// writePage writes items and the cursor of the following page, if any, as a listResponse.
+func writePage[T any](w http.ResponseWriter, items []T, page repository.Page, fields repository.SortFields, next *repository.Cursor) {
+	response := listResponse[T]{Items: items}
+	if next != nil {
+		sort := page.Sort
+		if page.Desc {
+			sort = "-" + sort
+		}
+		raw, _ := json.Marshal(cursorToken{Sort: sort, Value: fields[page.Field()].Format(next.Value), ID: next.ID})
+		cursor := base64.RawURLEncoding.EncodeToString(raw)
+		response.NextCursor = &cursor
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// queryInt returns the integer query parameter name, or 0 if it is absent.
+func queryInt(query url.Values, name string) (int, error) {
+	s := query.Get(name)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, apierr.BadRequest(fmt.Sprintf("Query parameter %s must be an integer", name))
+	}
+	return n, nil
+}
+
+// includeDeleted reports whether the request asks for deleted records with include_deleted=true.
+// Only admins may see deleted records; anyone else asking for them gets an HTTP 403 error.
+func includeDeleted(r *http.Request) (bool, error) {
+	s := r.URL.Query().Get("include_deleted")
+	if s == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, apierr.BadRequest("Query parameter include_deleted must be true or false")
+	}
+	if include && !principal(r).Is(auth.RoleAdmin) {
+		return false, apierr.Forbidden("Only admins can see deleted records")
+	}
+	return include, nil
+}
+
+// queryFloat returns the numeric query parameter name, or 0 if it is absent.
+func queryFloat(query url.Values, name string) (float64, error) {
+	s := query.Get(name)
+	if s == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, apierr.BadRequest(fmt.Sprintf("Query parameter %s must be a number", name))
+	}
+	return f, nil
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+const (
+	// locationMaxAge is how old a driver's last position may be for the driver to appear in nearby searches
+	locationMaxAge = 2 * time.Minute
+	// locationMaxClockSkew is how far in the future a device timestamp may be before the ping is rejected
+	locationMaxClockSkew = time.Minute
+	// defaultNearbyRadius is the search radius in meters used when the radius parameter is omitted
+	defaultNearbyRadius = 3000
+	// maxNearbyRadius is the largest accepted search radius in meters
+	maxNearbyRadius = 50000
+	// defaultNearbyLimit is the number of drivers returned when the limit parameter is omitted
+	defaultNearbyLimit = 20
+	// maxNearbyLimit is the largest accepted limit parameter
+	maxNearbyLimit = 100
+)
+
+// LocationHandler serves driver GPS ingestion and the nearest-driver search.
+type LocationHandler struct {
+	locations repository.LocationRepository
+	drivers   repository.DriverRepository
+}
+
+// NewLocationHandler returns a LocationHandler that stores positions in locations
+// and looks up driver availability in drivers.
+func NewLocationHandler(locations repository.LocationRepository, drivers repository.DriverRepository) *LocationHandler {
+	return &LocationHandler{locations: locations, drivers: drivers}
+}
+
+// locationPing is the request body of POST /api/drivers/{id}/location.
+type locationPing struct {
+	Lat       *float64  `json:"lat"`
+	Lon       *float64  `json:"lon"`
+	Heading   float64   `json:"heading"`
+	Speed     float64   `json:"speed"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// nearbyDriver is an element of the GET /api/drivers/nearby response.
+type nearbyDriver struct {
+	models.Driver
+	// Location is the driver's latest reported position
+	Location models.DriverLocation `json:"location"`
+	// Distance is the distance from the search center in meters
+	Distance float64 `json:"distance_m"`
+}
+
+// UpdateLocation handles POST /api/drivers/{id}/location requests.
+// It records a GPS ping (lat, lon, heading, speed, timestamp) as the driver's latest position.
+// The timestamp is optional and defaults to the time the ping was received; pings older than
+// the stored position are accepted but ignored.
+// Returns HTTP 204 (No Content) on success, HTTP 400 if the ID or ping is invalid,
+// HTTP 404 if the driver is not found, or HTTP 500 if there's a database error.
+func (h *LocationHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid driver ID"))
+		return
+	}
+
+	var ping locationPing
+	if err := decodeJSON(r, &ping); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if ping.Lat == nil || ping.Lon == nil || !validCoordinates(*ping.Lat, *ping.Lon) {
+		writeError(w, r, apierr.BadRequest("Invalid or missing lat/lon"))
+		return
+	}
+	if ping.Heading < 0 || ping.Heading > 360 || ping.Speed < 0 {
+		writeError(w, r, apierr.BadRequest("Invalid heading or speed"))
+		return
+	}
+	now := time.Now()
+	if ping.Timestamp.IsZero() {
+		ping.Timestamp = now
+	} else if ping.Timestamp.After(now.Add(locationMaxClockSkew)) {
+		writeError(w, r, apierr.BadRequest("Timestamp is in the future"))
+		return
+	}
+
+	if _, err := h.drivers.Get(r.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Driver not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	loc := models.DriverLocation{
+		DriverID:   id,
+		Lat:        *ping.Lat,
+		Lon:        *ping.Lon,
+		Heading:    ping.Heading,
+		Speed:      ping.Speed,
+		RecordedAt: ping.Timestamp,
+	}
+	if err := h.locations.Save(r.Context(), loc); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetNearbyDrivers handles GET /api/drivers/nearby?lat=..&lon=..&radius=..&limit=.. requests.
+// It returns available drivers whose latest position is within radius meters (default 3000,
+// at most 50000) of the given point, nearest first, as a JSON array of drivers with their
+// location and distance_m. Drivers whose last position is older than two minutes are skipped.
+// Returns HTTP 400 if a parameter is missing or invalid, or HTTP 500 if there's a database error.
+func (h *LocationHandler) GetNearbyDrivers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	lat, latErr := strconv.ParseFloat(q.Get("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(q.Get("lon"), 64)
+	if latErr != nil || lonErr != nil || !validCoordinates(lat, lon) {
+		writeError(w, r, apierr.BadRequest("Invalid or missing lat/lon"))
+		return
+	}
+	radius, err := intParam(q.Get("radius"), defaultNearbyRadius, 1, maxNearbyRadius)
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid radius"))
+		return
+	}
+	limit, err := intParam(q.Get("limit"), defaultNearbyLimit, 1, maxNearbyLimit)
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid limit"))
+		return
+	}
+
+	locations, err := h.locations.Nearby(r.Context(), repository.NearbyQuery{
+		Lat:    lat,
+		Lon:    lon,
+		Radius: float64(radius),
+		Since:  time.Now().Add(-locationMaxAge),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	byID, err := availableDrivers(r.Context(), h.drivers, locations)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	result := []nearbyDriver{}
+	for _, loc := range locations {
+		driver, ok := byID[loc.DriverID]
+		if !ok {
+			continue
+		}
+		result = append(result, nearbyDriver{Driver: driver, Location: loc.DriverLocation, Distance: loc.Distance})
+		if len(result) == limit {
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// availableDrivers returns the available drivers among those at locations, by ID.
+func availableDrivers(ctx context.Context, drivers repository.DriverRepository, locations []repository.NearbyLocation) (map[int]models.Driver, error) {
+	byID := make(map[int]models.Driver, len(locations))
+	if len(locations) == 0 {
+		return byID, nil
+	}
+	ids := make([]int, len(locations))
+	for i, loc := range locations {
+		ids[i] = loc.DriverID
+	}
+	available, _, err := drivers.List(ctx, repository.DriverFilter{Status: models.DriverAvailable, IDs: ids}, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	for _, driver := range available {
+		byID[driver.ID] = driver
+	}
+	return byID, nil
+}
+
+// intParam parses an optional integer query parameter, returning def if value is empty
+// and an error if it is not an integer within [min, max].
+func intParam(value string, def, min, max int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n < min || n > max {
+		return 0, strconv.ErrRange
+	}
+	return n, nil
+}
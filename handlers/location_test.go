@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+func TestGetNearbyDrivers(t *testing.T) {
+	s := newTestServer(t)
+	positions := []struct {
+		lat, lon float64
+		online   bool
+	}{
+		{55.7510, 37.6180, true},  // about 130 m from the center
+		{55.7600, 37.6200, true},  // about 1 km away
+		{55.7500, 37.6170, false}, // nearest, but offline
+		{55.9000, 37.8000, true},  // outside the radius
+	}
+	ids := make([]int, len(positions))
+	for i, p := range positions {
+		var driver models.Driver
+		decode(t, s.do(admin, "POST", "/api/drivers", fmt.Sprintf(`{"name": "Driver %d", "phone": "+7999000000%d", "license_number": "77 AB 12345%d"}`, i, i, i)), http.StatusCreated, &driver)
+		ids[i] = driver.ID
+		if p.online {
+			decode(t, s.do(admin, "POST", fmt.Sprintf("/api/drivers/%d/online", driver.ID), ""), http.StatusOK, nil)
+		}
+		decode(t, s.do(admin, "POST", fmt.Sprintf("/api/drivers/%d/location", driver.ID), fmt.Sprintf(`{"lat": %f, "lon": %f}`, p.lat, p.lon)), http.StatusNoContent, nil)
+	}
+
+	var nearby []nearbyDriver
+	decode(t, s.do(admin, "GET", "/api/drivers/nearby?lat=55.75&lon=37.617&radius=3000", ""), http.StatusOK, &nearby)
+	if len(nearby) != 2 || nearby[0].ID != ids[0] || nearby[1].ID != ids[1] {
+		t.Fatalf("nearby drivers = %+v, want drivers %d and %d", nearby, ids[0], ids[1])
+	}
+	if nearby[0].Distance >= nearby[1].Distance || nearby[0].Location.DriverID != ids[0] {
+		t.Errorf("nearby drivers = %+v, want them nearest first with their locations", nearby)
+	}
+
+	decode(t, s.do(admin, "GET", "/api/drivers/nearby?lat=55.75&lon=37.617&radius=3000&limit=1", ""), http.StatusOK, &nearby)
+	if len(nearby) != 1 || nearby[0].ID != ids[0] {
+		t.Errorf("nearby drivers with limit 1 = %+v, want driver %d", nearby, ids[0])
+	}
+
+	decode(t, s.do(admin, "GET", "/api/drivers/nearby?lat=10&lon=10", ""), http.StatusOK, &nearby)
+	if len(nearby) != 0 {
+		t.Errorf("nearby drivers far from everyone = %+v, want none", nearby)
+	}
+}
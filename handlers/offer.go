@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/auth"
+	"github.com/hse-trpo-taxi/backend/dispatch"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// OfferHandler serves the endpoints drivers use to see and answer dispatch offers.
+type OfferHandler struct {
+	dispatcher *dispatch.Dispatcher
+	rides      repository.RideRepository
+	drivers    repository.DriverRepository
+	audit      *Auditor
+}
+
+// NewOfferHandler returns an OfferHandler for the offers made by dispatcher. Accepting an
+// offer assigns the ride, read from rides, and puts the driver, read from drivers, on trip;
+// both changes are recorded with audit.
+func NewOfferHandler(dispatcher *dispatch.Dispatcher, rides repository.RideRepository, drivers repository.DriverRepository, audit *Auditor) *OfferHandler {
+	return &OfferHandler{dispatcher: dispatcher, rides: rides, drivers: drivers, audit: audit}
+}
+
+// offerResponse is the request body of POST /api/offers/{id}/accept and /decline.
+// A driver may omit driver_id, which then defaults to the caller.
+type offerResponse struct {
+	DriverID int `json:"driver_id"`
+}
+
+// GetDriverOffers handles GET /api/drivers/{id}/offers requests.
+// It returns the offers awaiting the driver's answer as a JSON array.
+// Returns HTTP 400 if the ID is invalid.
+func (h *OfferHandler) GetDriverOffers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid driver ID"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.dispatcher.PendingOffers(id))
+}
+
+// AcceptOffer handles POST /api/offers/{id}/accept requests.
+// The driver named in the request body takes the offered ride.
+// Returns the accepted ride as JSON on success, HTTP 400 if the ID or body is invalid,
+// HTTP 403 if the offer was made to another driver, HTTP 404 if the offer is not pending,
+// HTTP 409 if the driver or ride is no longer available, or HTTP 500 on a database error.
+func (h *OfferHandler) AcceptOffer(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, true)
+}
+
+// DeclineOffer handles POST /api/offers/{id}/decline requests.
+// The driver named in the request body turns the offer down and the ride is offered to the next candidate.
+// Returns HTTP 204 (No Content) on success, HTTP 400 if the ID or body is invalid,
+// HTTP 403 if the offer was made to another driver, or HTTP 404 if the offer is not pending.
+func (h *OfferHandler) DeclineOffer(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, false)
+}
+
+// respond records the driver's answer to the offer identified by the {id} route variable.
+func (h *OfferHandler) respond(w http.ResponseWriter, r *http.Request, accept bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid offer ID"))
+		return
+	}
+
+	var req offerResponse
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+	if p := principal(r); p.Is(auth.RoleDriver) {
+		if req.DriverID == 0 {
+			req.DriverID = p.Subject
+		}
+		if !p.Owns(auth.RoleDriver, req.DriverID) {
+			forbidden(w, r)
+			return
+		}
+	}
+
+	var ride models.Ride
+	if accept {
+		ride, err = auditedAssign(r, h.audit, h.rides, h.drivers, h.offeredRide(id, req.DriverID), req.DriverID, func(ctx context.Context) (models.Ride, error) {
+			return h.dispatcher.Respond(ctx, id, req.DriverID, true)
+		})
+	} else {
+		_, err = h.dispatcher.Respond(r.Context(), id, req.DriverID, false)
+	}
+	switch {
+	case errors.Is(err, dispatch.ErrOfferNotFound):
+		writeError(w, r, apierr.NotFound("Offer not found"))
+		return
+	case errors.Is(err, dispatch.ErrNotOfferedDriver):
+		writeError(w, r, apierr.Forbidden("Offer was made to another driver"))
+		return
+	case err != nil:
+		writeError(w, r, assignError(err))
+		return
+	}
+
+	if !accept {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ride)
+}
+
+// offeredRide returns the ID of the ride of the offer with the given ID pending for the
+// driver, or 0 if the driver has no such offer.
+func (h *OfferHandler) offeredRide(offerID, driverID int) int {
+	for _, offer := range h.dispatcher.PendingOffers(driverID) {
+		if offer.ID == offerID {
+			return offer.RideID
+		}
+	}
+	return 0
+}
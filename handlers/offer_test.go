@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/dispatch"
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+func TestAcceptOfferAudit(t *testing.T) {
+	s := newTestServer(t)
+	ride, driver, _ := s.requestRide(t)
+
+	var offers []dispatch.Offer
+	for deadline := time.Now().Add(time.Second); len(offers) == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no offer made to the driver next to the pickup point")
+		}
+		decode(t, s.do(admin, "GET", fmt.Sprintf("/api/drivers/%d/offers", driver.ID), ""), http.StatusOK, &offers)
+	}
+	decode(t, s.do(admin, "POST", fmt.Sprintf("/api/offers/%d/accept", offers[0].ID), fmt.Sprintf(`{"driver_id": %d}`, driver.ID)), http.StatusOK, nil)
+
+	var rideEvents, driverEvents listResponse[models.AuditEvent]
+	decode(t, s.do(admin, "GET", fmt.Sprintf("/api/audit?entity=ride&id=%d", ride.ID), ""), http.StatusOK, &rideEvents)
+	if n := len(rideEvents.Items); n != 2 || rideEvents.Items[1].Action != models.AuditUpdate {
+		t.Errorf("ride audit events = %+v, want the creation and the acceptance", rideEvents.Items)
+	}
+	decode(t, s.do(admin, "GET", fmt.Sprintf("/api/audit?entity=driver&id=%d", driver.ID), ""), http.StatusOK, &driverEvents)
+	if n := len(driverEvents.Items); n != 3 {
+		t.Errorf("driver audit events = %+v, want the creation, going online and being put on trip", driverEvents.Items)
+	}
+}
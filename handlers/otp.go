@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/auth"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/otp"
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/hse-trpo-taxi/backend/validate"
+)
+
+// OTPHandler serves the /api/auth/otp endpoints, which log clients and drivers in
+// with a one-time code sent to their phone number.
+// Clients are registered on their first successful login unless sign-up is switched off;
+// drivers must already exist.
+type OTPHandler struct {
+	codes   *otp.Service
+	clients repository.ClientRepository
+	drivers repository.DriverRepository
+	tokens  *auth.Tokens
+	audit   *Auditor
+	signup  atomic.Bool
+}
+
+// NewOTPHandler returns an OTPHandler that sends and checks codes with codes, looks up
+// accounts in clients and drivers, and issues access tokens with tokens. Clients registered
+// on their first login are recorded with audit. Client sign-up is on.
+func NewOTPHandler(codes *otp.Service, clients repository.ClientRepository, drivers repository.DriverRepository, tokens *auth.Tokens, audit *Auditor) *OTPHandler {
+	h := &OTPHandler{codes: codes, clients: clients, drivers: drivers, tokens: tokens, audit: audit}
+	h.signup.Store(true)
+	return h
+}
+
+// SetSignup switches the registration of new clients on their first login on or off.
+func (h *OTPHandler) SetSignup(enabled bool) {
+	h.signup.Store(enabled)
+}
+
+// otpRequest is the request body of POST /api/auth/otp/request and /api/auth/otp/verify.
+// Name is only used to register a client logging in for the first time.
+type otpRequest struct {
+	Phone string    `json:"phone"`
+	Role  auth.Role `json:"role"`
+	Code  string    `json:"code"`
+	Name  string    `json:"name"`
+}
+
+// tokenResponse is the response body of a successful login.
+type tokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Role      auth.Role `json:"role"`
+	Subject   int       `json:"subject"`
+}
+
+// Validate normalizes the phone number to E.164, defaults the role to client and checks both.
+func (req *otpRequest) Validate() error {
+	if req.Role == "" {
+		req.Role = auth.RoleClient
+	}
+	return validate.All(
+		validate.Phone("phone", &req.Phone),
+		validate.OneOf("role", string(req.Role), string(auth.RoleClient), string(auth.RoleDriver)),
+	)
+}
+
+// decodeOTPRequest reads and validates an otpRequest.
+// It writes the error response and returns false if the body is invalid.
+func decodeOTPRequest(w http.ResponseWriter, r *http.Request) (otpRequest, bool) {
+	var req otpRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return req, false
+	}
+	return req, true
+}
+
+// RequestCode handles POST /api/auth/otp/request requests.
+// It sends a login code to the phone number for the given role ("client" by default).
+// To avoid revealing which numbers belong to drivers, a driver request for an unknown
+// number is answered the same way but no code is sent.
+// Returns HTTP 202 on success, HTTP 400 if the request body is malformed,
+// HTTP 422 if the phone number or role is invalid, HTTP 429 with a Retry-After header if codes are requested too often,
+// or HTTP 500 if the code cannot be stored or sent.
+func (h *OTPHandler) RequestCode(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeOTPRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if req.Role == auth.RoleDriver {
+		if _, err := h.drivers.GetByPhone(r.Context(), req.Phone); errors.Is(err, repository.ErrNotFound) {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		} else if err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	var limited *otp.RateLimitError
+	if err := h.codes.Request(r.Context(), req.Phone, string(req.Role)); errors.As(err, &limited) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		writeError(w, r, apierr.TooManyRequests("Too many code requests"))
+		return
+	} else if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// VerifyCode handles POST /api/auth/otp/verify requests.
+// It checks the code sent to the phone number and, if it matches, issues an access token
+// for the client or driver with that number. A client logging in for the first time is
+// registered with the phone number and the name from the request, unless sign-up is switched off.
+// Returns the token as JSON on success, HTTP 400 if the request body is malformed,
+// HTTP 422 if the phone number or role is invalid or a new client gives no name, HTTP 401 if the code is wrong or expired or no driver has the number,
+// HTTP 403 if no client has the number and sign-up is switched off,
+// HTTP 429 if the code was guessed wrong too many times, or HTTP 500 if there's a database error.
+func (h *OTPHandler) VerifyCode(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeOTPRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.codes.Verify(r.Context(), req.Phone, string(req.Role), req.Code)
+	switch {
+	case errors.Is(err, otp.ErrInvalidCode):
+		writeError(w, r, apierr.Unauthorized("Invalid or expired code"))
+		return
+	case errors.Is(err, otp.ErrTooManyAttempts):
+		writeError(w, r, apierr.TooManyRequests("Too many attempts, request a new code"))
+		return
+	case err != nil:
+		writeError(w, r, err)
+		return
+	}
+
+	p := auth.Principal{Role: req.Role}
+	if req.Role == auth.RoleDriver {
+		driver, err := h.drivers.GetByPhone(r.Context(), req.Phone)
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.Unauthorized("Invalid or expired code"))
+			return
+		} else if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		p.Subject = driver.ID
+	} else {
+		client, err := h.clients.GetByPhone(r.Context(), req.Phone)
+		if errors.Is(err, repository.ErrNotFound) && !h.signup.Load() {
+			writeError(w, r, apierr.Forbidden("Registration of new clients is disabled"))
+			return
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			client = models.Client{Name: req.Name, Phone: req.Phone, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+			if err := client.Validate(); err != nil {
+				writeError(w, r, err)
+				return
+			}
+			err = h.audit.record(r, "client", models.AuditCreate, &client.ID, nil, &client, func(ctx context.Context) error {
+				return h.clients.Create(ctx, &client)
+			})
+			if err == nil {
+				slog.InfoContext(r.Context(), "Registered client on first login", "client_id", client.ID)
+			}
+		}
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		p.Subject = client.ID
+	}
+
+	token, expiresAt, err := h.tokens.Issue(p)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokenResponse{Token: token, ExpiresAt: expiresAt, Role: p.Role, Subject: p.Subject})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hse-trpo-taxi/backend/auth"
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+func TestOTPClientSignup(t *testing.T) {
+	s := newTestServer(t)
+	decode(t, s.do(anonymous, "POST", "/api/auth/otp/request", `{"phone": "8 999 123-45-67"}`), http.StatusAccepted, nil)
+	code := s.sms.code("+79991234567")
+	if code == "" {
+		t.Fatal("no code sent to +79991234567")
+	}
+
+	decode(t, s.do(anonymous, "POST", "/api/auth/otp/verify", `{"phone": "+79991234567", "code": "000000x"}`), http.StatusUnauthorized, nil)
+
+	var token tokenResponse
+	decode(t, s.do(anonymous, "POST", "/api/auth/otp/verify", `{"phone": "89991234567", "code": "`+code+`", "name": "Anna"}`), http.StatusOK, &token)
+	if token.Token == "" || token.Role != auth.RoleClient || token.Subject == 0 {
+		t.Fatalf("token = %+v, want a client token", token)
+	}
+
+	var page listResponse[models.Client]
+	decode(t, s.do(admin, "GET", "/api/clients?phone=%2B79991234567", ""), http.StatusOK, &page)
+	if len(page.Items) != 1 || page.Items[0].ID != token.Subject || page.Items[0].Name != "Anna" {
+		t.Errorf("clients with the phone number = %+v, want client %d named Anna", page.Items, token.Subject)
+	}
+
+	events := s.auditEvents(t)
+	if len(events) != 1 || events[0].Action != models.AuditCreate || events[0].EntityID != token.Subject || events[0].ActorID != nil {
+		t.Errorf("audit events = %+v, want an unattributed creation of client %d", events, token.Subject)
+	}
+}
+
+func TestOTPSignupWithoutName(t *testing.T) {
+	s := newTestServer(t)
+	decode(t, s.do(anonymous, "POST", "/api/auth/otp/request", `{"phone": "+79991234567"}`), http.StatusAccepted, nil)
+	decode(t, s.do(anonymous, "POST", "/api/auth/otp/verify", `{"phone": "+79991234567", "code": "`+s.sms.code("+79991234567")+`"}`), http.StatusUnprocessableEntity, nil)
+	if events := s.auditEvents(t); len(events) != 0 {
+		t.Errorf("audit events = %+v, want none", events)
+	}
+}
+
+func TestOTPSignupDisabled(t *testing.T) {
+	s := newTestServer(t)
+	s.logins.SetSignup(false)
+	decode(t, s.do(anonymous, "POST", "/api/auth/otp/request", `{"phone": "+79991234567"}`), http.StatusAccepted, nil)
+	decode(t, s.do(anonymous, "POST", "/api/auth/otp/verify", `{"phone": "+79991234567", "code": "`+s.sms.code("+79991234567")+`"}`), http.StatusForbidden, nil)
+	if events := s.auditEvents(t); len(events) != 0 {
+		t.Errorf("audit events = %+v, want none", events)
+	}
+}
+
+func TestOTPUnknownDriver(t *testing.T) {
+	s := newTestServer(t)
+	decode(t, s.do(anonymous, "POST", "/api/auth/otp/request", `{"phone": "+79990000001", "role": "driver"}`), http.StatusAccepted, nil)
+	if code := s.sms.code("+79990000001"); code != "" {
+		t.Errorf("code %s sent to a number no driver has", code)
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// mergePatchType is the media type of a JSON Merge Patch (RFC 7396).
+const mergePatchType = "application/merge-patch+json"
+
+// etag returns the entity tag of a record last modified at updatedAt.
+// The tag is built from the wall-clock time rounded to microseconds, the precision
+// PostgreSQL stores, so a record read back from the database has the tag it was written with.
+func etag(updatedAt time.Time) string {
+	return `"` + updatedAt.Round(time.Microsecond).Format("20060102T150405.000000") + `"`
+}
+
+// writeRecord writes v as JSON with the given status and an ETag header for updatedAt.
+func writeRecord(w http.ResponseWriter, status int, v any, updatedAt time.Time) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(updatedAt))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// checkIfMatch compares the If-Match header with the entity tag of a record last modified
+// at updatedAt. It returns an HTTP 412 error if the header is present and lists neither
+// that tag nor "*". Weak tags never match, as If-Match requires strong comparison.
+func checkIfMatch(r *http.Request, updatedAt time.Time) error {
+	values := r.Header.Values("If-Match")
+	if len(values) == 0 {
+		return nil
+	}
+	current := etag(updatedAt)
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag == "*" || tag == current {
+				return nil
+			}
+		}
+	}
+	return apierr.PreconditionFailed("Record has been modified since it was read")
+}
+
+// updateError converts the error of a conditional repository Update into the response error.
+// A record changed between reading and writing it is reported as HTTP 412 if the request
+// had an If-Match header and as HTTP 409 otherwise; a record deleted in between as HTTP 404
+// with notFound as the message.
+func updateError(r *http.Request, err error, notFound string) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return apierr.NotFound(notFound)
+	case errors.Is(err, repository.ErrConflict) && r.Header.Get("If-Match") != "":
+		return apierr.PreconditionFailed("Record has been modified since it was read")
+	}
+	return err
+}
+
+// decodePatch applies the JSON Merge Patch (RFC 7396) in the request body to current and
+// decodes the result into v like decodeJSON, so the patched record is validated as a whole.
+// Object members set to null are removed, which resets the field to its zero value.
+// The body must be a JSON object sent as application/merge-patch+json or application/json.
+func decodePatch(r *http.Request, current, v any) error {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != mergePatchType && mediaType != "application/json") {
+			return apierr.UnsupportedMediaType("Patch must be sent as " + mergePatchType)
+		}
+	}
+
+	var patch map[string]any
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&patch); err != nil {
+		return apierr.BadRequest("Patch must be a JSON object").WithDetails(err.Error())
+	}
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	var document map[string]any
+	decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return err
+	}
+
+	if data, err = json.Marshal(mergePatch(document, patch)); err != nil {
+		return err
+	}
+	return decodeJSONFrom(bytes.NewReader(data), v)
+}
+
+// mergePatch applies patch to target as described in RFC 7396 and returns the result.
+// target may be modified.
+func mergePatch(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = map[string]any{}
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = mergePatch(object[name], value)
+		}
+	}
+	return object
+}
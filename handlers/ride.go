@@ -0,0 +1,480 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/auth"
+	"github.com/hse-trpo-taxi/backend/dispatch"
+	"github.com/hse-trpo-taxi/backend/geo"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/pricing"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// RideHandler serves the /api/rides endpoints.
+// Status changes go through a fixed state machine (see models.RideStatus.CanTransitionTo);
+// an illegal move is rejected with HTTP 409. New rides are handed to the dispatcher,
+// which offers them to nearby drivers. Accepting a ride puts the driver on trip,
+// and completing or cancelling it makes the driver available again in the same transaction.
+// Completed rides are priced with the tariff and surge multiplier in effect when the ride was requested.
+// Every change to a ride is recorded in the audit log together with the driver status change it causes.
+type RideHandler struct {
+	rides      repository.RideRepository
+	drivers    repository.DriverRepository
+	tariffs    repository.TariffRepository
+	surge      *pricing.Surge
+	dispatcher *dispatch.Dispatcher
+	audit      *Auditor
+}
+
+// NewRideHandler returns a RideHandler that stores rides in rides, tracks driver
+// availability in drivers, prices rides with the tariffs in tariffs and the multipliers
+// of surge, and assigns rides to drivers through dispatcher. Ride and driver status changes
+// are recorded with audit.
+func NewRideHandler(rides repository.RideRepository, drivers repository.DriverRepository, tariffs repository.TariffRepository,
+	surge *pricing.Surge, dispatcher *dispatch.Dispatcher, audit *Auditor) *RideHandler {
+	return &RideHandler{rides: rides, drivers: drivers, tariffs: tariffs, surge: surge, dispatcher: dispatcher, audit: audit}
+}
+
+// rideRequest is the request body of POST /api/rides.
+type rideRequest struct {
+	ClientID   int     `json:"client_id"`
+	PickupLat  float64 `json:"pickup_lat"`
+	PickupLon  float64 `json:"pickup_lon"`
+	DropoffLat float64 `json:"dropoff_lat"`
+	DropoffLon float64 `json:"dropoff_lon"`
+	TariffID   *int    `json:"tariff_id"`
+}
+
+// acceptRideRequest is the request body of POST /api/rides/{id}/accept.
+type acceptRideRequest struct {
+	DriverID int `json:"driver_id"`
+	CarID    int `json:"car_id"`
+}
+
+// completeRideRequest is the request body of POST /api/rides/{id}/complete.
+// Distance is the odometer distance in meters reported by the driver's app;
+// Waiting is how long the driver waited at the pickup point, in seconds.
+type completeRideRequest struct {
+	Distance *float64 `json:"distance_m"`
+	Waiting  int      `json:"waiting_s"`
+}
+
+// validCoordinates reports whether lat and lon are within the valid WGS 84 ranges.
+func validCoordinates(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+// GetRides handles GET /api/rides requests.
+// It returns a page of rides as a listResponse. The optional status query parameter
+// (e.g. ?status=requested) limits the result to rides with that status. Paging and ordering
+// are controlled by limit, cursor and sort (id or requested_at, prefixed with "-" for descending order).
+// Returns HTTP 400 if a query parameter is invalid, or HTTP 500 if there's a database error.
+func (h *RideHandler) GetRides(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, err := parsePage(query, repository.RideSortFields)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	filter := repository.RideFilter{Status: models.RideStatus(query.Get("status"))}
+	if filter.Status != "" && !filter.Status.Valid() {
+		writeError(w, r, apierr.BadRequest("Invalid ride status"))
+		return
+	}
+
+	rides, next, err := h.rides.List(r.Context(), filter, page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writePage(w, rides, page, repository.RideSortFields, next)
+}
+
+// GetRide handles GET /api/rides/{id} requests.
+// It retrieves a specific ride by ID and returns it as JSON.
+// Clients and drivers may only see rides they take part in.
+// Returns HTTP 400 if the ID is invalid, HTTP 403 if the caller does not take part in the ride,
+// HTTP 404 if the ride is not found, or HTTP 500 if there's a database error.
+func (h *RideHandler) GetRide(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid ride ID"))
+		return
+	}
+
+	ride, err := h.rides.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Ride not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+	if !participant(principal(r), ride, auth.RoleClient, auth.RoleDriver) {
+		forbidden(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ride)
+}
+
+// RequestRide handles POST /api/rides requests.
+// It creates a new ride in the requested status for the given client and pickup/drop-off points
+// and starts dispatching it to nearby drivers. The ride is priced with the requested tariff,
+// or the default tariff if none is given; without either it is completed with no fare.
+// The current surge multiplier at the pickup point is locked in, and the request counts
+// towards demand in its zone. A client may omit client_id and can only order rides for themselves.
+// Returns the created ride with HTTP 201 on success,
+// HTTP 400 if the request body, coordinates or tariff are invalid,
+// HTTP 403 if a client orders for someone else, or HTTP 500 if there's a database error.
+func (h *RideHandler) RequestRide(w http.ResponseWriter, r *http.Request) {
+	var req rideRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if p := principal(r); p.Is(auth.RoleClient) {
+		if req.ClientID == 0 {
+			req.ClientID = p.Subject
+		}
+		if !p.Owns(auth.RoleClient, req.ClientID) {
+			forbidden(w, r)
+			return
+		}
+	}
+	if req.ClientID <= 0 {
+		writeError(w, r, apierr.BadRequest("client_id is required"))
+		return
+	}
+	if !validCoordinates(req.PickupLat, req.PickupLon) || !validCoordinates(req.DropoffLat, req.DropoffLon) {
+		writeError(w, r, apierr.BadRequest("Invalid pickup or dropoff coordinates"))
+		return
+	}
+
+	var tariffID *int
+	tariff, err := resolveTariff(r.Context(), h.tariffs, req.TariffID)
+	switch {
+	case err == nil:
+		tariffID = &tariff.ID
+	case errors.Is(err, repository.ErrNotFound) && req.TariffID != nil:
+		writeError(w, r, apierr.BadRequest("Unknown tariff"))
+		return
+	case !errors.Is(err, repository.ErrNotFound):
+		writeError(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	pickup := geo.Point{Lat: req.PickupLat, Lon: req.PickupLon}
+	ride := models.Ride{
+		ClientID:        req.ClientID,
+		PickupLat:       req.PickupLat,
+		PickupLon:       req.PickupLon,
+		DropoffLat:      req.DropoffLat,
+		DropoffLon:      req.DropoffLon,
+		Status:          models.RideRequested,
+		TariffID:        tariffID,
+		SurgeMultiplier: h.surge.Multiplier(pickup),
+		RequestedAt:     now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	err = h.audit.record(r, "ride", models.AuditCreate, &ride.ID, nil, &ride, func(ctx context.Context) error {
+		return h.rides.Create(ctx, &ride)
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.dispatcher.Dispatch(ride)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ride)
+}
+
+// AcceptRide handles POST /api/rides/{id}/accept requests.
+// It assigns the driver and car from the request body to a requested ride, bypassing any
+// pending dispatch offer. The car must belong to the accepting driver, and the driver must
+// be available; on success the driver is moved to the on_trip status.
+// A driver may omit driver_id and can only accept rides for themselves.
+// Returns the updated ride as JSON on success, HTTP 400 if the ID or body is invalid
+// or the car does not belong to the driver, HTTP 403 if a driver accepts for someone else,
+// HTTP 404 if the ride is not found,
+// HTTP 409 if the driver is not available or the ride cannot be accepted in its current status,
+// or HTTP 500 on a database error.
+func (h *RideHandler) AcceptRide(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid ride ID"))
+		return
+	}
+
+	var req acceptRideRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if p := principal(r); p.Is(auth.RoleDriver) {
+		if req.DriverID == 0 {
+			req.DriverID = p.Subject
+		}
+		if !p.Owns(auth.RoleDriver, req.DriverID) {
+			forbidden(w, r)
+			return
+		}
+	}
+	if req.DriverID <= 0 || req.CarID <= 0 {
+		writeError(w, r, apierr.BadRequest("driver_id and car_id are required"))
+		return
+	}
+
+	ride, err := auditedAssign(r, h.audit, h.rides, h.drivers, id, req.DriverID, func(ctx context.Context) (models.Ride, error) {
+		return h.dispatcher.Assign(ctx, id, req.DriverID, req.CarID)
+	})
+	if err != nil {
+		writeError(w, r, assignError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ride)
+}
+
+// StartRide handles POST /api/rides/{id}/start requests.
+// It marks an accepted ride as in progress once the client has been picked up.
+// Only the assigned driver and staff may start a ride.
+// Returns the updated ride as JSON on success, HTTP 400 if the ID is invalid,
+// HTTP 403 if the caller may not start the ride, HTTP 404 if the ride is not found,
+// HTTP 409 if the ride cannot be started in its current status,
+// or HTTP 500 if there's a database error.
+func (h *RideHandler) StartRide(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, models.RideInProgress, []auth.Role{auth.RoleDriver}, func(ride *models.Ride, now time.Time) error {
+		ride.StartedAt = &now
+		return nil
+	}, nil)
+}
+
+// CompleteRide handles POST /api/rides/{id}/complete requests.
+// It marks an in-progress ride as completed and calculates its fare from the ride's tariff,
+// the time since the client was picked up, and the distance and waiting time in the optional
+// request body. Without a reported distance the road distance between the pickup and drop-off
+// points is estimated. Only the assigned driver and staff may complete a ride.
+// Returns the updated ride as JSON on success, HTTP 400 if the ID or body is invalid,
+// HTTP 403 if the caller may not complete the ride, HTTP 404 if the ride is not found,
+// HTTP 409 if the ride cannot be completed in its current status,
+// or HTTP 500 if there's a database error.
+func (h *RideHandler) CompleteRide(w http.ResponseWriter, r *http.Request) {
+	var req completeRideRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+	if (req.Distance != nil && *req.Distance < 0) || req.Waiting < 0 {
+		writeError(w, r, apierr.BadRequest("distance_m and waiting_s must not be negative"))
+		return
+	}
+
+	h.transition(w, r, models.RideCompleted, []auth.Role{auth.RoleDriver}, func(ride *models.Ride, now time.Time) error {
+		ride.CompletedAt = &now
+		return h.price(r.Context(), ride, req)
+	}, h.releaseDriver(r))
+}
+
+// CancelRide handles POST /api/rides/{id}/cancel requests.
+// A ride can be cancelled while it is requested or accepted, but not once it is in progress.
+// Cancelling a ride stops its dispatch. The ride's client, its assigned driver and staff may cancel it.
+// Returns the updated ride as JSON on success, HTTP 400 if the ID is invalid,
+// HTTP 403 if the caller may not cancel the ride, HTTP 404 if the ride is not found,
+// HTTP 409 if the ride cannot be cancelled in its current status,
+// or HTTP 500 if there's a database error.
+func (h *RideHandler) CancelRide(w http.ResponseWriter, r *http.Request) {
+	ride, ok := h.transition(w, r, models.RideCancelled, []auth.Role{auth.RoleClient, auth.RoleDriver}, func(ride *models.Ride, now time.Time) error {
+		ride.CancelledAt = &now
+		return nil
+	}, h.releaseDriver(r))
+	if ok {
+		h.dispatcher.Cancel(ride.ID)
+	}
+}
+
+// participant reports whether p may act on ride: staff always may, a client in roles
+// if it is their ride, and a driver in roles if they are assigned to it.
+func participant(p auth.Principal, ride models.Ride, roles ...auth.Role) bool {
+	switch {
+	case p.IsStaff():
+		return true
+	case !p.Is(roles...):
+		return false
+	case p.Role == auth.RoleClient:
+		return p.Subject == ride.ClientID
+	case p.Role == auth.RoleDriver:
+		return ride.DriverID != nil && p.Subject == *ride.DriverID
+	}
+	return false
+}
+
+// assignError converts an error returned by dispatch.Dispatcher.Assign or Respond into an API error.
+func assignError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return apierr.NotFound("Ride not found")
+	case errors.Is(err, dispatch.ErrCarNotOwned):
+		return apierr.BadRequest("Car does not belong to driver")
+	case errors.Is(err, dispatch.ErrDriverUnavailable):
+		return apierr.Conflict("Driver is not available")
+	case errors.Is(err, dispatch.ErrRideNotOpen):
+		return apierr.Conflict("Ride is no longer open for acceptance")
+	}
+	return err
+}
+
+// price sets the fare, distance and duration of a ride being completed.
+// A ride without a tariff, or whose tariff no longer exists, gets no fare.
+func (h *RideHandler) price(ctx context.Context, ride *models.Ride, req completeRideRequest) error {
+	start := ride.RequestedAt
+	if ride.StartedAt != nil {
+		start = *ride.StartedAt
+	}
+	trip := pricing.Trip{
+		Duration: ride.CompletedAt.Sub(start),
+		Waiting:  time.Duration(req.Waiting) * time.Second,
+		Start:    start,
+		Surge:    ride.SurgeMultiplier,
+	}
+	if req.Distance != nil {
+		trip.Distance = *req.Distance
+	} else {
+		trip.Distance = pricing.EstimateTrip(
+			geo.Point{Lat: ride.PickupLat, Lon: ride.PickupLon},
+			geo.Point{Lat: ride.DropoffLat, Lon: ride.DropoffLon},
+			start,
+		).Distance
+	}
+	duration := int(trip.Duration.Seconds())
+	ride.Distance = &trip.Distance
+	ride.Duration = &duration
+
+	if ride.TariffID == nil {
+		return nil
+	}
+	tariff, err := h.tariffs.Get(ctx, *ride.TariffID)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.WarnContext(ctx, "Tariff of ride no longer exists, completing without a fare", "ride_id", ride.ID, "tariff_id", *ride.TariffID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fare := pricing.Calculate(tariff, trip).Total
+	ride.Fare = &fare
+	return nil
+}
+
+// releaseDriver returns a function that makes the driver assigned to a finished ride
+// available again and records the change, for transition to call in the ride's transaction.
+// A driver whose status has already moved on from on_trip, or who has been deleted, is left as is.
+func (h *RideHandler) releaseDriver(r *http.Request) func(ctx context.Context, ride models.Ride) error {
+	return func(ctx context.Context, ride models.Ride) error {
+		if ride.DriverID == nil {
+			return nil
+		}
+		_, err := auditedDriverStatus(r.WithContext(ctx), h.audit, h.drivers, *ride.DriverID, func(ctx context.Context) error {
+			return h.drivers.SetStatus(ctx, *ride.DriverID, models.DriverOnTrip, models.DriverAvailable, ride.UpdatedAt)
+		})
+		if errors.Is(err, repository.ErrConflict) || errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+}
+
+// transition moves the ride identified by the {id} route variable to status next,
+// applying apply to set the fields that accompany the new status, and writes the result.
+// Besides staff, only participants of the ride in roles may make the transition.
+// If also is not nil, it is called with the updated ride in the transaction that stores it
+// and records the change.
+// An error from apply or also is reported as HTTP 500 and the ride is left unchanged.
+// It returns the updated ride and whether the transition succeeded.
+func (h *RideHandler) transition(w http.ResponseWriter, r *http.Request, next models.RideStatus, roles []auth.Role,
+	apply func(ride *models.Ride, now time.Time) error, also func(ctx context.Context, ride models.Ride) error) (models.Ride, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid ride ID"))
+		return models.Ride{}, false
+	}
+
+	ride, err := h.rides.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Ride not found"))
+			return models.Ride{}, false
+		}
+		writeError(w, r, err)
+		return models.Ride{}, false
+	}
+
+	if !participant(principal(r), ride, roles...) {
+		forbidden(w, r)
+		return models.Ride{}, false
+	}
+
+	from := ride.Status
+	if !from.CanTransitionTo(next) {
+		writeError(w, r, apierr.Conflict(fmt.Sprintf("Cannot move ride from %s to %s", from, next)))
+		return models.Ride{}, false
+	}
+
+	before := ride
+	now := time.Now()
+	ride.Status = next
+	ride.UpdatedAt = now
+	if err := apply(&ride, now); err != nil {
+		writeError(w, r, err)
+		return models.Ride{}, false
+	}
+
+	err = h.audit.record(r, "ride", models.AuditUpdate, &ride.ID, &before, &ride, func(ctx context.Context) error {
+		if err := h.rides.Transition(ctx, &ride, from); err != nil {
+			return err
+		}
+		if also != nil {
+			return also(ctx, ride)
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			writeError(w, r, apierr.NotFound("Ride not found"))
+		case errors.Is(err, repository.ErrConflict):
+			writeError(w, r, apierr.Conflict("Ride status was changed by another request"))
+		default:
+			writeError(w, r, err)
+		}
+		return models.Ride{}, false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ride)
+	return ride, true
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// requestRide creates a client and an online driver with a car next to the pickup point,
+// and requests a ride for the client. It returns the ride, the driver and the car.
+func (s *testServer) requestRide(t *testing.T) (models.Ride, models.Driver, models.Car) {
+	t.Helper()
+	var client models.Client
+	decode(t, s.do(admin, "POST", "/api/clients", `{"name": "Anna", "phone": "+79991234567"}`), http.StatusCreated, &client)
+	var driver models.Driver
+	decode(t, s.do(admin, "POST", "/api/drivers", `{"name": "Ivan", "phone": "+79990000001", "license_number": "77 AB 123456"}`), http.StatusCreated, &driver)
+	var car models.Car
+	decode(t, s.do(admin, "POST", "/api/cars", fmt.Sprintf(`{"driver_id": %d, "brand": "Kia", "model": "Rio", "year": 2020, "license_plate": "А123ВС77", "color": "white"}`, driver.ID)), http.StatusCreated, &car)
+	decode(t, s.do(admin, "POST", fmt.Sprintf("/api/drivers/%d/online", driver.ID), ""), http.StatusOK, nil)
+	decode(t, s.do(admin, "POST", fmt.Sprintf("/api/drivers/%d/location", driver.ID), `{"lat": 55.7501, "lon": 37.6101}`), http.StatusNoContent, nil)
+
+	var ride models.Ride
+	decode(t, s.do(admin, "POST", "/api/rides", fmt.Sprintf(`{"client_id": %d, "pickup_lat": 55.75, "pickup_lon": 37.61, "dropoff_lat": 55.76, "dropoff_lon": 37.64}`, client.ID)), http.StatusCreated, &ride)
+	return ride, driver, car
+}
+
+// acceptRide requests a ride as requestRide does and lets the driver accept it.
+// It returns the ride and the driver.
+func (s *testServer) acceptRide(t *testing.T) (models.Ride, models.Driver) {
+	t.Helper()
+	ride, driver, car := s.requestRide(t)
+	decode(t, s.do(admin, "POST", fmt.Sprintf("/api/rides/%d/accept", ride.ID), fmt.Sprintf(`{"driver_id": %d, "car_id": %d}`, driver.ID, car.ID)), http.StatusOK, &ride)
+	return ride, driver
+}
+
+func TestFinishRideReleasesDriver(t *testing.T) {
+	for _, action := range []string{"complete", "cancel"} {
+		t.Run(action, func(t *testing.T) {
+			s := newTestServer(t)
+			ride, driver := s.acceptRide(t)
+			path := fmt.Sprintf("/api/rides/%d", ride.ID)
+			if action == "complete" {
+				decode(t, s.do(admin, "POST", path+"/start", ""), http.StatusOK, nil)
+			}
+
+			decode(t, s.do(admin, "POST", path+"/"+action, ""), http.StatusOK, &ride)
+			if ride.Status != models.RideCompleted && ride.Status != models.RideCancelled {
+				t.Errorf("ride status = %q, want it finished", ride.Status)
+			}
+			var stored models.Driver
+			decode(t, s.do(admin, "GET", fmt.Sprintf("/api/drivers/%d", driver.ID), ""), http.StatusOK, &stored)
+			if stored.Status != models.DriverAvailable {
+				t.Errorf("driver status = %q, want available", stored.Status)
+			}
+		})
+	}
+}
+
+func TestFinishRideRollsBackWhenDriverIsNotReleased(t *testing.T) {
+	for _, action := range []string{"complete", "cancel"} {
+		t.Run(action, func(t *testing.T) {
+			s := newTestServer(t)
+			ride, _ := s.acceptRide(t)
+			path := fmt.Sprintf("/api/rides/%d", ride.ID)
+			if action == "complete" {
+				decode(t, s.do(admin, "POST", path+"/start", ""), http.StatusOK, &ride)
+			}
+			events := len(s.auditEvents(t))
+
+			s.drivers.failStatus = errors.New("connection reset")
+			decode(t, s.do(admin, "POST", path+"/"+action, ""), http.StatusInternalServerError, nil)
+			s.drivers.failStatus = nil
+
+			var stored models.Ride
+			decode(t, s.do(admin, "GET", path, ""), http.StatusOK, &stored)
+			if stored.Status != ride.Status {
+				t.Errorf("ride status = %q, want %q", stored.Status, ride.Status)
+			}
+			if n := len(s.auditEvents(t)); n != events {
+				t.Errorf("%d audit events after the failure, want %d", n, events)
+			}
+		})
+	}
+}
+
+func TestRideAudit(t *testing.T) {
+	s := newTestServer(t)
+	ride, _ := s.acceptRide(t)
+	path := fmt.Sprintf("/api/rides/%d", ride.ID)
+	decode(t, s.do(admin, "POST", path+"/start", ""), http.StatusOK, nil)
+	decode(t, s.do(admin, "POST", path+"/complete", ""), http.StatusOK, nil)
+
+	var page listResponse[models.AuditEvent]
+	decode(t, s.do(admin, "GET", fmt.Sprintf("/api/audit?entity=ride&id=%d", ride.ID), ""), http.StatusOK, &page)
+	want := []models.RideStatus{models.RideRequested, models.RideAccepted, models.RideInProgress, models.RideCompleted}
+	if len(page.Items) != len(want) {
+		t.Fatalf("ride audit events = %+v, want %d", page.Items, len(want))
+	}
+	for i, event := range page.Items {
+		var before, after models.Ride
+		if i == 0 {
+			if event.Action != models.AuditCreate {
+				t.Errorf("event %d = %+v, want the creation of the ride", i, event)
+			}
+		} else if err := json.Unmarshal(event.Before, &before); err != nil || event.Action != models.AuditUpdate || before.Status != want[i-1] {
+			t.Errorf("event %d = %+v, want an update from %s", i, event, want[i-1])
+		}
+		if err := json.Unmarshal(event.After, &after); err != nil || after.Status != want[i] {
+			t.Errorf("event %d after = %s, want status %s", i, event.After, want[i])
+		}
+	}
+}
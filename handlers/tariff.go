@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/pricing"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// defaultTimezone is the tariff time zone used when a request does not specify one.
+const defaultTimezone = "Europe/Moscow"
+
+// TariffHandler serves the /api/tariffs endpoints.
+type TariffHandler struct {
+	repo  repository.TariffRepository
+	audit *Auditor
+}
+
+// NewTariffHandler returns a TariffHandler that stores tariffs in repo and records changes to them with audit.
+func NewTariffHandler(repo repository.TariffRepository, audit *Auditor) *TariffHandler {
+	return &TariffHandler{repo: repo, audit: audit}
+}
+
+// GetTariffs handles GET /api/tariffs requests.
+// It returns a page of tariffs as a listResponse. Paging and ordering are controlled by
+// limit, cursor and sort (id or name, prefixed with "-" for descending order).
+// Returns HTTP 400 if a query parameter is invalid, or HTTP 500 if there's a database error.
+func (h *TariffHandler) GetTariffs(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r.URL.Query(), repository.TariffSortFields)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tariffs, next, err := h.repo.List(r.Context(), page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writePage(w, tariffs, page, repository.TariffSortFields, next)
+}
+
+// GetTariff handles GET /api/tariffs/{id} requests.
+// It retrieves a specific tariff by ID and returns it as JSON.
+// Returns HTTP 400 if the ID is invalid, HTTP 404 if the tariff is not found,
+// or HTTP 500 if there's a database error.
+func (h *TariffHandler) GetTariff(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid tariff ID"))
+		return
+	}
+
+	tariff, err := h.repo.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Tariff not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tariff)
+}
+
+// CreateTariff handles POST /api/tariffs requests.
+// It creates a new tariff with the provided JSON data. Omitted multipliers default to 1
+// and an omitted timezone defaults to Europe/Moscow. Creating a tariff with "default": true
+// makes it the default in place of the previous one.
+// Returns the created tariff with HTTP 201 on success,
+// HTTP 400 if the request body or tariff is invalid, or HTTP 500 if there's a database error.
+func (h *TariffHandler) CreateTariff(w http.ResponseWriter, r *http.Request) {
+	tariff, ok := decodeTariff(w, r)
+	if !ok {
+		return
+	}
+
+	tariff.CreatedAt = time.Now()
+	tariff.UpdatedAt = time.Now()
+
+	err := h.audit.record(r, "tariff", models.AuditCreate, &tariff.ID, nil, &tariff, func(ctx context.Context) error {
+		return h.repo.Create(ctx, &tariff)
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tariff)
+}
+
+// UpdateTariff handles PUT /api/tariffs/{id} requests.
+// It updates an existing tariff with the provided JSON data, using the same defaults as CreateTariff.
+// Rides already requested with the tariff are priced with the updated values on completion.
+// Returns the updated tariff as JSON on success,
+// HTTP 400 if the ID, request body or tariff is invalid, HTTP 404 if the tariff is not found,
+// or HTTP 500 if there's a database error.
+func (h *TariffHandler) UpdateTariff(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid tariff ID"))
+		return
+	}
+
+	tariff, ok := decodeTariff(w, r)
+	if !ok {
+		return
+	}
+
+	tariff.ID = id
+	tariff.UpdatedAt = time.Now()
+
+	var before models.Tariff
+	err = h.audit.record(r, "tariff", models.AuditUpdate, &id, &before, &tariff, func(ctx context.Context) error {
+		var err error
+		if before, err = h.repo.Get(ctx, id); err != nil {
+			return err
+		}
+		tariff.CreatedAt = before.CreatedAt
+		return h.repo.Update(ctx, &tariff)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, apierr.NotFound("Tariff not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tariff)
+}
+
+// DeleteTariff handles DELETE /api/tariffs/{id} requests.
+// It removes a tariff from the repository by ID. A tariff that rides were priced with cannot be deleted.
+// Returns HTTP 204 (No Content) on successful deletion,
+// HTTP 400 if the ID is invalid, HTTP 404 if the tariff is not found,
+// HTTP 409 if rides reference the tariff, or HTTP 500 if there's a database error.
+func (h *TariffHandler) DeleteTariff(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid tariff ID"))
+		return
+	}
+
+	var before models.Tariff
+	err = h.audit.record(r, "tariff", models.AuditDelete, &id, &before, nil, func(ctx context.Context) error {
+		var err error
+		if before, err = h.repo.Get(ctx, id); err != nil {
+			return err
+		}
+		return h.repo.Delete(ctx, id)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			writeError(w, r, apierr.NotFound("Tariff not found"))
+		case errors.Is(err, repository.ErrConflict):
+			writeError(w, r, apierr.Conflict("Tariff is used by rides"))
+		default:
+			writeError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeTariff reads a tariff from the request body, fills in defaults and validates it.
+// On failure it writes an HTTP 400 response and returns false.
+func decodeTariff(w http.ResponseWriter, r *http.Request) (models.Tariff, bool) {
+	var tariff models.Tariff
+	if err := decodeJSON(r, &tariff); err != nil {
+		writeError(w, r, err)
+		return tariff, false
+	}
+
+	if tariff.NightMultiplier == 0 {
+		tariff.NightMultiplier = 1
+	}
+	if tariff.HolidayMultiplier == 0 {
+		tariff.HolidayMultiplier = 1
+	}
+	if tariff.Timezone == "" {
+		tariff.Timezone = defaultTimezone
+	}
+	if tariff.Holidays == nil {
+		tariff.Holidays = []string{}
+	}
+
+	if err := pricing.ValidateTariff(tariff); err != nil {
+		writeError(w, r, apierr.BadRequest("Invalid tariff").WithDetails(err.Error()))
+		return tariff, false
+	}
+	return tariff, true
+}
@@ -0,0 +1,209 @@
+// Package health serves the liveness, readiness and health endpoints. Liveness only
+// shows that the process answers; readiness runs the registered dependency checks,
+// such as a database ping, each with a timeout; health adds the build version, uptime
+// and details such as the migration version and connection pool statistics.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check reports whether a dependency is usable. It should give up when ctx is done.
+type Check func(ctx context.Context) error
+
+// Detail returns extra information shown by the health endpoint.
+type Detail func(ctx context.Context) (any, error)
+
+// Status values of checks and of the service as a whole.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckResult is the outcome of a single check.
+type CheckResult struct {
+	Status string `json:"status"`
+	// Error is the check's error message if it failed
+	Error string `json:"error,omitempty"`
+	// LatencyMS is how long the check took in milliseconds
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// readyResponse is the response body of the readiness endpoint.
+type readyResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// healthResponse is the response body of the health endpoint.
+type healthResponse struct {
+	Status        string                 `json:"status"`
+	Version       string                 `json:"version"`
+	StartedAt     time.Time              `json:"started_at"`
+	UptimeSeconds int64                  `json:"uptime_seconds"`
+	Checks        map[string]CheckResult `json:"checks"`
+	Details       map[string]any         `json:"details"`
+}
+
+// Checker runs the registered checks and serves their results.
+// Checks and details must be registered before the handlers are used.
+type Checker struct {
+	version string
+	timeout time.Duration
+	started time.Time
+
+	checks  map[string]Check
+	details map[string]Detail
+}
+
+// New returns a Checker for a service of the given build version that gives every
+// check timeout to complete.
+func New(version string, timeout time.Duration) *Checker {
+	return &Checker{
+		version: version,
+		timeout: timeout,
+		started: time.Now(),
+		checks:  make(map[string]Check),
+		details: make(map[string]Detail),
+	}
+}
+
+// AddCheck registers a readiness check under name.
+func (c *Checker) AddCheck(name string, check Check) {
+	c.checks[name] = check
+}
+
+// AddDetail registers a detail of the health endpoint under name.
+func (c *Checker) AddDetail(name string, detail Detail) {
+	c.details[name] = detail
+}
+
+// Run runs all checks concurrently and returns their results and whether all of them passed.
+func (c *Checker) Run(ctx context.Context) (map[string]CheckResult, bool) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]CheckResult, len(c.checks))
+	ok := true
+	for name, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := check(ctx)
+			result := CheckResult{Status: StatusOK, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				result.Status, result.Error = StatusFail, err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = result
+			ok = ok && err == nil
+		}()
+	}
+	wg.Wait()
+	return results, ok
+}
+
+// Live handles GET /livez requests. It always returns HTTP 200, since answering at all
+// shows the process is alive; dependencies are not checked, so an outage of one does not
+// get the service restarted.
+func (c *Checker) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// Ready handles GET /readyz requests. It runs the checks and returns their results as JSON,
+// with HTTP 200 if all of them passed and HTTP 503 otherwise.
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	results, ok := c.Run(r.Context())
+	writeJSON(w, statusCode(ok), readyResponse{Status: status(ok), Checks: results})
+}
+
+// Health handles GET /health requests. It returns the check results together with the
+// build version, start time, uptime and registered details as JSON, with the same status
+// code as Ready. A detail that fails is shown as its error message.
+func (c *Checker) Health(w http.ResponseWriter, r *http.Request) {
+	results, ok := c.Run(r.Context())
+	response := healthResponse{
+		Status:        status(ok),
+		Version:       c.version,
+		StartedAt:     c.started,
+		UptimeSeconds: int64(time.Since(c.started).Seconds()),
+		Checks:        results,
+		Details:       make(map[string]any, len(c.details)),
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), c.timeout)
+	defer cancel()
+	for name, detail := range c.details {
+		value, err := detail(ctx)
+		if err != nil {
+			value = map[string]string{"error": err.Error()}
+		}
+		response.Details[name] = value
+	}
+	writeJSON(w, statusCode(ok), response)
+}
+
+// PoolStats is the JSON form of the connection pool statistics of a sql.DB.
+type PoolStats struct {
+	MaxOpenConnections int     `json:"max_open_connections"`
+	OpenConnections    int     `json:"open_connections"`
+	InUse              int     `json:"in_use"`
+	Idle               int     `json:"idle"`
+	WaitCount          int64   `json:"wait_count"`
+	WaitSeconds        float64 `json:"wait_seconds"`
+	MaxIdleClosed      int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64   `json:"max_lifetime_closed"`
+}
+
+// DBStats returns a Detail showing the connection pool statistics of db.
+func DBStats(db *sql.DB) Detail {
+	return func(ctx context.Context) (any, error) {
+		stats := db.Stats()
+		return PoolStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitSeconds:        stats.WaitDuration.Seconds(),
+			MaxIdleClosed:      stats.MaxIdleClosed,
+			MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+			MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+		}, nil
+	}
+}
+
+// status returns the status of the service as a whole.
+func status(ok bool) string {
+	if ok {
+		return StatusOK
+	}
+	return StatusFail
+}
+
+// statusCode returns the HTTP status code of a readiness or health response.
+func statusCode(ok bool) int {
+	if ok {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
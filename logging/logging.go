@@ -0,0 +1,108 @@
+// Package logging sets up structured logging with log/slog and writes an access log line
+// for every HTTP request. Records logged with a request context carry the ID of the request
+// and of its trace.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hse-trpo-taxi/backend/requestid"
+	"github.com/hse-trpo-taxi/backend/route"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// level is the lowest level logged by the logger installed by Setup; SetLevel changes it.
+var level slog.LevelVar
+
+// Setup makes a logger writing to stderr the default for both log/slog and the log package.
+// format is "json" or "text"; lvl is "debug", "info", "warn" or "error".
+func Setup(format, lvl string) error {
+	if err := SetLevel(lvl); err != nil {
+		return err
+	}
+	opts := &slog.HandlerOptions{Level: &level}
+
+	var handler slog.Handler
+	switch format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return nil
+}
+
+// SetLevel changes the lowest level logged to lvl, "debug", "info", "warn" or "error",
+// while the server runs.
+func SetLevel(lvl string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(lvl)); err != nil {
+		return fmt.Errorf("invalid log level %q", lvl)
+	}
+	level.Set(l)
+	return nil
+}
+
+// contextHandler adds the request ID and the trace and span IDs of the context a record
+// is logged with, so log lines can be matched to requests and traces.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// AccessLog returns next wrapped to log every request after it is served, with its method,
+// route template, path, status, latency, response size and request ID. It must run inside
+// requestid.Middleware for the ID to be set, and inside tracing.Middleware for the trace ID.
+// Requests to the routes in quiet, such as probes and metric scrapes, are logged at debug
+// level so they don't drown out the API traffic.
+func AccessLog(router *mux.Router, next http.Handler, quiet ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		template := route.Template(router, r)
+
+		recorder := route.NewRecorder(w)
+		start := time.Now()
+		next.ServeHTTP(recorder, r)
+
+		level := slog.LevelInfo
+		if slices.Contains(quiet, template) {
+			level = slog.LevelDebug
+		}
+		slog.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("route", template),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.Status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int64("bytes", recorder.Bytes),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+		)
+	})
+}
@@ -1,64 +1,423 @@
 // Package main provides the entry point for the taxi service backend API server.
-// This service manages clients, drivers, and cars for a taxi service.
+// This service manages clients, drivers, cars, and rides for a taxi service.
 // It provides RESTful endpoints for CRUD operations on these entities.
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // tariff time zones must resolve in minimal containers
 
 	"github.com/gorilla/mux"
+	"github.com/hse-trpo-taxi/backend/apierr"
+	"github.com/hse-trpo-taxi/backend/auth"
 	"github.com/hse-trpo-taxi/backend/config"
+	"github.com/hse-trpo-taxi/backend/cors"
 	"github.com/hse-trpo-taxi/backend/database"
+	"github.com/hse-trpo-taxi/backend/dispatch"
 	"github.com/hse-trpo-taxi/backend/handlers"
+	"github.com/hse-trpo-taxi/backend/health"
+	"github.com/hse-trpo-taxi/backend/logging"
+	"github.com/hse-trpo-taxi/backend/metrics"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/otp"
+	"github.com/hse-trpo-taxi/backend/pricing"
+	"github.com/hse-trpo-taxi/backend/purge"
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/hse-trpo-taxi/backend/repository/memory"
+	"github.com/hse-trpo-taxi/backend/repository/postgres"
+	"github.com/hse-trpo-taxi/backend/requestid"
+	"github.com/hse-trpo-taxi/backend/server"
+	"github.com/hse-trpo-taxi/backend/tracing"
 )
 
+// version is the build version reported by /health, set at build time with
+// go build -ldflags "-X main.version=1.2.3".
+var version = "dev"
+
 // main initializes the taxi service backend API server.
-// It loads configuration, initializes the database connection,
-// sets up HTTP routes, and starts the server on the configured port.
+// It loads the configuration from the flags before the first argument that is not a flag,
+// the config file and the environment, and refuses to start if any setting is invalid.
+// When invoked as "backend [flags] migrate ...", "token ..." or "config ..." it runs that
+// subcommand instead. Otherwise it initializes the database connection,
+// builds the PostgreSQL repositories and handlers, sets up HTTP routes,
+// and serves on the configured port until SIGINT or SIGTERM, when it drains the requests
+// in flight and stops the background workers before closing the database.
 func main() {
 	// Load configuration
-	cfg := config.LoadConfig()
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			err = runMigrate(cfg, args[1:])
+		case "token":
+			err = runToken(cfg, args[1:])
+		case "config":
+			err = runConfig(cfg, args[1:])
+		default:
+			err = fmt.Errorf("unknown command %q, see backend -h", args[0])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := logging.Setup(cfg.LogFormat, cfg.LogLevel); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	slog.Info("Configuration loaded", "file", cfg.File, "config", cfg)
+
+	tracingConfig := tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		SampleRatio: cfg.TracingSampleRatio,
+		ServiceName: cfg.TracingServiceName,
+	}
+	if err := tracingConfig.Validate(); err != nil {
+		log.Fatalf("Invalid tracing configuration: %v", err)
+	}
+	exporter, err := tracing.NewExporter(context.Background(), tracingConfig)
+	if err != nil {
+		log.Fatalf("Failed to create trace exporter: %v", err)
+	}
+	shutdownTracing, err := tracing.Setup(exporter, tracingConfig, version)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
+
+	serverConfig := server.Config{
+		ReadTimeout:     cfg.HTTPReadTimeout,
+		WriteTimeout:    cfg.HTTPWriteTimeout,
+		IdleTimeout:     cfg.HTTPIdleTimeout,
+		ShutdownDelay:   cfg.ShutdownDelay,
+		ShutdownTimeout: cfg.ShutdownGracePeriod,
+	}
+	if err := serverConfig.Validate(); err != nil {
+		log.Fatalf("Invalid server configuration: %v", err)
+	}
+	srv := server.New(":"+cfg.ServerPort, serverConfig)
 
 	// Initialize database
-	if err := database.InitDB(cfg.DatabaseDSN); err != nil {
+	dbConfig, err := databaseConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid database configuration: %v", err)
+	}
+	if err := database.InitDB(cfg.DatabaseDSN, dbConfig); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer database.CloseDB()
 
+	// Metrics; every statement the repositories run is timed per operation and table
+	m := metrics.New()
+	m.RegisterDB(database.DB, "postgres")
+	postgres.ObserveQueries(m.ObserveQuery)
+
+	// Setup handlers; changes to clients, drivers, cars and tariffs are audited
+	auditRepo := postgres.NewAuditRepository(database.DB)
+	auditor := handlers.NewAuditor(postgres.NewTransactor(database.DB), auditRepo)
+	audit := handlers.NewAuditHandler(auditRepo)
+	clientRepo := postgres.NewClientRepository(database.DB)
+	clients := handlers.NewClientHandler(clientRepo, auditor)
+	driverRepo := postgres.NewDriverRepository(database.DB)
+	drivers := handlers.NewDriverHandler(driverRepo, postgres.NewShiftRepository(database.DB), auditor)
+	carRepo := postgres.NewCarRepository(database.DB)
+	cars := handlers.NewCarHandler(carRepo, auditor)
+
+	// Deleted records are purged after the retention period; cars go first so that
+	// drivers whose cars are all purged can be purged in the same run
+	purgeConfig := purge.Config{Retention: cfg.DeletedRetention, Interval: cfg.PurgeInterval}
+	if err := purgeConfig.Validate(); err != nil {
+		log.Fatalf("Invalid purge configuration: %v", err)
+	}
+	purger := purge.New(purgeConfig,
+		purge.Target{Name: "cars", Repo: carRepo},
+		purge.Target{Name: "drivers", Repo: driverRepo},
+		purge.Target{Name: "clients", Repo: clientRepo},
+	)
+	purger.Start()
+	defer purger.Stop()
+
+	var locationRepo repository.LocationRepository
+	switch cfg.LocationStore {
+	case "postgres":
+		locationRepo = postgres.NewLocationRepository(database.DB)
+	case "memory":
+		locationRepo = memory.NewLocationRepository()
+	default:
+		log.Fatalf("Unknown location store %q", cfg.LocationStore)
+	}
+
+	// Setup dispatch
+	strategy, ok := dispatch.NewStrategy(cfg.DispatchStrategy)
+	if !ok {
+		log.Fatalf("Unknown dispatch strategy %q", cfg.DispatchStrategy)
+	}
+	rideRepo := postgres.NewRideRepository(database.DB)
+	dispatcher := dispatch.New(rideRepo, driverRepo, carRepo, locationRepo, strategy, dispatch.Config{
+		Radius:        cfg.DispatchRadius,
+		MinRating:     cfg.DispatchMinRating,
+		OfferTimeout:  cfg.DispatchOfferTimeout,
+		MaxCandidates: cfg.DispatchMaxCandidates,
+	})
+	defer dispatcher.Stop()
+	if err := dispatcher.Resume(context.Background()); err != nil {
+		slog.Error("Failed to resume dispatch of requested rides", "error", err)
+	}
+
+	// Setup pricing
+	surgeConfig := pricing.SurgeConfig{
+		ZoneSize:    cfg.SurgeZoneSize,
+		Window:      cfg.SurgeWindow,
+		Interval:    cfg.SurgeInterval,
+		Sensitivity: cfg.SurgeSensitivity,
+		Cap:         cfg.SurgeCap,
+		Smoothing:   cfg.SurgeSmoothing,
+	}
+	if err := surgeConfig.Validate(); err != nil {
+		log.Fatalf("Invalid surge configuration: %v", err)
+	}
+	surge := pricing.NewSurge(surgeConfig)
+	surge.SetEnabled(cfg.FeatureSurgePricing)
+	// Rides waiting for a driver count as demand; drivers count as supply with the same
+	// two-minute location freshness as dispatch uses
+	surge.Start(pricing.OpenRequests(rideRepo, surgeConfig.Window), pricing.AvailableDrivers(driverRepo, locationRepo, 2*time.Minute))
+	defer surge.Stop()
+
+	tariffRepo := postgres.NewTariffRepository(database.DB)
+	tariffs := handlers.NewTariffHandler(tariffRepo, auditor)
+	fares := handlers.NewFareHandler(tariffRepo, surge)
+	rides := handlers.NewRideHandler(rideRepo, driverRepo, tariffRepo, surge, dispatcher, auditor)
+	offers := handlers.NewOfferHandler(dispatcher, rideRepo, driverRepo, auditor)
+	locations := handlers.NewLocationHandler(locationRepo, driverRepo)
+
+	// Setup authentication
+	keys, signingKey, err := auth.ParseKeys(cfg.JWTKeys)
+	if errors.Is(err, auth.ErrNoKeys) {
+		slog.Warn("JWT_KEYS is not set, signing tokens with a random key that is lost on restart")
+		keys, signingKey = auth.RandomKey()
+	} else if err != nil {
+		log.Fatalf("Invalid JWT_KEYS: %v", err)
+	}
+	tokens, err := auth.NewTokens(keys, signingKey, cfg.JWTTTL)
+	if err != nil {
+		log.Fatalf("Failed to set up authentication: %v", err)
+	}
+
+	// Setup phone login
+	codeConfig, err := otpConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid OTP configuration: %v", err)
+	}
+	sender, err := otp.NewSender(cfg.SMSSender, cfg.SMSFile)
+	if err != nil {
+		log.Fatalf("Failed to set up SMS sender: %v", err)
+	}
+	codes := otp.NewService(postgres.NewOTPRepository(database.DB), sender, codeConfig)
+	logins := handlers.NewOTPHandler(codes, clientRepo, driverRepo, tokens, auditor)
+	logins.SetSignup(cfg.FeatureClientSignup)
+
+	// Authorization rules; handlers additionally restrict clients and drivers to their own records
+	require := tokens.Require
+	admin := auth.Roles(auth.RoleAdmin)
+	staff := auth.Roles(auth.RoleDispatcher, auth.RoleAdmin)
+	staffOrClient := auth.Roles(auth.RoleDispatcher, auth.RoleAdmin, auth.RoleClient)
+	staffOrDriver := auth.Roles(auth.RoleDispatcher, auth.RoleAdmin, auth.RoleDriver)
+	selfClient := auth.Any(staff, auth.Self(auth.RoleClient, "id"))
+	selfDriver := auth.Any(staff, auth.Self(auth.RoleDriver, "id"))
+
 	// Setup router
 	router := mux.NewRouter()
 
+	// Login routes are public: they are how callers obtain a token
+	router.HandleFunc("/api/auth/otp/request", logins.RequestCode).Methods("POST")
+	router.HandleFunc("/api/auth/otp/verify", logins.VerifyCode).Methods("POST")
+
 	// Client routes
-	router.HandleFunc("/api/clients", handlers.GetClients).Methods("GET")
-	router.HandleFunc("/api/clients/{id}", handlers.GetClient).Methods("GET")
-	router.HandleFunc("/api/clients", handlers.CreateClient).Methods("POST")
-	router.HandleFunc("/api/clients/{id}", handlers.UpdateClient).Methods("PUT")
-	router.HandleFunc("/api/clients/{id}", handlers.DeleteClient).Methods("DELETE")
+	router.HandleFunc("/api/clients", require(staff, clients.GetClients)).Methods("GET")
+	router.HandleFunc("/api/clients/{id}", require(selfClient, clients.GetClient)).Methods("GET")
+	router.HandleFunc("/api/clients", require(staff, clients.CreateClient)).Methods("POST")
+	router.HandleFunc("/api/clients/{id}", require(selfClient, clients.UpdateClient)).Methods("PUT")
+	router.HandleFunc("/api/clients/{id}", require(selfClient, clients.PatchClient)).Methods("PATCH")
+	router.HandleFunc("/api/clients/{id}", require(admin, clients.DeleteClient)).Methods("DELETE")
+	router.HandleFunc("/api/clients/{id}/restore", require(admin, clients.RestoreClient)).Methods("POST")
 
 	// Driver routes
-	router.HandleFunc("/api/drivers", handlers.GetDrivers).Methods("GET")
-	router.HandleFunc("/api/drivers/{id}", handlers.GetDriver).Methods("GET")
-	router.HandleFunc("/api/drivers", handlers.CreateDriver).Methods("POST")
-	router.HandleFunc("/api/drivers/{id}", handlers.UpdateDriver).Methods("PUT")
-	router.HandleFunc("/api/drivers/{id}", handlers.DeleteDriver).Methods("DELETE")
+	router.HandleFunc("/api/drivers/nearby", require(staffOrClient, locations.GetNearbyDrivers)).Methods("GET")
+	router.HandleFunc("/api/drivers", require(staff, drivers.GetDrivers)).Methods("GET")
+	router.HandleFunc("/api/drivers/{id}", require(selfDriver, drivers.GetDriver)).Methods("GET")
+	router.HandleFunc("/api/drivers", require(admin, drivers.CreateDriver)).Methods("POST")
+	router.HandleFunc("/api/drivers/{id}", require(selfDriver, drivers.UpdateDriver)).Methods("PUT")
+	router.HandleFunc("/api/drivers/{id}", require(selfDriver, drivers.PatchDriver)).Methods("PATCH")
+	router.HandleFunc("/api/drivers/{id}", require(admin, drivers.DeleteDriver)).Methods("DELETE")
+	router.HandleFunc("/api/drivers/{id}/restore", require(admin, drivers.RestoreDriver)).Methods("POST")
+	router.HandleFunc("/api/drivers/{id}/online", require(selfDriver, drivers.GoOnline)).Methods("POST")
+	router.HandleFunc("/api/drivers/{id}/offline", require(selfDriver, drivers.GoOffline)).Methods("POST")
+	router.HandleFunc("/api/drivers/{id}/break", require(selfDriver, drivers.TakeBreak)).Methods("POST")
+	router.HandleFunc("/api/drivers/{id}/shifts", require(selfDriver, drivers.GetDriverShifts)).Methods("GET")
+	router.HandleFunc("/api/drivers/{id}/location", require(auth.Self(auth.RoleDriver, "id"), locations.UpdateLocation)).Methods("POST")
+	router.HandleFunc("/api/drivers/{id}/offers", require(selfDriver, offers.GetDriverOffers)).Methods("GET")
 
 	// Car routes
-	router.HandleFunc("/api/cars", handlers.GetCars).Methods("GET")
-	router.HandleFunc("/api/cars/{id}", handlers.GetCar).Methods("GET")
-	router.HandleFunc("/api/cars", handlers.CreateCar).Methods("POST")
-	router.HandleFunc("/api/cars/{id}", handlers.UpdateCar).Methods("PUT")
-	router.HandleFunc("/api/cars/{id}", handlers.DeleteCar).Methods("DELETE")
-
-	// Health check endpoint
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	}).Methods("GET")
-
-	// Start server
-	log.Printf("Server starting on port %s", cfg.ServerPort)
-	if err := http.ListenAndServe(":"+cfg.ServerPort, router); err != nil {
+	router.HandleFunc("/api/cars", require(staffOrDriver, cars.GetCars)).Methods("GET")
+	router.HandleFunc("/api/cars/{id}", require(staffOrDriver, cars.GetCar)).Methods("GET")
+	router.HandleFunc("/api/cars", require(staffOrDriver, cars.CreateCar)).Methods("POST")
+	router.HandleFunc("/api/cars/{id}", require(staffOrDriver, cars.UpdateCar)).Methods("PUT")
+	router.HandleFunc("/api/cars/{id}", require(staffOrDriver, cars.PatchCar)).Methods("PATCH")
+	router.HandleFunc("/api/cars/{id}", require(auth.Roles(auth.RoleAdmin, auth.RoleDriver), cars.DeleteCar)).Methods("DELETE")
+	router.HandleFunc("/api/cars/{id}/restore", require(admin, cars.RestoreCar)).Methods("POST")
+
+	// Ride routes
+	router.HandleFunc("/api/rides", require(staff, rides.GetRides)).Methods("GET")
+	router.HandleFunc("/api/rides/{id}", require(auth.Authenticated, rides.GetRide)).Methods("GET")
+	router.HandleFunc("/api/rides", require(staffOrClient, rides.RequestRide)).Methods("POST")
+	router.HandleFunc("/api/rides/{id}/accept", require(staffOrDriver, rides.AcceptRide)).Methods("POST")
+	router.HandleFunc("/api/rides/{id}/start", require(staffOrDriver, rides.StartRide)).Methods("POST")
+	router.HandleFunc("/api/rides/{id}/complete", require(staffOrDriver, rides.CompleteRide)).Methods("POST")
+	router.HandleFunc("/api/rides/{id}/cancel", require(auth.Authenticated, rides.CancelRide)).Methods("POST")
+
+	// Tariff and fare routes
+	router.HandleFunc("/api/tariffs", require(auth.Authenticated, tariffs.GetTariffs)).Methods("GET")
+	router.HandleFunc("/api/tariffs/{id}", require(auth.Authenticated, tariffs.GetTariff)).Methods("GET")
+	router.HandleFunc("/api/tariffs", require(admin, tariffs.CreateTariff)).Methods("POST")
+	router.HandleFunc("/api/tariffs/{id}", require(admin, tariffs.UpdateTariff)).Methods("PUT")
+	router.HandleFunc("/api/tariffs/{id}", require(admin, tariffs.DeleteTariff)).Methods("DELETE")
+	router.HandleFunc("/api/fares/estimate", require(auth.Authenticated, fares.EstimateFare)).Methods("POST")
+	router.HandleFunc("/api/surge", require(auth.Authenticated, fares.GetSurge)).Methods("GET")
+
+	// Dispatch offer routes
+	router.HandleFunc("/api/offers/{id}/accept", require(staffOrDriver, offers.AcceptOffer)).Methods("POST")
+	router.HandleFunc("/api/offers/{id}/decline", require(staffOrDriver, offers.DeclineOffer)).Methods("POST")
+
+	// Audit log routes
+	router.HandleFunc("/api/audit", require(admin, audit.GetAuditEvents)).Methods("GET")
+
+	// Health endpoints; readiness fails as soon as shutdown starts so no new traffic is routed here
+	migrator, err := database.NewMigrator(database.DB)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	checker := health.New(version, cfg.HealthCheckTimeout)
+	checker.AddCheck("server", func(ctx context.Context) error {
+		if !srv.Ready() {
+			return errors.New("shutting down")
+		}
+		return nil
+	})
+	checker.AddCheck("postgres", database.DB.PingContext)
+	checker.AddDetail("migrations", func(ctx context.Context) (any, error) {
+		applied, err := migrator.Version(ctx)
+		return map[string]int{"version": applied, "latest": migrator.Latest()}, err
+	})
+	checker.AddDetail("database_pool", health.DBStats(database.DB))
+	router.HandleFunc("/livez", checker.Live).Methods("GET")
+	router.HandleFunc("/readyz", checker.Ready).Methods("GET")
+	router.HandleFunc("/health", checker.Health).Methods("GET")
+
+	// Metrics endpoint; the gauges below are read from the database on every scrape
+	metrics.RegisterStatusGauge(m, "taxi_drivers", "Drivers by status.",
+		[]models.DriverStatus{models.DriverOffline, models.DriverAvailable, models.DriverOnTrip, models.DriverOnBreak},
+		driverRepo.CountByStatus)
+	activeRides := []models.RideStatus{models.RideRequested, models.RideAccepted, models.RideInProgress}
+	metrics.RegisterStatusGauge(m, "taxi_rides_active", "Rides that are not finished, by status.", activeRides,
+		func(ctx context.Context) (map[models.RideStatus]int, error) {
+			return rideRepo.CountByStatus(ctx, activeRides...)
+		})
+	router.Handle("/metrics", m.Handler()).Methods("GET")
+
+	// Unknown routes get the same JSON errors as the handlers
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apierr.Write(w, r, apierr.NotFound("Route not found"))
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apierr.Write(w, r, apierr.New(http.StatusMethodNotAllowed, apierr.CodeMethodNotAllowed, "Method not allowed"))
+	})
+
+	// Browser applications on the configured origins may call the API
+	origins, err := cors.ParseOrigins(cfg.CORSOrigins)
+	if err != nil {
+		log.Fatalf("Invalid CORS origins: %v", err)
+	}
+	allowed := cors.New(origins)
+
+	// Reload the log level, login code limits, feature flags and CORS origins on SIGHUP or
+	// when the config file changes; reloaded settings have already been validated
+	reloader := config.NewReloader(cfg, os.Args[1:])
+	config.Subscribe(reloader, func(c *config.Config) string { return c.LogLevel }, func(level string) {
+		logging.SetLevel(level)
+	})
+	config.Subscribe(reloader, func(c *config.Config) otp.Config {
+		codeConfig, _ := otpConfig(c)
+		return codeConfig
+	}, codes.SetConfig)
+	config.Subscribe(reloader, func(c *config.Config) bool { return c.FeatureSurgePricing }, surge.SetEnabled)
+	config.Subscribe(reloader, func(c *config.Config) bool { return c.FeatureClientSignup }, logins.SetSignup)
+	config.Subscribe(reloader, func(c *config.Config) string { return c.CORSOrigins }, func(s string) {
+		origins, _ := cors.ParseOrigins(s)
+		allowed.SetOrigins(origins)
+	})
+	reloader.Start()
+	defer reloader.Stop()
+
+	// Start server; a second signal during shutdown kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+
+	// Every request is traced and logged with its request and trace IDs; probes and scrapes
+	// are logged only at debug level
+	handler := logging.AccessLog(router, m.Instrument(router), "/livez", "/readyz", "/metrics")
+	handler = requestid.Middleware(allowed.Middleware(tracing.Middleware(router, handler)))
+
+	slog.Info("Server starting", "port", cfg.ServerPort)
+	if err := srv.Run(ctx, handler); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// otpConfig returns the validated login code settings of cfg.
+func otpConfig(cfg *config.Config) (otp.Config, error) {
+	codeConfig := otp.Config{
+		TTL:            cfg.OTPTTL,
+		ResendInterval: cfg.OTPResendInterval,
+		MaxSends:       cfg.OTPMaxSends,
+		SendWindow:     cfg.OTPSendWindow,
+		MaxAttempts:    cfg.OTPMaxAttempts,
+	}
+	return codeConfig, codeConfig.Validate()
+}
+
+// databaseConfig returns the validated connection pool settings of cfg.
+func databaseConfig(cfg *config.Config) (database.Config, error) {
+	dbConfig := database.Config{
+		MaxOpenConns:     cfg.DBMaxOpenConns,
+		MaxIdleConns:     cfg.DBMaxIdleConns,
+		ConnMaxLifetime:  cfg.DBConnMaxLifetime,
+		ConnMaxIdleTime:  cfg.DBConnMaxIdleTime,
+		StatementTimeout: cfg.DBStatementTimeout,
+	}
+	return dbConfig, dbConfig.Validate()
+}
@@ -0,0 +1,142 @@
+// Package metrics exposes Prometheus metrics: request counts and latencies per route,
+// database query latencies and connection pool statistics, and business gauges such as
+// the number of drivers per status.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hse-trpo-taxi/backend/route"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// collectTimeout bounds the database queries behind the business gauges on each scrape.
+const collectTimeout = 2 * time.Second
+
+// Metrics holds the service's metrics in its own registry.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+
+	queries     *prometheus.HistogramVec
+	queryErrors *prometheus.CounterVec
+}
+
+// New returns Metrics with the HTTP and query metrics and the Go runtime and process collectors registered.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method and route template.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests being served.",
+		}),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Database statement latency by operation and table.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "db_query_errors_total",
+			Help: "Failed database statements by operation and table.",
+		}, []string{"operation", "table"}),
+	}
+	m.registry.MustRegister(
+		m.requests, m.latency, m.inFlight, m.queries, m.queryErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler returns the handler of GET /metrics, which writes the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Instrument returns router wrapped to count and time its requests. Requests are labelled
+// with the path template of the route they match, such as /api/drivers/{id}, so the number
+// of series does not grow with the IDs in the paths.
+func (m *Metrics) Instrument(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		template := route.Template(router, r)
+
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+		recorder := route.NewRecorder(w)
+		start := time.Now()
+		router.ServeHTTP(recorder, r)
+
+		m.latency.WithLabelValues(r.Method, template).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(r.Method, template, strconv.Itoa(recorder.Status)).Inc()
+	})
+}
+
+// ObserveQuery records a database statement; it has the signature of postgres.QueryObserver.
+func (m *Metrics) ObserveQuery(operation, table string, duration time.Duration, err error) {
+	m.queries.WithLabelValues(operation, table).Observe(duration.Seconds())
+	if err != nil {
+		m.queryErrors.WithLabelValues(operation, table).Inc()
+	}
+}
+
+// RegisterDB adds the connection pool statistics of db, such as open and idle connections
+// and the time spent waiting for one, labelled with name.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterStatusGauge adds a gauge with a status label whose values are read with count
+// on every scrape, e.g. the number of drivers per status. statuses lists the statuses that
+// are always reported, as 0 if count leaves them out. If count fails, the error is logged
+// and the gauge is left out of the scrape.
+func RegisterStatusGauge[S ~string](m *Metrics, name, help string, statuses []S, count func(ctx context.Context) (map[S]int, error)) {
+	m.registry.MustRegister(&statusCollector[S]{
+		desc:     prometheus.NewDesc(name, help, []string{"status"}, nil),
+		statuses: statuses,
+		count:    count,
+	})
+}
+
+// statusCollector is the collector behind RegisterStatusGauge.
+type statusCollector[S ~string] struct {
+	desc     *prometheus.Desc
+	statuses []S
+	count    func(ctx context.Context) (map[S]int, error)
+}
+
+func (c *statusCollector[S]) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *statusCollector[S]) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	counts, err := c.count(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to collect metric", "metric", c.desc.String(), "error", err)
+		return
+	}
+	for _, status := range c.statuses {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[status]), string(status))
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/hse-trpo-taxi/backend/config"
+	"github.com/hse-trpo-taxi/backend/database"
+)
+
+// migrateUsage describes the arguments accepted by the migrate subcommand.
+const migrateUsage = `usage: backend migrate <command>
+
+commands:
+  up      apply all pending migrations
+  down    revert the most recently applied migration
+  status  list migrations and whether they are applied
+  to N    migrate up or down to version N (0 reverts everything)`
+
+// runMigrate implements the "migrate" subcommand.
+// It connects to the configured database without applying migrations automatically
+// and then runs the requested migration command.
+func runMigrate(cfg *config.Config, args []string) error {
+	switch {
+	case len(args) == 1 && (args[0] == "up" || args[0] == "down" || args[0] == "status"):
+	case len(args) == 2 && args[0] == "to":
+	default:
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	dbConfig, err := databaseConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid database configuration: %v", err)
+	}
+	if err := database.OpenDB(cfg.DatabaseDSN, dbConfig); err != nil {
+		return err
+	}
+	defer database.CloseDB()
+
+	migrator, err := database.NewMigrator(database.DB)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx)
+	case "to":
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid migration version %q", args[1])
+		}
+		return migrator.To(ctx, version)
+	default:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return tw.Flush()
+	}
+}
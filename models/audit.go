@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction is the kind of change an AuditEvent records.
+type AuditAction string
+
+// Audited actions.
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+)
+
+// AuditEvent records a change made to a client, driver, car or tariff through the API:
+// who made it, in which request, and what the record looked like before and after.
+// Events are written in the same transaction as the change, so every stored change has one.
+type AuditEvent struct {
+	// ID is the unique identifier for the event; IDs grow in the order events are written
+	ID int `json:"id" db:"id"`
+	// ActorID is the ID of the account that made the change, or nil for an anonymous request
+	ActorID *int `json:"actor_id" db:"actor_id"`
+	// ActorRole is the role of the account that made the change, empty for an anonymous request
+	ActorRole string `json:"actor_role" db:"actor_role"`
+	// EntityType is the kind of record changed: "client", "driver", "car" or "tariff"
+	EntityType string `json:"entity" db:"entity_type"`
+	// EntityID is the ID of the record changed
+	EntityID int `json:"entity_id" db:"entity_id"`
+	// Action is the kind of change
+	Action AuditAction `json:"action" db:"action"`
+	// Before is the record as JSON before the change; nil for a create
+	Before json.RawMessage `json:"before" db:"before"`
+	// After is the record as JSON after the change; nil for a tariff delete, which removes the tariff
+	After json.RawMessage `json:"after" db:"after"`
+	// RequestID is the X-Request-ID of the request that made the change
+	RequestID string `json:"request_id" db:"request_id"`
+	// CreatedAt is the timestamp when the change was made
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/validate"
+)
 
 // Car represents a vehicle used in the taxi service.
 // It contains detailed information about the car and its association with a driver.
@@ -24,4 +28,26 @@ type Car struct {
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	// UpdatedAt is the timestamp when the car record was last modified
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// DeletedAt is the timestamp when the car was deleted, or nil if it has not been.
+	// Deleted cars are hidden but kept until purged, and can be restored.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// MinCarYear is the earliest accepted model year of a car.
+const MinCarYear = 1970
+
+// Validate checks the car's fields and normalizes its license plate.
+// The model year may be at most one year ahead, as new models go on sale early.
+func (c *Car) Validate() error {
+	return validate.All(
+		validate.Positive("driver_id", c.DriverID),
+		validate.Required("brand", c.Brand),
+		validate.MaxLength("brand", c.Brand, 100),
+		validate.Required("model", c.Model),
+		validate.MaxLength("model", c.Model, 100),
+		validate.Range("year", c.Year, MinCarYear, time.Now().Year()+1),
+		validate.LicensePlate("license_plate", &c.LicensePlate),
+		validate.Required("color", c.Color),
+		validate.MaxLength("color", c.Color, 50),
+	)
 }
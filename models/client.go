@@ -1,8 +1,12 @@
 // Package models defines the data structures used throughout the taxi service.
-// These models represent the core entities: clients, drivers, and cars.
+// These models represent the core entities: clients, drivers, cars, rides, and tariffs.
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/validate"
+)
 
 // Client represents a taxi service customer.
 // It contains personal information and contact details for a client
@@ -20,4 +24,19 @@ type Client struct {
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	// UpdatedAt is the timestamp when the client record was last modified
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// DeletedAt is the timestamp when the client was deleted, or nil if it has not been.
+	// Deleted clients are hidden but kept until purged, and can be restored.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// Validate checks the client's fields and normalizes its phone number to E.164.
+// Email is optional, since clients who registered by phone have none yet.
+func (c *Client) Validate() error {
+	return validate.All(
+		validate.Required("name", c.Name),
+		validate.MaxLength("name", c.Name, 255),
+		validate.Phone("phone", &c.Phone),
+		validate.Email("email", &c.Email),
+		validate.MaxLength("email", c.Email, 255),
+	)
 }
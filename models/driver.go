@@ -1,6 +1,35 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/validate"
+)
+
+// DriverStatus is the availability of a driver for dispatch.
+type DriverStatus string
+
+// Driver availability states. A driver is offline outside of a shift;
+// going online opens a shift and makes the driver available.
+const (
+	// DriverOffline means the driver is not working and has no open shift
+	DriverOffline DriverStatus = "offline"
+	// DriverAvailable means the driver is on shift and can accept rides
+	DriverAvailable DriverStatus = "available"
+	// DriverOnTrip means the driver is on shift and serving a ride
+	DriverOnTrip DriverStatus = "on_trip"
+	// DriverOnBreak means the driver is on shift but temporarily not accepting rides
+	DriverOnBreak DriverStatus = "on_break"
+)
+
+// Valid reports whether s is one of the known driver statuses.
+func (s DriverStatus) Valid() bool {
+	switch s {
+	case DriverOffline, DriverAvailable, DriverOnTrip, DriverOnBreak:
+		return true
+	}
+	return false
+}
 
 // Driver represents a taxi driver in the service.
 // It contains personal information, licensing details, and performance metrics
@@ -16,8 +45,28 @@ type Driver struct {
 	LicenseNumber string `json:"license_number" db:"license_number"`
 	// Rating is the driver's average rating from clients (0.0 to 5.0)
 	Rating float64 `json:"rating" db:"rating"`
+	// Status is the driver's current availability; it is changed only through the shift endpoints and ride lifecycle
+	Status DriverStatus `json:"status" db:"status"`
 	// CreatedAt is the timestamp when the driver record was created
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	// UpdatedAt is the timestamp when the driver record was last modified
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// DeletedAt is the timestamp when the driver was deleted, or nil if it has not been.
+	// Deleted drivers are hidden but kept until purged, and can be restored.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// MaxRating is the highest driver rating.
+const MaxRating = 5
+
+// Validate checks the driver's fields and normalizes its phone number to E.164.
+func (d *Driver) Validate() error {
+	return validate.All(
+		validate.Required("name", d.Name),
+		validate.MaxLength("name", d.Name, 255),
+		validate.Phone("phone", &d.Phone),
+		validate.Required("license_number", d.LicenseNumber),
+		validate.MaxLength("license_number", d.LicenseNumber, 50),
+		validate.Range("rating", d.Rating, 0, MaxRating),
+	)
 }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// DriverLocation is the latest GPS position reported by a driver's device.
+// Only the most recent ping per driver is kept.
+type DriverLocation struct {
+	// DriverID is the foreign key reference to the driver who reported the position
+	DriverID int `json:"driver_id" db:"driver_id"`
+	// Lat is the latitude in degrees
+	Lat float64 `json:"lat" db:"lat"`
+	// Lon is the longitude in degrees
+	Lon float64 `json:"lon" db:"lon"`
+	// Heading is the direction of travel in degrees clockwise from north (0 to 360)
+	Heading float64 `json:"heading" db:"heading"`
+	// Speed is the ground speed in meters per second
+	Speed float64 `json:"speed" db:"speed"`
+	// RecordedAt is the device timestamp of the GPS fix
+	RecordedAt time.Time `json:"timestamp" db:"recorded_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeEmail returns the form of an email address used to check it is unique:
+// trimmed and lower-cased, so that "Anna@Mail.ru" and "anna@mail.ru" compare equal.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeLicenseNumber returns the form of a driver's license number used to check it is unique:
+// upper-cased without spaces or dashes, so that "77 ab 123456" and "77AB123456" compare equal.
+func NormalizeLicenseNumber(number string) string {
+	return strings.ToUpper(stripSeparators(number))
+}
+
+// stripSeparators removes the whitespace and dashes people put inside document numbers.
+func stripSeparators(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// OTPCode is the one-time login code most recently sent to a phone number for a role,
+// together with the counters used to rate-limit sending and guessing it.
+type OTPCode struct {
+	// Phone is the normalized phone number the code was sent to
+	Phone string `db:"phone"`
+	// Role is the kind of account logging in, "client" or "driver"
+	Role string `db:"role"`
+	// CodeHash is the hex SHA-256 of the code; the code itself is never stored
+	CodeHash string `db:"code_hash"`
+	// ExpiresAt is the time after which the code is no longer accepted
+	ExpiresAt time.Time `db:"expires_at"`
+	// Attempts is the number of verification attempts made against the code
+	Attempts int `db:"attempts"`
+	// SentAt is when the code was sent
+	SentAt time.Time `db:"sent_at"`
+	// SendCount is the number of codes sent to the phone since WindowStartedAt
+	SendCount int `db:"send_count"`
+	// WindowStartedAt is the start of the current rate-limiting window
+	WindowStartedAt time.Time `db:"window_started_at"`
+}
@@ -0,0 +1,96 @@
+package models
+
+import "time"
+
+// RideStatus is the lifecycle state of a ride.
+type RideStatus string
+
+// Ride lifecycle states. A ride starts as requested and ends as either completed or cancelled.
+const (
+	// RideRequested means a client has asked for a ride and no driver has accepted it yet
+	RideRequested RideStatus = "requested"
+	// RideAccepted means a driver has accepted the ride and is on the way to the pickup point
+	RideAccepted RideStatus = "accepted"
+	// RideInProgress means the client has been picked up
+	RideInProgress RideStatus = "in_progress"
+	// RideCompleted means the client has been dropped off
+	RideCompleted RideStatus = "completed"
+	// RideCancelled means the ride was cancelled before it was completed
+	RideCancelled RideStatus = "cancelled"
+)
+
+// Valid reports whether s is one of the known ride statuses.
+func (s RideStatus) Valid() bool {
+	switch s {
+	case RideRequested, RideAccepted, RideInProgress, RideCompleted, RideCancelled:
+		return true
+	}
+	return false
+}
+
+// rideTransitions lists the states each ride status may move to.
+// Completed and cancelled rides are final and have no outgoing transitions.
+var rideTransitions = map[RideStatus][]RideStatus{
+	RideRequested:  {RideAccepted, RideCancelled},
+	RideAccepted:   {RideInProgress, RideCancelled},
+	RideInProgress: {RideCompleted},
+}
+
+// CanTransitionTo reports whether a ride in status s may move to status next.
+func (s RideStatus) CanTransitionTo(next RideStatus) bool {
+	for _, allowed := range rideTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Ride represents a single taxi trip requested by a client.
+// DriverID and CarID are set once a driver accepts the ride; the per-state
+// timestamps are set as the ride moves through its lifecycle, and Fare, Distance
+// and Duration are set on completion using the tariff chosen when the ride was requested.
+type Ride struct {
+	// ID is the unique identifier for the ride
+	ID int `json:"id" db:"id"`
+	// ClientID is the foreign key reference to the client who requested the ride
+	ClientID int `json:"client_id" db:"client_id"`
+	// DriverID is the foreign key reference to the driver who accepted the ride
+	DriverID *int `json:"driver_id" db:"driver_id"`
+	// CarID is the foreign key reference to the car used for the ride
+	CarID *int `json:"car_id" db:"car_id"`
+	// PickupLat is the latitude of the pickup point in degrees
+	PickupLat float64 `json:"pickup_lat" db:"pickup_lat"`
+	// PickupLon is the longitude of the pickup point in degrees
+	PickupLon float64 `json:"pickup_lon" db:"pickup_lon"`
+	// DropoffLat is the latitude of the drop-off point in degrees
+	DropoffLat float64 `json:"dropoff_lat" db:"dropoff_lat"`
+	// DropoffLon is the longitude of the drop-off point in degrees
+	DropoffLon float64 `json:"dropoff_lon" db:"dropoff_lon"`
+	// Status is the current lifecycle state of the ride
+	Status RideStatus `json:"status" db:"status"`
+	// TariffID is the foreign key reference to the tariff the ride is priced with
+	TariffID *int `json:"tariff_id" db:"tariff_id"`
+	// SurgeMultiplier is the surge multiplier in the pickup zone when the ride was requested
+	SurgeMultiplier float64 `json:"surge_multiplier" db:"surge_multiplier"`
+	// Fare is the final price of the ride, set on completion
+	Fare *float64 `json:"fare" db:"fare"`
+	// Distance is the travelled distance in meters, set on completion
+	Distance *float64 `json:"distance_m" db:"distance_m"`
+	// Duration is the travel time in seconds, set on completion
+	Duration *int `json:"duration_s" db:"duration_s"`
+	// RequestedAt is the timestamp when the client requested the ride
+	RequestedAt time.Time `json:"requested_at" db:"requested_at"`
+	// AcceptedAt is the timestamp when a driver accepted the ride
+	AcceptedAt *time.Time `json:"accepted_at" db:"accepted_at"`
+	// StartedAt is the timestamp when the client was picked up
+	StartedAt *time.Time `json:"started_at" db:"started_at"`
+	// CompletedAt is the timestamp when the client was dropped off
+	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+	// CancelledAt is the timestamp when the ride was cancelled
+	CancelledAt *time.Time `json:"cancelled_at" db:"cancelled_at"`
+	// CreatedAt is the timestamp when the ride record was created
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// UpdatedAt is the timestamp when the ride record was last modified
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Shift represents a continuous working period of a driver, from going online to going offline.
+// Shifts are used for payroll, so they are never modified after they end.
+type Shift struct {
+	// ID is the unique identifier for the shift
+	ID int `json:"id" db:"id"`
+	// DriverID is the foreign key reference to the driver who worked the shift
+	DriverID int `json:"driver_id" db:"driver_id"`
+	// StartedAt is the timestamp when the driver went online
+	StartedAt time.Time `json:"started_at" db:"started_at"`
+	// EndedAt is the timestamp when the driver went offline; nil while the shift is open
+	EndedAt *time.Time `json:"ended_at" db:"ended_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Tariff is a set of prices used to calculate ride fares.
+// Exactly one tariff may be the default; it is used when a ride does not name a tariff.
+type Tariff struct {
+	// ID is the unique identifier for the tariff
+	ID int `json:"id" db:"id"`
+	// Name is the human-readable tariff name (e.g., Standard, Comfort)
+	Name string `json:"name" db:"name"`
+	// BaseFare is the fixed amount charged for every ride
+	BaseFare float64 `json:"base_fare" db:"base_fare"`
+	// PerKm is the amount charged per kilometer travelled
+	PerKm float64 `json:"per_km" db:"per_km"`
+	// PerMinute is the amount charged per minute of travel
+	PerMinute float64 `json:"per_minute" db:"per_minute"`
+	// MinimumFare is the lowest total a ride can cost
+	MinimumFare float64 `json:"minimum_fare" db:"minimum_fare"`
+	// WaitingPerMinute is the amount charged per minute the driver waits beyond the free waiting time
+	WaitingPerMinute float64 `json:"waiting_per_minute" db:"waiting_per_minute"`
+	// FreeWaitingMinutes is how long the driver waits at pickup free of charge
+	FreeWaitingMinutes int `json:"free_waiting_minutes" db:"free_waiting_minutes"`
+	// NightMultiplier scales the fare of rides starting between NightStartHour and NightEndHour
+	NightMultiplier float64 `json:"night_multiplier" db:"night_multiplier"`
+	// NightStartHour is the hour (0-23, local time) at which night pricing begins
+	NightStartHour int `json:"night_start_hour" db:"night_start_hour"`
+	// NightEndHour is the hour (0-23, local time) at which night pricing ends; equal to NightStartHour disables it
+	NightEndHour int `json:"night_end_hour" db:"night_end_hour"`
+	// HolidayMultiplier scales the fare of rides starting on one of the Holidays
+	HolidayMultiplier float64 `json:"holiday_multiplier" db:"holiday_multiplier"`
+	// Holidays lists the dates (YYYY-MM-DD, local time) on which HolidayMultiplier applies
+	Holidays []string `json:"holidays" db:"holidays"`
+	// Timezone is the IANA time zone used for night and holiday pricing (e.g., Europe/Moscow)
+	Timezone string `json:"timezone" db:"timezone"`
+	// Default marks the tariff used when a ride does not name one
+	Default bool `json:"default" db:"is_default"`
+	// CreatedAt is the timestamp when the tariff record was created
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// UpdatedAt is the timestamp when the tariff record was last modified
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
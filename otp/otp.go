@@ -0,0 +1,198 @@
+// Package otp implements phone number login with one-time codes sent by SMS.
+//
+// A Service issues a short numeric code for a phone number and role, keeps only its hash,
+// and accepts it once within its lifetime. Sending is rate-limited per phone number
+// (a minimum interval between codes and a cap per window) and each code tolerates only a
+// few wrong guesses, after which a new code has to be requested.
+package otp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// codeDigits is the length of a login code.
+const codeDigits = 6
+
+// Errors returned by Service.
+var (
+	// ErrInvalidCode means the code is wrong, expired, already used, or was never requested
+	ErrInvalidCode = errors.New("invalid or expired code")
+	// ErrTooManyAttempts means the code was guessed wrong too many times and a new one must be requested
+	ErrTooManyAttempts = errors.New("too many attempts")
+)
+
+// RateLimitError is returned by Service.Request when a code was requested too soon or too often.
+type RateLimitError struct {
+	// RetryAfter is how long the caller has to wait before requesting another code
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("too many code requests, retry in %v", e.RetryAfter.Round(time.Second))
+}
+
+// Config holds the code lifetime and rate-limiting settings.
+type Config struct {
+	// TTL is how long a code stays valid
+	TTL time.Duration
+	// ResendInterval is the minimum time between two codes sent to the same phone number
+	ResendInterval time.Duration
+	// MaxSends is how many codes can be sent to a phone number per SendWindow
+	MaxSends int
+	// SendWindow is the period over which MaxSends is counted
+	SendWindow time.Duration
+	// MaxAttempts is how many verification attempts a single code allows
+	MaxAttempts int
+}
+
+// Validate reports the first setting that would make the service misbehave.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.TTL <= 0:
+		return errors.New("code lifetime must be positive")
+	case cfg.ResendInterval < 0:
+		return errors.New("resend interval must not be negative")
+	case cfg.MaxSends <= 0:
+		return errors.New("maximum sends must be positive")
+	case cfg.SendWindow <= 0:
+		return errors.New("send window must be positive")
+	case cfg.MaxAttempts <= 0:
+		return errors.New("maximum attempts must be positive")
+	}
+	return nil
+}
+
+// Service issues and verifies login codes.
+type Service struct {
+	codes  repository.OTPRepository
+	sender Sender
+	cfg    atomic.Pointer[Config]
+	now    func() time.Time
+}
+
+// NewService returns a Service that stores codes in codes and delivers them through sender.
+func NewService(codes repository.OTPRepository, sender Sender, cfg Config) *Service {
+	s := &Service{codes: codes, sender: sender, now: time.Now}
+	s.cfg.Store(&cfg)
+	return s
+}
+
+// SetConfig replaces the code lifetime and rate limits while the service is in use.
+// Codes already sent keep their expiry; the new limits apply from the next request.
+func (s *Service) SetConfig(cfg Config) {
+	s.cfg.Store(&cfg)
+}
+
+// Request generates a new code for phone and role, replacing any previous one, and sends it.
+// phone must already be normalized with validate.NormalizePhone.
+// It returns a *RateLimitError if the phone number has to wait before receiving another code.
+func (s *Service) Request(ctx context.Context, phone, role string) error {
+	cfg := s.cfg.Load()
+	now := s.now()
+	prev, err := s.codes.Get(ctx, phone, role)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	code := models.OTPCode{Phone: phone, Role: role, SentAt: now, SendCount: 1, WindowStartedAt: now}
+	if err == nil {
+		if wait := prev.SentAt.Add(cfg.ResendInterval).Sub(now); wait > 0 {
+			return &RateLimitError{RetryAfter: wait}
+		}
+		if windowEnd := prev.WindowStartedAt.Add(cfg.SendWindow); now.Before(windowEnd) {
+			if prev.SendCount >= cfg.MaxSends {
+				return &RateLimitError{RetryAfter: windowEnd.Sub(now)}
+			}
+			code.SendCount = prev.SendCount + 1
+			code.WindowStartedAt = prev.WindowStartedAt
+		}
+	}
+
+	value, err := generate()
+	if err != nil {
+		return err
+	}
+	code.CodeHash = hash(phone, role, value)
+	code.ExpiresAt = now.Add(cfg.TTL)
+	if err := s.codes.Save(ctx, code); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Your taxi login code: %s. Valid for %d min. Do not share it.", value, max(1, int(cfg.TTL.Minutes()+0.5)))
+	if err := s.sender.Send(ctx, phone, message); err != nil {
+		return fmt.Errorf("error sending code: %w", err)
+	}
+	return nil
+}
+
+// Verify checks value against the code issued for phone and role and consumes the code
+// if it matches. Every call counts as an attempt, so the code cannot be brute-forced.
+func (s *Service) Verify(ctx context.Context, phone, role, value string) error {
+	cfg := s.cfg.Load()
+	code, err := s.codes.Get(ctx, phone, role)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidCode
+	} else if err != nil {
+		return err
+	}
+	if !s.now().Before(code.ExpiresAt) {
+		return ErrInvalidCode
+	}
+	if code.Attempts >= cfg.MaxAttempts {
+		return ErrTooManyAttempts
+	}
+
+	// Counting the attempt in the store before comparing keeps concurrent guesses within the limit
+	attempts, err := s.codes.AddAttempt(ctx, phone, role)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidCode
+	} else if err != nil {
+		return err
+	}
+	if attempts > cfg.MaxAttempts {
+		return ErrTooManyAttempts
+	}
+	if subtle.ConstantTimeCompare([]byte(hash(phone, role, value)), []byte(code.CodeHash)) != 1 {
+		return ErrInvalidCode
+	}
+
+	// A concurrent successful verification may have consumed the code already
+	if err := s.codes.Delete(ctx, phone, role); errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidCode
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// generate returns a random numeric code of codeDigits digits.
+func generate() (string, error) {
+	limit := big.NewInt(1)
+	for range codeDigits {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n), nil
+}
+
+// hash returns the hex SHA-256 of a code bound to its phone number and role,
+// so equal codes issued to different numbers have different hashes.
+func hash(phone, role, value string) string {
+	sum := sha256.Sum256([]byte(phone + ":" + role + ":" + value))
+	return hex.EncodeToString(sum[:])
+}
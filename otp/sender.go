@@ -0,0 +1,68 @@
+package otp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sender delivers a text message to a phone number. An SMS gateway integration
+// implements it; LogSender and FileSender stand in for one during development.
+type Sender interface {
+	Send(ctx context.Context, phone, message string) error
+}
+
+// NewSender returns the Sender selected by kind: "log" writes messages to the server log
+// and "file" appends them to the file at path.
+func NewSender(kind, path string) (Sender, error) {
+	switch kind {
+	case "log":
+		return LogSender{}, nil
+	case "file":
+		if path == "" {
+			return nil, fmt.Errorf("file sender requires a path")
+		}
+		return NewFileSender(path), nil
+	}
+	return nil, fmt.Errorf("unknown SMS sender %q", kind)
+}
+
+// LogSender logs messages with log/slog instead of sending them.
+type LogSender struct{}
+
+// Send logs message for phone.
+func (LogSender) Send(ctx context.Context, phone, message string) error {
+	slog.InfoContext(ctx, "SMS", "phone", phone, "message", message)
+	return nil
+}
+
+// FileSender appends messages to a file, one line per message, instead of sending them.
+// It is safe for concurrent use.
+type FileSender struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileSender returns a FileSender that appends to the file at path.
+func NewFileSender(path string) *FileSender {
+	return &FileSender{path: path}
+}
+
+// Send appends a timestamped line with phone and message to the file.
+func (s *FileSender) Send(ctx context.Context, phone, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().UTC().Format(time.RFC3339), phone, message); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,175 @@
+// Package pricing calculates ride fares from a tariff.
+// The same Calculate function produces both the up-front estimate for a pickup/drop-off pair
+// and the final fare from the actual distance and duration of a completed ride.
+// Surge tracks supply and demand per zone and provides the surge multiplier applied on top.
+package pricing
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/geo"
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+const (
+	// RoadFactor converts a straight-line distance into an expected road distance
+	RoadFactor = 1.3
+	// AverageSpeed is the expected city travel speed in meters per second (25 km/h)
+	AverageSpeed = 25000.0 / 3600
+)
+
+// Trip describes the measurable parts of a ride that are priced.
+type Trip struct {
+	// Distance is the travelled distance in meters
+	Distance float64
+	// Duration is the time spent travelling
+	Duration time.Duration
+	// Waiting is the time the driver waited at the pickup point
+	Waiting time.Duration
+	// Start is when the ride started; it selects night and holiday pricing
+	Start time.Time
+	// Surge is the surge multiplier locked in when the ride was requested; zero means none
+	Surge float64
+}
+
+// Breakdown is an itemised fare. All amounts are rounded to two decimal places.
+type Breakdown struct {
+	// TariffID is the tariff the fare was calculated with
+	TariffID int `json:"tariff_id"`
+	// DistanceKm is the priced distance in kilometers
+	DistanceKm float64 `json:"distance_km"`
+	// DurationMin is the priced travel time in minutes
+	DurationMin float64 `json:"duration_min"`
+	// WaitingMin is the chargeable waiting time in minutes, after the free waiting time
+	WaitingMin float64 `json:"waiting_min"`
+	// BaseFare is the fixed part of the fare
+	BaseFare float64 `json:"base_fare"`
+	// DistanceFare is the distance-based part of the fare
+	DistanceFare float64 `json:"distance_fare"`
+	// TimeFare is the time-based part of the fare
+	TimeFare float64 `json:"time_fare"`
+	// WaitingFare is the charge for waiting at pickup
+	WaitingFare float64 `json:"waiting_fare"`
+	// Subtotal is the sum of the parts before multipliers
+	Subtotal float64 `json:"subtotal"`
+	// Multiplier is the night or holiday multiplier applied to the subtotal (1 if none)
+	Multiplier float64 `json:"multiplier"`
+	// MinimumApplied reports whether the total was raised to the tariff's minimum fare
+	MinimumApplied bool `json:"minimum_applied"`
+	// SurgeMultiplier is the demand-based multiplier applied last (1 if none)
+	SurgeMultiplier float64 `json:"surge_multiplier"`
+	// Total is the amount the client pays
+	Total float64 `json:"total"`
+}
+
+// EstimateTrip predicts the trip between pickup and dropoff starting at start,
+// using RoadFactor and AverageSpeed. No waiting time is assumed.
+func EstimateTrip(pickup, dropoff geo.Point, start time.Time) Trip {
+	distance := geo.Distance(pickup, dropoff) * RoadFactor
+	return Trip{
+		Distance: distance,
+		Duration: time.Duration(distance / AverageSpeed * float64(time.Second)),
+		Start:    start,
+	}
+}
+
+// Calculate prices trip with tariff t. When both night and holiday pricing apply,
+// the larger multiplier is used. The surge multiplier scales the result after
+// the minimum fare is applied. The tariff must have passed ValidateTariff.
+func Calculate(t models.Tariff, trip Trip) Breakdown {
+	b := Breakdown{
+		TariffID:        t.ID,
+		DistanceKm:      round(trip.Distance / 1000),
+		DurationMin:     round(trip.Duration.Minutes()),
+		WaitingMin:      round(math.Max(0, trip.Waiting.Minutes()-float64(t.FreeWaitingMinutes))),
+		BaseFare:        round(t.BaseFare),
+		Multiplier:      Multiplier(t, trip.Start),
+		SurgeMultiplier: math.Max(1, trip.Surge),
+	}
+	b.DistanceFare = round(b.DistanceKm * t.PerKm)
+	b.TimeFare = round(b.DurationMin * t.PerMinute)
+	b.WaitingFare = round(b.WaitingMin * t.WaitingPerMinute)
+	b.Subtotal = round(b.BaseFare + b.DistanceFare + b.TimeFare + b.WaitingFare)
+	b.Total = round(b.Subtotal * b.Multiplier)
+	if b.Total < t.MinimumFare {
+		b.Total = round(t.MinimumFare)
+		b.MinimumApplied = true
+	}
+	b.Total = round(b.Total * b.SurgeMultiplier)
+	return b
+}
+
+// Multiplier returns the night or holiday multiplier of t for a ride starting at start,
+// or 1 if neither applies.
+func Multiplier(t models.Tariff, start time.Time) float64 {
+	if loc, err := time.LoadLocation(t.Timezone); err == nil {
+		start = start.In(loc)
+	}
+
+	multiplier := 1.0
+	if isNight(t, start.Hour()) {
+		multiplier = math.Max(multiplier, t.NightMultiplier)
+	}
+	if slices.Contains(t.Holidays, start.Format(time.DateOnly)) {
+		multiplier = math.Max(multiplier, t.HolidayMultiplier)
+	}
+	return multiplier
+}
+
+// ValidateTariff checks that the amounts of t are non-negative, multipliers are at least 1,
+// hours are within 0-23, holidays are YYYY-MM-DD dates and the time zone is known.
+func ValidateTariff(t models.Tariff) error {
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	for name, amount := range map[string]float64{
+		"base_fare":          t.BaseFare,
+		"per_km":             t.PerKm,
+		"per_minute":         t.PerMinute,
+		"minimum_fare":       t.MinimumFare,
+		"waiting_per_minute": t.WaitingPerMinute,
+	} {
+		if amount < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	if t.FreeWaitingMinutes < 0 {
+		return fmt.Errorf("free_waiting_minutes must not be negative")
+	}
+	if t.NightMultiplier < 1 || t.HolidayMultiplier < 1 {
+		return fmt.Errorf("night_multiplier and holiday_multiplier must be at least 1")
+	}
+	if t.NightStartHour < 0 || t.NightStartHour > 23 || t.NightEndHour < 0 || t.NightEndHour > 23 {
+		return fmt.Errorf("night_start_hour and night_end_hour must be between 0 and 23")
+	}
+	for _, day := range t.Holidays {
+		if _, err := time.Parse(time.DateOnly, day); err != nil {
+			return fmt.Errorf("holiday %q is not a YYYY-MM-DD date", day)
+		}
+	}
+	if _, err := time.LoadLocation(t.Timezone); err != nil || t.Timezone == "" {
+		return fmt.Errorf("unknown timezone %q", t.Timezone)
+	}
+	return nil
+}
+
+// isNight reports whether hour falls in the tariff's night window, which may wrap past midnight.
+func isNight(t models.Tariff, hour int) bool {
+	start, end := t.NightStartHour, t.NightEndHour
+	switch {
+	case start == end:
+		return false
+	case start < end:
+		return hour >= start && hour < end
+	default:
+		return hour >= start || hour < end
+	}
+}
+
+// round rounds an amount to two decimal places.
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
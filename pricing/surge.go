@@ -0,0 +1,296 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/geo"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// surgeSteps is the number of published surge levels per unit of multiplier (steps of 0.1).
+const surgeSteps = 10
+
+// SurgeConfig holds the surge pricing tuning parameters.
+type SurgeConfig struct {
+	// ZoneSize is the side of a square surge zone in degrees
+	ZoneSize float64
+	// Window is how long a ride request still waiting for a driver counts as demand in its zone
+	Window time.Duration
+	// Interval is how often the multipliers are recomputed
+	Interval time.Duration
+	// Sensitivity is how much the multiplier grows per unit of demand/supply ratio above 1
+	Sensitivity float64
+	// Cap is the highest multiplier that can be applied
+	Cap float64
+	// Smoothing is the weight (0-1] of a new target multiplier against the previous one;
+	// lower values make prices change more slowly
+	Smoothing float64
+}
+
+// Validate reports the first setting that would make surge pricing misbehave.
+func (cfg SurgeConfig) Validate() error {
+	switch {
+	case cfg.ZoneSize <= 0:
+		return errors.New("surge zone size must be positive")
+	case cfg.Window <= 0 || cfg.Interval <= 0:
+		return errors.New("surge window and interval must be positive")
+	case cfg.Sensitivity < 0:
+		return errors.New("surge sensitivity must not be negative")
+	case cfg.Cap < 1:
+		return errors.New("surge cap must be at least 1")
+	case cfg.Smoothing <= 0 || cfg.Smoothing > 1:
+		return errors.New("surge smoothing must be in (0, 1]")
+	}
+	return nil
+}
+
+// Zone is a square cell of the surge grid.
+type Zone struct {
+	// Row is the latitude index of the cell
+	Row int `json:"row"`
+	// Col is the longitude index of the cell
+	Col int `json:"col"`
+}
+
+// ZoneOf returns the zone of size degrees that contains p.
+func ZoneOf(p geo.Point, size float64) Zone {
+	return Zone{Row: int(math.Floor(p.Lat / size)), Col: int(math.Floor(p.Lon / size))}
+}
+
+// ZoneSurge is the surge state of a single zone.
+type ZoneSurge struct {
+	// Zone is the grid cell
+	Zone Zone `json:"zone"`
+	// Multiplier is the published surge multiplier, rounded to 0.1
+	Multiplier float64 `json:"multiplier"`
+	// Demand is the number of ride requests in the zone still waiting for a driver, within the window
+	Demand int `json:"demand"`
+	// Supply is the number of available drivers in the zone
+	Supply int `json:"supply"`
+	// UpdatedAt is when the multipliers were last recomputed
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TargetMultiplier returns the surge multiplier that demand ride requests and supply
+// available drivers call for: 1 while requests do not outnumber drivers, growing by
+// cfg.Sensitivity per unit of the demand/supply ratio above 1, and capped at cfg.Cap.
+// A zone with no drivers is treated as having one.
+func TargetMultiplier(cfg SurgeConfig, demand, supply int) float64 {
+	ratio := float64(demand) / float64(max(supply, 1))
+	return math.Min(cfg.Cap, math.Max(1, 1+cfg.Sensitivity*(ratio-1)))
+}
+
+// Smooth moves previous towards target by cfg.Smoothing, so a single recomputation
+// never jumps straight to a new level.
+func Smooth(cfg SurgeConfig, previous, target float64) float64 {
+	return previous + cfg.Smoothing*(target-previous)
+}
+
+// zoneState is the internal state of a zone; multiplier keeps full precision so that
+// smoothing converges, and is rounded only when published.
+type zoneState struct {
+	demand     int
+	supply     int
+	multiplier float64
+}
+
+// Request is a ride request still waiting for a driver, which counts as demand.
+type Request struct {
+	// Pickup is where the client waits
+	Pickup geo.Point
+	// At is when the ride was requested
+	At time.Time
+}
+
+// DemandFunc returns the ride requests still waiting for a driver.
+type DemandFunc func(ctx context.Context) ([]Request, error)
+
+// SupplyFunc returns the positions of the drivers currently available for rides.
+type SupplyFunc func(ctx context.Context) ([]geo.Point, error)
+
+// Surge tracks ride demand and driver supply per zone and derives a surge multiplier for each.
+// Multipliers change only in Update, which is deterministic given the open requests,
+// the driver positions and the time, so the computation can be replayed.
+// Surge is safe for concurrent use; it holds state in memory per replica.
+type Surge struct {
+	cfg     SurgeConfig
+	enabled atomic.Bool
+
+	mu        sync.RWMutex
+	zones     map[Zone]*zoneState
+	updatedAt time.Time
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// NewSurge returns a Surge with no recorded demand, in which every multiplier is 1.
+func NewSurge(cfg SurgeConfig) *Surge {
+	s := &Surge{cfg: cfg, zones: make(map[Zone]*zoneState)}
+	s.enabled.Store(true)
+	return s
+}
+
+// SetEnabled switches surge pricing on or off. While it is off every multiplier is 1,
+// but demand and supply are still tracked, so multipliers are current when it is switched on.
+func (s *Surge) SetEnabled(enabled bool) {
+	s.enabled.Store(enabled)
+}
+
+// Update recomputes every zone's multiplier at now from the open ride requests made
+// within the window and the given available driver positions. Requests older than the
+// window no longer count. Zones that have settled back to 1 with no demand are forgotten.
+func (s *Surge) Update(now time.Time, requests []Request, drivers []geo.Point) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, state := range s.zones {
+		state.demand, state.supply = 0, 0
+	}
+	since := now.Add(-s.cfg.Window)
+	for _, request := range requests {
+		if !request.At.Before(since) && !request.At.After(now) {
+			s.zone(ZoneOf(request.Pickup, s.cfg.ZoneSize)).demand++
+		}
+	}
+	for _, p := range drivers {
+		if state, ok := s.zones[ZoneOf(p, s.cfg.ZoneSize)]; ok {
+			state.supply++
+		}
+	}
+
+	for zone, state := range s.zones {
+		target := TargetMultiplier(s.cfg, state.demand, state.supply)
+		state.multiplier = Smooth(s.cfg, state.multiplier, target)
+		if state.demand == 0 && roundStep(state.multiplier) <= 1 {
+			delete(s.zones, zone)
+		}
+	}
+	s.updatedAt = now
+}
+
+// Multiplier returns the current surge multiplier for a pickup at p.
+func (s *Surge) Multiplier(p geo.Point) float64 {
+	return s.Zone(p).Multiplier
+}
+
+// Zone returns the current surge state of p's zone; its multiplier is 1 while surge pricing is off.
+func (s *Surge) Zone(p geo.Point) ZoneSurge {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	zone := ZoneOf(p, s.cfg.ZoneSize)
+	result := ZoneSurge{Zone: zone, Multiplier: 1, UpdatedAt: s.updatedAt}
+	if state, ok := s.zones[zone]; ok {
+		if s.enabled.Load() {
+			result.Multiplier = math.Max(1, roundStep(state.multiplier))
+		}
+		result.Demand = state.demand
+		result.Supply = state.supply
+	}
+	return result
+}
+
+// Start recomputes the multipliers every cfg.Interval in the background,
+// taking the open ride requests from demand and the available drivers from supply.
+func (s *Surge) Start(demand DemandFunc, supply SupplyFunc) {
+	ctx, stop := context.WithCancel(context.Background())
+	s.stop = stop
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				requests, err := demand(ctx)
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to load ride demand for surge pricing", "error", err)
+					continue
+				}
+				drivers, err := supply(ctx)
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to load driver supply for surge pricing", "error", err)
+					continue
+				}
+				s.Update(now, requests, drivers)
+			}
+		}
+	}()
+}
+
+// Stop ends background recomputation started by Start and waits for it to finish.
+func (s *Surge) Stop() {
+	if s.stop != nil {
+		s.stop()
+	}
+	s.wg.Wait()
+}
+
+// zone returns the state of zone, creating it at multiplier 1. The caller must hold the write lock.
+func (s *Surge) zone(zone Zone) *zoneState {
+	state, ok := s.zones[zone]
+	if !ok {
+		state = &zoneState{multiplier: 1}
+		s.zones[zone] = state
+	}
+	return state
+}
+
+// OpenRequests returns a DemandFunc listing the rides in rides still waiting for a driver
+// that were requested within window.
+func OpenRequests(rides repository.RideRepository, window time.Duration) DemandFunc {
+	return func(ctx context.Context) ([]Request, error) {
+		requested, err := rides.Requested(ctx, time.Now().Add(-window))
+		if err != nil {
+			return nil, err
+		}
+		requests := make([]Request, len(requested))
+		for i, ride := range requested {
+			requests[i] = Request{Pickup: geo.Point{Lat: ride.PickupLat, Lon: ride.PickupLon}, At: ride.RequestedAt}
+		}
+		return requests, nil
+	}
+}
+
+// AvailableDrivers returns a SupplyFunc listing the positions of available drivers
+// whose last location is no older than maxAge.
+func AvailableDrivers(drivers repository.DriverRepository, locations repository.LocationRepository, maxAge time.Duration) SupplyFunc {
+	return func(ctx context.Context) ([]geo.Point, error) {
+		available, _, err := drivers.List(ctx, repository.DriverFilter{Status: models.DriverAvailable}, repository.Page{})
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[int]bool, len(available))
+		for _, driver := range available {
+			ids[driver.ID] = true
+		}
+
+		recent, err := locations.Recent(ctx, time.Now().Add(-maxAge))
+		if err != nil {
+			return nil, err
+		}
+		var points []geo.Point
+		for _, loc := range recent {
+			if ids[loc.DriverID] {
+				points = append(points, geo.Point{Lat: loc.Lat, Lon: loc.Lon})
+			}
+		}
+		return points, nil
+	}
+}
+
+// roundStep rounds a multiplier to the nearest published level.
+func roundStep(multiplier float64) float64 {
+	return math.Round(multiplier*surgeSteps) / surgeSteps
+}
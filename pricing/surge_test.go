@@ -0,0 +1,205 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/geo"
+)
+
+var testSurgeConfig = SurgeConfig{
+	ZoneSize:    0.02,
+	Window:      10 * time.Minute,
+	Interval:    30 * time.Second,
+	Sensitivity: 0.5,
+	Cap:         3,
+	Smoothing:   1,
+}
+
+func TestTargetMultiplier(t *testing.T) {
+	tests := []struct {
+		name           string
+		demand, supply int
+		want           float64
+	}{
+		{"no demand", 0, 5, 1},
+		{"fewer requests than drivers", 3, 5, 1},
+		{"balanced", 5, 5, 1},
+		{"twice as many requests", 10, 5, 1.5},
+		{"no drivers counts as one", 3, 0, 2},
+		{"capped", 100, 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TargetMultiplier(testSurgeConfig, tt.demand, tt.supply); got != tt.want {
+				t.Errorf("TargetMultiplier(%d, %d) = %v, want %v", tt.demand, tt.supply, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSmooth(t *testing.T) {
+	tests := []struct {
+		name                        string
+		smoothing, previous, target float64
+		want                        float64
+	}{
+		{"rising", 0.3, 1, 2, 1.3},
+		{"falling", 0.3, 2, 1, 1.7},
+		{"settled", 0.3, 1.5, 1.5, 1.5},
+		{"no smoothing", 1, 1, 2.5, 2.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testSurgeConfig
+			cfg.Smoothing = tt.smoothing
+			if got := Smooth(cfg, tt.previous, tt.target); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Smooth(%v, %v) = %v, want %v", tt.previous, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSurgeUpdate(t *testing.T) {
+	now := time.Date(2025, 3, 1, 18, 0, 0, 0, time.UTC)
+	pickup := geo.Point{Lat: 55.7558, Lon: 37.6173}
+	elsewhere := geo.Point{Lat: 55.9, Lon: 37.9}
+	requests := func(p geo.Point, at time.Time, n int) []Request {
+		var requests []Request
+		for range n {
+			requests = append(requests, Request{Pickup: p, At: at})
+		}
+		return requests
+	}
+	drivers := func(p geo.Point, n int) []geo.Point {
+		var drivers []geo.Point
+		for range n {
+			drivers = append(drivers, p)
+		}
+		return drivers
+	}
+
+	tests := []struct {
+		name     string
+		requests []Request
+		drivers  []geo.Point
+		want     ZoneSurge
+	}{
+		{
+			name: "no demand",
+			want: ZoneSurge{Multiplier: 1},
+		},
+		{
+			name:     "demand exceeds supply",
+			requests: requests(pickup, now.Add(-time.Minute), 4),
+			drivers:  drivers(pickup, 2),
+			want:     ZoneSurge{Multiplier: 1.5, Demand: 4, Supply: 2},
+		},
+		{
+			name:     "drivers in other zones do not count",
+			requests: requests(pickup, now.Add(-time.Minute), 4),
+			drivers:  drivers(elsewhere, 4),
+			want:     ZoneSurge{Multiplier: 2.5, Demand: 4},
+		},
+		{
+			name:     "requests in other zones do not count",
+			requests: requests(elsewhere, now.Add(-time.Minute), 4),
+			drivers:  drivers(pickup, 1),
+			want:     ZoneSurge{Multiplier: 1},
+		},
+		{
+			name:     "request at the start of the window counts",
+			requests: requests(pickup, now.Add(-testSurgeConfig.Window), 3),
+			want:     ZoneSurge{Multiplier: 2, Demand: 3},
+		},
+		{
+			name:     "requests older than the window do not count",
+			requests: requests(pickup, now.Add(-testSurgeConfig.Window-time.Second), 3),
+			want:     ZoneSurge{Multiplier: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSurge(testSurgeConfig)
+			s.Update(now, tt.requests, tt.drivers)
+
+			tt.want.Zone = ZoneOf(pickup, testSurgeConfig.ZoneSize)
+			tt.want.UpdatedAt = now
+			if got := s.Zone(pickup); got != tt.want {
+				t.Errorf("Zone() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSurgeWindowExpiry(t *testing.T) {
+	cfg := testSurgeConfig
+	cfg.Smoothing = 0.5
+	s := NewSurge(cfg)
+	start := time.Date(2025, 3, 1, 18, 0, 0, 0, time.UTC)
+	pickup := geo.Point{Lat: 55.7558, Lon: 37.6173}
+	open := []Request{{Pickup: pickup, At: start}, {Pickup: pickup, At: start}, {Pickup: pickup, At: start}}
+
+	// Three requests and no drivers target 2; each step closes half of the remaining gap.
+	// The requests stay open but stop counting once they are older than the window.
+	steps := []struct {
+		after time.Duration
+		want  float64
+	}{
+		{0, 1.5},
+		{5 * time.Minute, 1.8},
+		{cfg.Window, 1.9},
+		{cfg.Window + time.Minute, 1.4},
+		{cfg.Window + 2*time.Minute, 1.2},
+	}
+	for _, step := range steps {
+		s.Update(start.Add(step.after), open, nil)
+		if got := s.Multiplier(pickup); got != step.want {
+			t.Errorf("after %v: Multiplier() = %v, want %v", step.after, got, step.want)
+		}
+	}
+
+	for range 10 {
+		s.Update(start.Add(time.Hour), open, nil)
+	}
+	if len(s.zones) != 0 {
+		t.Errorf("zones = %d, want settled zone forgotten", len(s.zones))
+	}
+}
+
+func TestSurgeDisabled(t *testing.T) {
+	s := NewSurge(testSurgeConfig)
+	now := time.Date(2025, 3, 1, 18, 0, 0, 0, time.UTC)
+	pickup := geo.Point{Lat: 55.7558, Lon: 37.6173}
+	s.Update(now, []Request{{Pickup: pickup, At: now}, {Pickup: pickup, At: now}, {Pickup: pickup, At: now}}, nil)
+
+	s.SetEnabled(false)
+	if got := s.Zone(pickup); got.Multiplier != 1 || got.Demand != 3 {
+		t.Errorf("disabled: Zone() = %+v, want multiplier 1 with demand 3", got)
+	}
+	s.SetEnabled(true)
+	if got := s.Multiplier(pickup); got != 2 {
+		t.Errorf("enabled again: Multiplier() = %v, want 2", got)
+	}
+}
+
+func TestSurgeUpdateTimeZones(t *testing.T) {
+	// The ticker reports the local time of the service, while request times come from a
+	// TIMESTAMPTZ column, which lib/pq returns in the time zone of the database session
+	moscow := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2025, 3, 1, 21, 0, 0, 0, moscow)
+	pickup := geo.Point{Lat: 55.7558, Lon: 37.6173}
+	requested := now.Add(-time.Minute)
+	requests := []Request{
+		{Pickup: pickup, At: requested.UTC()},
+		{Pickup: pickup, At: requested.In(time.FixedZone("", -5*60*60))},
+		{Pickup: pickup, At: now.Add(-testSurgeConfig.Window - time.Minute).UTC()},
+	}
+
+	s := NewSurge(testSurgeConfig)
+	s.Update(now, requests, nil)
+	if got := s.Zone(pickup); got.Demand != 2 {
+		t.Errorf("Zone() = %+v, want demand 2 from the requests made a minute ago", got)
+	}
+}
@@ -0,0 +1,112 @@
+// Package purge permanently removes soft-deleted records once they have been deleted
+// for longer than the retention period. Until then they can be restored.
+package purge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Repository is implemented by the repositories that soft-delete records,
+// such as repository.ClientRepository.
+type Repository interface {
+	// Purge permanently removes the records deleted before the given time and returns how many were removed.
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// Target is a repository to purge and the name of its records used in log messages.
+type Target struct {
+	Name string
+	Repo Repository
+}
+
+// Config holds the purge settings.
+type Config struct {
+	// Retention is how long deleted records are kept; 0 keeps them forever
+	Retention time.Duration
+	// Interval is how often expired records are purged
+	Interval time.Duration
+}
+
+// Validate reports the first setting that would make purging misbehave.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.Retention < 0:
+		return errors.New("retention of deleted records must not be negative")
+	case cfg.Retention > 0 && cfg.Interval <= 0:
+		return errors.New("purge interval must be positive")
+	}
+	return nil
+}
+
+// Purger removes expired deleted records from its targets on a schedule.
+type Purger struct {
+	cfg     Config
+	targets []Target
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// New returns a Purger for targets. They are purged in the given order, so records
+// should come before the records they refer to, e.g. cars before drivers.
+func New(cfg Config, targets ...Target) *Purger {
+	return &Purger{cfg: cfg, targets: targets}
+}
+
+// Run purges every target once, removing the records deleted before now minus the
+// retention period. A failing target does not stop the others; all errors are returned.
+func (p *Purger) Run(ctx context.Context, now time.Time) error {
+	before := now.Add(-p.cfg.Retention)
+	var errs []error
+	for _, target := range p.targets {
+		n, err := target.Repo.Purge(ctx, before)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error purging deleted %s: %w", target.Name, err))
+			continue
+		}
+		if n > 0 {
+			slog.InfoContext(ctx, "Purged deleted records", "records", target.Name, "count", n, "deleted_before", before)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Start runs Run every cfg.Interval in the background, starting immediately.
+// It does nothing if cfg.Retention is 0.
+func (p *Purger) Start() {
+	if p.cfg.Retention == 0 {
+		return
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	p.stop = stop
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.cfg.Interval)
+		defer ticker.Stop()
+		now := time.Now()
+		for {
+			if err := p.Run(ctx, now); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Failed to purge deleted records", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case now = <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop ends background purging started by Start and waits for it to finish.
+func (p *Purger) Stop() {
+	if p.stop != nil {
+		p.stop()
+	}
+	p.wg.Wait()
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// AuditFilter narrows the events returned by AuditRepository.List. Zero fields match everything.
+type AuditFilter struct {
+	// EntityType is the kind of record the events are about, e.g. "driver"
+	EntityType string
+	// EntityID is the ID of the record the events are about
+	EntityID int
+}
+
+// AuditRepository provides persistent storage for the audit log.
+type AuditRepository interface {
+	// Record stores a new event and sets its ID. Called inside Transactor.InTx, the event
+	// is stored only if the change it describes is.
+	Record(ctx context.Context, event *models.AuditEvent) error
+	// List returns the page of events matching filter.
+	List(ctx context.Context, filter AuditFilter, page Page) ([]models.AuditEvent, *Cursor, error)
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// CarFilter narrows the cars returned by CarRepository.List.
+// Zero-valued fields do not filter.
+type CarFilter struct {
+	// DriverID limits the result to cars belonging to this driver
+	DriverID int
+	// DriverIDs limits the result to cars belonging to these drivers unless it is nil
+	DriverIDs []int
+	// Brand limits the result to cars of this brand, ignoring case
+	Brand string
+	// Year limits the result to cars made in this year
+	Year int
+	// IncludeDeleted adds soft-deleted cars to the result
+	IncludeDeleted bool
+}
+
+// CarRepository provides persistent storage for cars.
+type CarRepository interface {
+	// List returns the page of cars matching filter, sorted by one of CarSortFields,
+	// and the cursor of the next page, which is nil if this is the last one.
+	List(ctx context.Context, filter CarFilter, page Page) ([]models.Car, *Cursor, error)
+	// Get returns the car with the given ID or ErrNotFound. Deleted cars are not found.
+	Get(ctx context.Context, id int) (models.Car, error)
+	// GetIncludingDeleted is like Get but also returns a deleted car.
+	GetIncludingDeleted(ctx context.Context, id int) (models.Car, error)
+	// Create stores a new car and sets its ID. It returns a *DuplicateError if the
+	// license plate is already taken.
+	Create(ctx context.Context, car *models.Car) error
+	// Update overwrites the car identified by car.ID or returns ErrNotFound.
+	// Like Create, it returns a *DuplicateError if the license plate is already taken.
+	// The write only happens if the stored updated_at still equals updatedAt, the value
+	// the caller read; otherwise it returns ErrConflict.
+	Update(ctx context.Context, car *models.Car, updatedAt time.Time) error
+	// Delete marks the car with the given ID as deleted, hiding it from the other
+	// methods, or returns ErrNotFound if there is no such car or it is already deleted.
+	Delete(ctx context.Context, id int) error
+	// Restore undoes Delete for the car with the given ID. It returns ErrNotFound if there
+	// is no such car, ErrConflict if it is not deleted, and a *DuplicateError if another
+	// car has taken one of its unique values in the meantime.
+	Restore(ctx context.Context, id int) error
+	// Purge permanently removes the cars deleted before the given time and returns how many
+	// were removed. Cars that rides refer to are kept, since the rides are needed for disputes.
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// ClientFilter narrows the clients returned by ClientRepository.List.
+// Zero-valued fields do not filter.
+type ClientFilter struct {
+	// Name limits the result to clients whose name contains this text, ignoring case
+	Name string
+	// Phone limits the result to clients whose phone number, normalized with
+	// validate.NormalizePhone, equals this one
+	Phone string
+	// IncludeDeleted adds soft-deleted clients to the result
+	IncludeDeleted bool
+}
+
+// ClientRepository provides persistent storage for clients.
+type ClientRepository interface {
+	// List returns the page of clients matching filter, sorted by one of ClientSortFields,
+	// and the cursor of the next page, which is nil if this is the last one.
+	List(ctx context.Context, filter ClientFilter, page Page) ([]models.Client, *Cursor, error)
+	// Get returns the client with the given ID or ErrNotFound. Deleted clients are not found.
+	Get(ctx context.Context, id int) (models.Client, error)
+	// GetIncludingDeleted is like Get but also returns a deleted client.
+	GetIncludingDeleted(ctx context.Context, id int) (models.Client, error)
+	// GetByPhone returns the client whose phone number, normalized with validate.NormalizePhone,
+	// equals phone, or ErrNotFound. If several match, the one with the lowest ID is returned.
+	GetByPhone(ctx context.Context, phone string) (models.Client, error)
+	// Create stores a new client and sets its ID. It returns a *DuplicateError if the
+	// phone number or email is already taken.
+	Create(ctx context.Context, client *models.Client) error
+	// Update overwrites the client identified by client.ID or returns ErrNotFound.
+	// Like Create, it returns a *DuplicateError if the phone number or email is already taken.
+	// The write only happens if the stored updated_at still equals updatedAt, the value
+	// the caller read; otherwise it returns ErrConflict.
+	Update(ctx context.Context, client *models.Client, updatedAt time.Time) error
+	// Delete marks the client with the given ID as deleted, hiding it from the other
+	// methods, or returns ErrNotFound if there is no such client or it is already deleted.
+	Delete(ctx context.Context, id int) error
+	// Restore undoes Delete for the client with the given ID. It returns ErrNotFound if there
+	// is no such client, ErrConflict if it is not deleted, and a *DuplicateError if another
+	// client has taken one of its unique values in the meantime.
+	Restore(ctx context.Context, id int) error
+	// Purge permanently removes the clients deleted before the given time and returns how many
+	// were removed. Clients that rides refer to are kept, since the rides are needed
+	// for disputes.
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
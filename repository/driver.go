@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// DriverFilter narrows the drivers returned by DriverRepository.List.
+// Zero-valued fields do not filter.
+type DriverFilter struct {
+	// Status limits the result to drivers with this availability status
+	Status models.DriverStatus
+	// MinRating limits the result to drivers rated at least this high
+	MinRating float64
+	// IDs limits the result to the drivers with these IDs unless it is nil
+	IDs []int
+	// IncludeDeleted adds soft-deleted drivers to the result
+	IncludeDeleted bool
+}
+
+// DriverRepository provides persistent storage for drivers.
+type DriverRepository interface {
+	// List returns the page of drivers matching filter, sorted by one of DriverSortFields,
+	// and the cursor of the next page, which is nil if this is the last one.
+	List(ctx context.Context, filter DriverFilter, page Page) ([]models.Driver, *Cursor, error)
+	// Get returns the driver with the given ID or ErrNotFound. Deleted drivers are not found.
+	Get(ctx context.Context, id int) (models.Driver, error)
+	// GetIncludingDeleted is like Get but also returns a deleted driver.
+	GetIncludingDeleted(ctx context.Context, id int) (models.Driver, error)
+	// GetByPhone returns the driver whose phone number, normalized with validate.NormalizePhone,
+	// equals phone, or ErrNotFound. If several match, the one with the lowest ID is returned.
+	GetByPhone(ctx context.Context, phone string) (models.Driver, error)
+	// Create stores a new driver and sets its ID. It returns a *DuplicateError if the
+	// phone number or license number is already taken.
+	Create(ctx context.Context, driver *models.Driver) error
+	// Update overwrites the profile of the driver identified by driver.ID or returns ErrNotFound.
+	// Like Create, it returns a *DuplicateError if the phone number or license number is already taken.
+	// The stored status is left unchanged and copied into driver.Status.
+	// The write only happens if the stored updated_at still equals updatedAt, the value
+	// the caller read; otherwise it returns ErrConflict.
+	Update(ctx context.Context, driver *models.Driver, updatedAt time.Time) error
+	// Delete marks the driver with the given ID as deleted, hiding it from the other
+	// methods, or returns ErrNotFound if there is no such driver or it is already deleted.
+	Delete(ctx context.Context, id int) error
+	// Restore undoes Delete for the driver with the given ID. It returns ErrNotFound if there
+	// is no such driver, ErrConflict if it is not deleted, and a *DuplicateError if another
+	// driver has taken one of its unique values in the meantime.
+	Restore(ctx context.Context, id int) error
+	// Purge permanently removes the drivers deleted before the given time and returns how many
+	// were removed. Drivers that rides or cars refer to are kept, since the rides are
+	// needed for disputes; the shifts of removed drivers are removed with them.
+	Purge(ctx context.Context, before time.Time) (int, error)
+	// SetStatus changes the status of the driver with the given ID from from to to and sets
+	// its updated_at to updatedAt. It returns ErrNotFound if the driver does not exist or is
+	// deleted, and ErrConflict if its status is not from.
+	SetStatus(ctx context.Context, id int, from, to models.DriverStatus, updatedAt time.Time) error
+	// CountByStatus returns the number of drivers in each status. Deleted drivers are not counted.
+	CountByStatus(ctx context.Context) (map[models.DriverStatus]int, error)
+}
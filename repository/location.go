@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// NearbyQuery describes a radius search for driver locations.
+type NearbyQuery struct {
+	// Lat is the latitude of the search center in degrees
+	Lat float64
+	// Lon is the longitude of the search center in degrees
+	Lon float64
+	// Radius is the search radius in meters
+	Radius float64
+	// Since excludes locations recorded before this time, so stale positions are not matched
+	Since time.Time
+}
+
+// NearbyLocation is a driver location matched by LocationRepository.Nearby.
+type NearbyLocation struct {
+	models.DriverLocation
+	// Distance is the distance from the search center in meters
+	Distance float64
+}
+
+// LocationRepository stores the latest known position of each driver.
+type LocationRepository interface {
+	// Save records loc as the driver's latest position unless a newer position is already stored.
+	Save(ctx context.Context, loc models.DriverLocation) error
+	// Get returns the latest position of the given driver or ErrNotFound.
+	Get(ctx context.Context, driverID int) (models.DriverLocation, error)
+	// Nearby returns the positions matching query ordered by distance, nearest first.
+	Nearby(ctx context.Context, query NearbyQuery) ([]NearbyLocation, error)
+	// Recent returns every position recorded at or after since, ordered by driver ID.
+	Recent(ctx context.Context, since time.Time) ([]models.DriverLocation, error)
+}
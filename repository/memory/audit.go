@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// AuditRepository is an in-memory repository.AuditRepository. It is safe for concurrent use.
+type AuditRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	events []models.AuditEvent
+}
+
+// NewAuditRepository returns an empty AuditRepository.
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{nextID: 1}
+}
+
+// Record stores a new event and sets its ID.
+func (r *AuditRepository) Record(ctx context.Context, event *models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.ID = r.nextID
+	r.nextID++
+	r.events = append(r.events, *event)
+	id := event.ID
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.events = slices.DeleteFunc(r.events, func(e models.AuditEvent) bool { return e.ID == id })
+	})
+	return nil
+}
+
+// List returns the page of events matching filter.
+func (r *AuditRepository) List(ctx context.Context, filter repository.AuditFilter, page repository.Page) ([]models.AuditEvent, *repository.Cursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]models.AuditEvent, 0, len(r.events))
+	for _, event := range r.events {
+		if filter.EntityType != "" && event.EntityType != filter.EntityType {
+			continue
+		}
+		if filter.EntityID != 0 && event.EntityID != filter.EntityID {
+			continue
+		}
+		events = append(events, event)
+	}
+	events, next := paginate(events, page, repository.AuditSortKey, func(e models.AuditEvent) int { return e.ID })
+	return events, next, nil
+}
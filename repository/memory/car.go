@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/hse-trpo-taxi/backend/validate"
+)
+
+// CarRepository is an in-memory repository.CarRepository. It is safe for concurrent use.
+type CarRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	cars   map[int]models.Car
+}
+
+// NewCarRepository returns an empty CarRepository.
+func NewCarRepository() *CarRepository {
+	return &CarRepository{nextID: 1, cars: make(map[int]models.Car)}
+}
+
+// List returns the page of cars matching filter.
+func (r *CarRepository) List(ctx context.Context, filter repository.CarFilter, page repository.Page) ([]models.Car, *repository.Cursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cars := make([]models.Car, 0, len(r.cars))
+	for _, car := range r.cars {
+		if car.DeletedAt != nil && !filter.IncludeDeleted {
+			continue
+		}
+		if filter.DriverID != 0 && car.DriverID != filter.DriverID {
+			continue
+		}
+		if filter.DriverIDs != nil && !slices.Contains(filter.DriverIDs, car.DriverID) {
+			continue
+		}
+		if filter.Brand != "" && !strings.EqualFold(car.Brand, filter.Brand) {
+			continue
+		}
+		if filter.Year != 0 && car.Year != filter.Year {
+			continue
+		}
+		cars = append(cars, car)
+	}
+	cars, next := paginate(cars, page, repository.CarSortKey, func(c models.Car) int { return c.ID })
+	return cars, next, nil
+}
+
+// Get returns the car with the given ID unless it is deleted.
+func (r *CarRepository) Get(ctx context.Context, id int) (models.Car, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	car, ok := r.cars[id]
+	if !ok || car.DeletedAt != nil {
+		return models.Car{}, repository.ErrNotFound
+	}
+	return car, nil
+}
+
+// GetIncludingDeleted returns the car with the given ID, deleted or not.
+func (r *CarRepository) GetIncludingDeleted(ctx context.Context, id int) (models.Car, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	car, ok := r.cars[id]
+	if !ok {
+		return models.Car{}, repository.ErrNotFound
+	}
+	return car, nil
+}
+
+// Create stores a new car and sets its ID.
+func (r *CarRepository) Create(ctx context.Context, car *models.Car) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(r.nextID, car); err != nil {
+		return err
+	}
+	car.ID = r.nextID
+	r.nextID++
+	saveEntry(ctx, &r.mu, r.cars, car.ID)
+	r.cars[car.ID] = *car
+	return nil
+}
+
+// Update overwrites the car identified by car.ID if it was last updated at updatedAt.
+// The stored creation timestamp is preserved.
+func (r *CarRepository) Update(ctx context.Context, car *models.Car, updatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.cars[car.ID]
+	if !ok || existing.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	if !existing.UpdatedAt.Equal(updatedAt) {
+		return repository.ErrConflict
+	}
+	if err := r.checkUnique(car.ID, car); err != nil {
+		return err
+	}
+	stored := *car
+	stored.CreatedAt = existing.CreatedAt
+	saveEntry(ctx, &r.mu, r.cars, car.ID)
+	r.cars[car.ID] = stored
+	return nil
+}
+
+// Delete marks the car with the given ID as deleted.
+func (r *CarRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	car, ok := r.cars[id]
+	if !ok || car.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	car.DeletedAt = &now
+	car.UpdatedAt = now
+	saveEntry(ctx, &r.mu, r.cars, id)
+	r.cars[id] = car
+	return nil
+}
+
+// Restore clears the deletion mark of the car with the given ID.
+func (r *CarRepository) Restore(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	car, ok := r.cars[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if car.DeletedAt == nil {
+		return repository.ErrConflict
+	}
+	if err := r.checkUnique(id, &car); err != nil {
+		return err
+	}
+	car.DeletedAt = nil
+	car.UpdatedAt = time.Now()
+	saveEntry(ctx, &r.mu, r.cars, id)
+	r.cars[id] = car
+	return nil
+}
+
+// Purge removes the cars deleted before the given time.
+// Unlike the PostgreSQL implementation it cannot see rides, so it keeps no cars for them.
+func (r *CarRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for id, car := range r.cars {
+		if car.DeletedAt != nil && car.DeletedAt.Before(before) {
+			saveEntry(ctx, &r.mu, r.cars, id)
+			delete(r.cars, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// checkUnique returns a *repository.DuplicateError if a live car other than the one with the given ID has the same license plate.
+// The caller must hold r.mu.
+func (r *CarRepository) checkUnique(id int, car *models.Car) error {
+	licensePlate := plateKey(car.LicensePlate)
+	for otherID, other := range r.cars {
+		if otherID == id || other.DeletedAt != nil {
+			continue
+		}
+		if plateKey(other.LicensePlate) == licensePlate {
+			return &repository.DuplicateError{Entity: "car", Field: "license_plate"}
+		}
+	}
+	return nil
+}
+
+// plateKey returns plate in the form license plates are compared in, as normalized by
+// validate.NormalizeLicensePlate, or plate as is if it is not a valid plate.
+func plateKey(plate string) string {
+	if normalized, ok := validate.NormalizeLicensePlate(plate); ok {
+		return normalized
+	}
+	return plate
+}
@@ -0,0 +1,211 @@
+// Package memory implements the repository interfaces with in-process maps.
+// It is intended for tests and local development where no PostgreSQL instance is available.
+// Data is lost when the process exits.
+package memory
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/hse-trpo-taxi/backend/validate"
+)
+
+// ClientRepository is an in-memory repository.ClientRepository. It is safe for concurrent use.
+type ClientRepository struct {
+	mu      sync.RWMutex
+	nextID  int
+	clients map[int]models.Client
+}
+
+// NewClientRepository returns an empty ClientRepository.
+func NewClientRepository() *ClientRepository {
+	return &ClientRepository{nextID: 1, clients: make(map[int]models.Client)}
+}
+
+// List returns the page of clients matching filter.
+func (r *ClientRepository) List(ctx context.Context, filter repository.ClientFilter, page repository.Page) ([]models.Client, *repository.Cursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clients := make([]models.Client, 0, len(r.clients))
+	for _, client := range r.clients {
+		if client.DeletedAt != nil && !filter.IncludeDeleted {
+			continue
+		}
+		if filter.Name != "" && !strings.Contains(strings.ToLower(client.Name), strings.ToLower(filter.Name)) {
+			continue
+		}
+		if filter.Phone != "" && phoneKey(client.Phone) != filter.Phone {
+			continue
+		}
+		clients = append(clients, client)
+	}
+	clients, next := paginate(clients, page, repository.ClientSortKey, func(c models.Client) int { return c.ID })
+	return clients, next, nil
+}
+
+// Get returns the client with the given ID unless it is deleted.
+func (r *ClientRepository) Get(ctx context.Context, id int) (models.Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	client, ok := r.clients[id]
+	if !ok || client.DeletedAt != nil {
+		return models.Client{}, repository.ErrNotFound
+	}
+	return client, nil
+}
+
+// GetIncludingDeleted returns the client with the given ID, deleted or not.
+func (r *ClientRepository) GetIncludingDeleted(ctx context.Context, id int) (models.Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return models.Client{}, repository.ErrNotFound
+	}
+	return client, nil
+}
+
+// GetByPhone returns the client with the lowest ID whose normalized phone number equals phone.
+func (r *ClientRepository) GetByPhone(ctx context.Context, phone string) (models.Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found models.Client
+	for _, client := range r.clients {
+		if client.DeletedAt == nil && phoneKey(client.Phone) == phone && (found.ID == 0 || client.ID < found.ID) {
+			found = client
+		}
+	}
+	if found.ID == 0 {
+		return found, repository.ErrNotFound
+	}
+	return found, nil
+}
+
+// Create stores a new client and sets its ID.
+func (r *ClientRepository) Create(ctx context.Context, client *models.Client) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(r.nextID, client); err != nil {
+		return err
+	}
+	client.ID = r.nextID
+	r.nextID++
+	saveEntry(ctx, &r.mu, r.clients, client.ID)
+	r.clients[client.ID] = *client
+	return nil
+}
+
+// Update overwrites the client identified by client.ID if it was last updated at updatedAt.
+// The stored creation timestamp is preserved.
+func (r *ClientRepository) Update(ctx context.Context, client *models.Client, updatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.clients[client.ID]
+	if !ok || existing.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	if !existing.UpdatedAt.Equal(updatedAt) {
+		return repository.ErrConflict
+	}
+	if err := r.checkUnique(client.ID, client); err != nil {
+		return err
+	}
+	stored := *client
+	stored.CreatedAt = existing.CreatedAt
+	saveEntry(ctx, &r.mu, r.clients, client.ID)
+	r.clients[client.ID] = stored
+	return nil
+}
+
+// Delete marks the client with the given ID as deleted.
+func (r *ClientRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[id]
+	if !ok || client.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	client.DeletedAt = &now
+	client.UpdatedAt = now
+	saveEntry(ctx, &r.mu, r.clients, id)
+	r.clients[id] = client
+	return nil
+}
+
+// Restore clears the deletion mark of the client with the given ID.
+func (r *ClientRepository) Restore(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if client.DeletedAt == nil {
+		return repository.ErrConflict
+	}
+	if err := r.checkUnique(id, &client); err != nil {
+		return err
+	}
+	client.DeletedAt = nil
+	client.UpdatedAt = time.Now()
+	saveEntry(ctx, &r.mu, r.clients, id)
+	r.clients[id] = client
+	return nil
+}
+
+// Purge removes the clients deleted before the given time.
+// Unlike the PostgreSQL implementation it cannot see rides, so it keeps no clients for them.
+func (r *ClientRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for id, client := range r.clients {
+		if client.DeletedAt != nil && client.DeletedAt.Before(before) {
+			saveEntry(ctx, &r.mu, r.clients, id)
+			delete(r.clients, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// checkUnique returns a *repository.DuplicateError if a live client other than the one with the given ID has the same phone number or email.
+// The caller must hold r.mu.
+func (r *ClientRepository) checkUnique(id int, client *models.Client) error {
+	phone, email := phoneKey(client.Phone), models.NormalizeEmail(client.Email)
+	for otherID, other := range r.clients {
+		if otherID == id || other.DeletedAt != nil {
+			continue
+		}
+		if phoneKey(other.Phone) == phone {
+			return &repository.DuplicateError{Entity: "client", Field: "phone"}
+		}
+		if email != "" && models.NormalizeEmail(other.Email) == email {
+			return &repository.DuplicateError{Entity: "client", Field: "email"}
+		}
+	}
+	return nil
+}
+
+// phoneKey returns phone in the form phone numbers are compared in, as normalized by
+// validate.NormalizePhone, or phone as is if it is not a valid number.
+func phoneKey(phone string) string {
+	if normalized, ok := validate.NormalizePhone(phone); ok {
+		return normalized
+	}
+	return phone
+}
@@ -0,0 +1,236 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// DriverRepository is an in-memory repository.DriverRepository. It is safe for concurrent use.
+type DriverRepository struct {
+	mu      sync.RWMutex
+	nextID  int
+	drivers map[int]models.Driver
+}
+
+// NewDriverRepository returns an empty DriverRepository.
+func NewDriverRepository() *DriverRepository {
+	return &DriverRepository{nextID: 1, drivers: make(map[int]models.Driver)}
+}
+
+// List returns the page of drivers matching filter.
+func (r *DriverRepository) List(ctx context.Context, filter repository.DriverFilter, page repository.Page) ([]models.Driver, *repository.Cursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	drivers := make([]models.Driver, 0, len(r.drivers))
+	for _, driver := range r.drivers {
+		if driver.DeletedAt != nil && !filter.IncludeDeleted {
+			continue
+		}
+		if filter.Status != "" && driver.Status != filter.Status {
+			continue
+		}
+		if driver.Rating < filter.MinRating {
+			continue
+		}
+		if filter.IDs != nil && !slices.Contains(filter.IDs, driver.ID) {
+			continue
+		}
+		drivers = append(drivers, driver)
+	}
+	drivers, next := paginate(drivers, page, repository.DriverSortKey, func(d models.Driver) int { return d.ID })
+	return drivers, next, nil
+}
+
+// Get returns the driver with the given ID unless it is deleted.
+func (r *DriverRepository) Get(ctx context.Context, id int) (models.Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	driver, ok := r.drivers[id]
+	if !ok || driver.DeletedAt != nil {
+		return models.Driver{}, repository.ErrNotFound
+	}
+	return driver, nil
+}
+
+// GetIncludingDeleted returns the driver with the given ID, deleted or not.
+func (r *DriverRepository) GetIncludingDeleted(ctx context.Context, id int) (models.Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	driver, ok := r.drivers[id]
+	if !ok {
+		return models.Driver{}, repository.ErrNotFound
+	}
+	return driver, nil
+}
+
+// GetByPhone returns the driver with the lowest ID whose normalized phone number equals phone.
+func (r *DriverRepository) GetByPhone(ctx context.Context, phone string) (models.Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found models.Driver
+	for _, driver := range r.drivers {
+		if driver.DeletedAt == nil && phoneKey(driver.Phone) == phone && (found.ID == 0 || driver.ID < found.ID) {
+			found = driver
+		}
+	}
+	if found.ID == 0 {
+		return found, repository.ErrNotFound
+	}
+	return found, nil
+}
+
+// Create stores a new driver and sets its ID.
+func (r *DriverRepository) Create(ctx context.Context, driver *models.Driver) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(r.nextID, driver); err != nil {
+		return err
+	}
+	driver.ID = r.nextID
+	r.nextID++
+	saveEntry(ctx, &r.mu, r.drivers, driver.ID)
+	r.drivers[driver.ID] = *driver
+	return nil
+}
+
+// Update overwrites the profile of the driver identified by driver.ID if it was last updated at updatedAt.
+// The stored creation timestamp and status are preserved, and the status is copied into driver.Status.
+func (r *DriverRepository) Update(ctx context.Context, driver *models.Driver, updatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.drivers[driver.ID]
+	if !ok || existing.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	if !existing.UpdatedAt.Equal(updatedAt) {
+		return repository.ErrConflict
+	}
+	if err := r.checkUnique(driver.ID, driver); err != nil {
+		return err
+	}
+	driver.Status = existing.Status
+	stored := *driver
+	stored.CreatedAt = existing.CreatedAt
+	saveEntry(ctx, &r.mu, r.drivers, driver.ID)
+	r.drivers[driver.ID] = stored
+	return nil
+}
+
+// Delete marks the driver with the given ID as deleted.
+func (r *DriverRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	driver, ok := r.drivers[id]
+	if !ok || driver.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	driver.DeletedAt = &now
+	driver.UpdatedAt = now
+	saveEntry(ctx, &r.mu, r.drivers, id)
+	r.drivers[id] = driver
+	return nil
+}
+
+// Restore clears the deletion mark of the driver with the given ID.
+func (r *DriverRepository) Restore(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	driver, ok := r.drivers[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if driver.DeletedAt == nil {
+		return repository.ErrConflict
+	}
+	if err := r.checkUnique(id, &driver); err != nil {
+		return err
+	}
+	driver.DeletedAt = nil
+	driver.UpdatedAt = time.Now()
+	saveEntry(ctx, &r.mu, r.drivers, id)
+	r.drivers[id] = driver
+	return nil
+}
+
+// Purge removes the drivers deleted before the given time.
+// Unlike the PostgreSQL implementation it cannot see rides, so it keeps no drivers for them.
+func (r *DriverRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for id, driver := range r.drivers {
+		if driver.DeletedAt != nil && driver.DeletedAt.Before(before) {
+			saveEntry(ctx, &r.mu, r.drivers, id)
+			delete(r.drivers, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// SetStatus changes the status of the driver with the given ID from from to to.
+// A deleted driver is reported as missing.
+func (r *DriverRepository) SetStatus(ctx context.Context, id int, from, to models.DriverStatus, updatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	driver, ok := r.drivers[id]
+	if !ok || driver.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	if driver.Status != from {
+		return repository.ErrConflict
+	}
+	driver.Status = to
+	driver.UpdatedAt = updatedAt
+	saveEntry(ctx, &r.mu, r.drivers, id)
+	r.drivers[id] = driver
+	return nil
+}
+
+// CountByStatus returns the number of live drivers in each status.
+func (r *DriverRepository) CountByStatus(ctx context.Context) (map[models.DriverStatus]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[models.DriverStatus]int)
+	for _, driver := range r.drivers {
+		if driver.DeletedAt == nil {
+			counts[driver.Status]++
+		}
+	}
+	return counts, nil
+}
+
+// checkUnique returns a *repository.DuplicateError if a live driver other than the one with the given ID has the same phone number or license number.
+// The caller must hold r.mu.
+func (r *DriverRepository) checkUnique(id int, driver *models.Driver) error {
+	phone, licenseNumber := phoneKey(driver.Phone), models.NormalizeLicenseNumber(driver.LicenseNumber)
+	for otherID, other := range r.drivers {
+		if otherID == id || other.DeletedAt != nil {
+			continue
+		}
+		if phoneKey(other.Phone) == phone {
+			return &repository.DuplicateError{Entity: "driver", Field: "phone"}
+		}
+		if models.NormalizeLicenseNumber(other.LicenseNumber) == licenseNumber {
+			return &repository.DuplicateError{Entity: "driver", Field: "license_number"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/geo"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// locationCellSize is the grid cell size of the location index in degrees (about 1.1 km of latitude).
+const locationCellSize = 0.01
+
+// LocationRepository is an in-memory repository.LocationRepository backed by a geo.Index grid.
+// It is safe for concurrent use.
+type LocationRepository struct {
+	mu        sync.RWMutex
+	locations map[int]models.DriverLocation
+	index     *geo.Index
+}
+
+// NewLocationRepository returns an empty LocationRepository.
+func NewLocationRepository() *LocationRepository {
+	return &LocationRepository{
+		locations: make(map[int]models.DriverLocation),
+		index:     geo.NewIndex(locationCellSize),
+	}
+}
+
+// Save records loc as the driver's latest position unless a newer position is already stored.
+func (r *LocationRepository) Save(ctx context.Context, loc models.DriverLocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.locations[loc.DriverID]; ok && existing.RecordedAt.After(loc.RecordedAt) {
+		return nil
+	}
+	r.saveForRollback(ctx, loc.DriverID)
+	r.locations[loc.DriverID] = loc
+	r.index.Set(loc.DriverID, geo.Point{Lat: loc.Lat, Lon: loc.Lon})
+	return nil
+}
+
+// Get returns the latest position of the given driver.
+func (r *LocationRepository) Get(ctx context.Context, driverID int) (models.DriverLocation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	loc, ok := r.locations[driverID]
+	if !ok {
+		return models.DriverLocation{}, repository.ErrNotFound
+	}
+	return loc, nil
+}
+
+// Nearby returns the positions matching query ordered by distance, nearest first.
+func (r *LocationRepository) Nearby(ctx context.Context, query repository.NearbyQuery) ([]repository.NearbyLocation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []repository.NearbyLocation{}
+	for _, match := range r.index.Within(geo.Point{Lat: query.Lat, Lon: query.Lon}, query.Radius) {
+		loc := r.locations[match.ID]
+		if loc.RecordedAt.Before(query.Since) {
+			continue
+		}
+		result = append(result, repository.NearbyLocation{DriverLocation: loc, Distance: match.Distance})
+	}
+	return result, nil
+}
+
+// Recent returns every position recorded at or after since, ordered by driver ID.
+func (r *LocationRepository) Recent(ctx context.Context, since time.Time) ([]models.DriverLocation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []models.DriverLocation{}
+	for _, loc := range r.locations {
+		if !loc.RecordedAt.Before(since) {
+			result = append(result, loc)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].DriverID < result[j].DriverID })
+	return result, nil
+}
+
+// saveForRollback registers the restoration of the driver's current position, in the map and
+// in the index, on rollback of the transaction of ctx. The caller must hold the write lock.
+func (r *LocationRepository) saveForRollback(ctx context.Context, driverID int) {
+	old, ok := r.locations[driverID]
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if ok {
+			r.locations[driverID] = old
+			r.index.Set(driverID, geo.Point{Lat: old.Lat, Lon: old.Lon})
+		} else {
+			delete(r.locations, driverID)
+			r.index.Remove(driverID)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// otpKey identifies a code by phone number and role.
+type otpKey struct {
+	phone, role string
+}
+
+// OTPRepository is an in-memory repository.OTPRepository. It is safe for concurrent use.
+type OTPRepository struct {
+	mu    sync.Mutex
+	codes map[otpKey]models.OTPCode
+}
+
+// NewOTPRepository returns an empty OTPRepository.
+func NewOTPRepository() *OTPRepository {
+	return &OTPRepository{codes: make(map[otpKey]models.OTPCode)}
+}
+
+// Get returns the code for phone and role.
+func (r *OTPRepository) Get(ctx context.Context, phone, role string) (models.OTPCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[otpKey{phone, role}]
+	if !ok {
+		return models.OTPCode{}, repository.ErrNotFound
+	}
+	return code, nil
+}
+
+// Save stores code, replacing any previous code for the same phone and role.
+func (r *OTPRepository) Save(ctx context.Context, code models.OTPCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saveEntry(ctx, &r.mu, r.codes, otpKey{code.Phone, code.Role})
+	r.codes[otpKey{code.Phone, code.Role}] = code
+	return nil
+}
+
+// AddAttempt increments the attempt counter of the code for phone and role and returns the new count.
+func (r *OTPRepository) AddAttempt(ctx context.Context, phone, role string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[otpKey{phone, role}]
+	if !ok {
+		return 0, repository.ErrNotFound
+	}
+	code.Attempts++
+	saveEntry(ctx, &r.mu, r.codes, otpKey{phone, role})
+	r.codes[otpKey{phone, role}] = code
+	return code.Attempts, nil
+}
+
+// Delete removes the code for phone and role.
+func (r *OTPRepository) Delete(ctx context.Context, phone, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.codes[otpKey{phone, role}]; !ok {
+		return repository.ErrNotFound
+	}
+	saveEntry(ctx, &r.mu, r.codes, otpKey{phone, role})
+	delete(r.codes, otpKey{phone, role})
+	return nil
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"cmp"
+	"sort"
+
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// paginate sorts items in page order, drops those up to and including page.After,
+// and returns the page together with the cursor of the next one.
+func paginate[T any](items []T, page repository.Page, key func(T, string) any, id func(T) int) ([]T, *repository.Cursor) {
+	field := page.Field()
+	order := func(a, b T) int {
+		c := repository.CompareSortValues(key(a, field), key(b, field))
+		if c == 0 {
+			c = cmp.Compare(id(a), id(b))
+		}
+		if page.Desc {
+			c = -c
+		}
+		return c
+	}
+	sort.Slice(items, func(i, j int) bool { return order(items[i], items[j]) < 0 })
+
+	if page.After != nil {
+		start := sort.Search(len(items), func(i int) bool {
+			c := repository.CompareSortValues(key(items[i], field), page.After.Value)
+			if c == 0 {
+				c = cmp.Compare(id(items[i]), page.After.ID)
+			}
+			if page.Desc {
+				c = -c
+			}
+			return c > 0
+		})
+		items = items[start:]
+	}
+	if page.Limit > 0 && len(items) > page.Limit+1 {
+		items = items[:page.Limit+1]
+	}
+	return repository.NextPage(items, page, key, id)
+}
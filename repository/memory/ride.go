@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// RideRepository is an in-memory repository.RideRepository. It is safe for concurrent use.
+type RideRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	rides  map[int]models.Ride
+}
+
+// NewRideRepository returns an empty RideRepository.
+func NewRideRepository() *RideRepository {
+	return &RideRepository{nextID: 1, rides: make(map[int]models.Ride)}
+}
+
+// List returns the page of rides matching filter.
+func (r *RideRepository) List(ctx context.Context, filter repository.RideFilter, page repository.Page) ([]models.Ride, *repository.Cursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rides := make([]models.Ride, 0, len(r.rides))
+	for _, ride := range r.rides {
+		if filter.Status != "" && ride.Status != filter.Status {
+			continue
+		}
+		rides = append(rides, ride)
+	}
+	rides, next := paginate(rides, page, repository.RideSortKey, func(r models.Ride) int { return r.ID })
+	return rides, next, nil
+}
+
+// Requested returns the rides still waiting for a driver that were requested at or after since.
+func (r *RideRepository) Requested(ctx context.Context, since time.Time) ([]models.Ride, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rides := []models.Ride{}
+	for _, ride := range r.rides {
+		if ride.Status == models.RideRequested && !ride.RequestedAt.Before(since) {
+			rides = append(rides, ride)
+		}
+	}
+	sort.Slice(rides, func(i, j int) bool { return rides[i].ID < rides[j].ID })
+	return rides, nil
+}
+
+// Get returns the ride with the given ID.
+func (r *RideRepository) Get(ctx context.Context, id int) (models.Ride, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ride, ok := r.rides[id]
+	if !ok {
+		return models.Ride{}, repository.ErrNotFound
+	}
+	return ride, nil
+}
+
+// Create stores a new ride and sets its ID.
+func (r *RideRepository) Create(ctx context.Context, ride *models.Ride) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ride.ID = r.nextID
+	r.nextID++
+	saveEntry(ctx, &r.mu, r.rides, ride.ID)
+	r.rides[ride.ID] = *ride
+	return nil
+}
+
+// Transition overwrites the ride identified by ride.ID if its stored status is still from.
+func (r *RideRepository) Transition(ctx context.Context, ride *models.Ride, from models.RideStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.rides[ride.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if existing.Status != from {
+		return repository.ErrConflict
+	}
+	saveEntry(ctx, &r.mu, r.rides, ride.ID)
+	r.rides[ride.ID] = *ride
+	return nil
+}
+
+// CountByStatus returns the number of rides in each of statuses.
+func (r *RideRepository) CountByStatus(ctx context.Context, statuses ...models.RideStatus) (map[models.RideStatus]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[models.RideStatus]int)
+	for _, ride := range r.rides {
+		if slices.Contains(statuses, ride.Status) {
+			counts[ride.Status]++
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// ShiftRepository is an in-memory repository.ShiftRepository. It is safe for concurrent use.
+type ShiftRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	shifts map[int]models.Shift
+}
+
+// NewShiftRepository returns an empty ShiftRepository.
+func NewShiftRepository() *ShiftRepository {
+	return &ShiftRepository{nextID: 1, shifts: make(map[int]models.Shift)}
+}
+
+// Open stores a new open shift and sets its ID.
+func (r *ShiftRepository) Open(ctx context.Context, shift *models.Shift) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.shifts {
+		if existing.DriverID == shift.DriverID && existing.EndedAt == nil {
+			return repository.ErrConflict
+		}
+	}
+	shift.ID = r.nextID
+	shift.EndedAt = nil
+	r.nextID++
+	saveEntry(ctx, &r.mu, r.shifts, shift.ID)
+	r.shifts[shift.ID] = *shift
+	return nil
+}
+
+// Close ends the open shift of the given driver at endedAt and returns it.
+func (r *ShiftRepository) Close(ctx context.Context, driverID int, endedAt time.Time) (models.Shift, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, shift := range r.shifts {
+		if shift.DriverID == driverID && shift.EndedAt == nil {
+			shift.EndedAt = &endedAt
+			saveEntry(ctx, &r.mu, r.shifts, id)
+			r.shifts[id] = shift
+			return shift, nil
+		}
+	}
+	return models.Shift{}, repository.ErrNotFound
+}
+
+// ListByDriver returns the page of the driver's shifts overlapping the filter interval.
+func (r *ShiftRepository) ListByDriver(ctx context.Context, driverID int, filter repository.ShiftFilter, page repository.Page) ([]models.Shift, *repository.Cursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	shifts := []models.Shift{}
+	for _, shift := range r.shifts {
+		if shift.DriverID != driverID {
+			continue
+		}
+		if !filter.From.IsZero() && shift.EndedAt != nil && !shift.EndedAt.After(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !shift.StartedAt.Before(filter.To) {
+			continue
+		}
+		shifts = append(shifts, shift)
+	}
+	shifts, next := paginate(shifts, page, repository.ShiftSortKey, func(s models.Shift) int { return s.ID })
+	return shifts, next, nil
+}
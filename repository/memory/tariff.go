@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// TariffRepository is an in-memory repository.TariffRepository. It is safe for concurrent use.
+// It does not know about rides, so Delete never reports a conflict.
+type TariffRepository struct {
+	mu      sync.RWMutex
+	nextID  int
+	tariffs map[int]models.Tariff
+}
+
+// NewTariffRepository returns an empty TariffRepository.
+func NewTariffRepository() *TariffRepository {
+	return &TariffRepository{nextID: 1, tariffs: make(map[int]models.Tariff)}
+}
+
+// List returns a page of tariffs.
+func (r *TariffRepository) List(ctx context.Context, page repository.Page) ([]models.Tariff, *repository.Cursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tariffs := make([]models.Tariff, 0, len(r.tariffs))
+	for _, tariff := range r.tariffs {
+		tariffs = append(tariffs, tariff)
+	}
+	tariffs, next := paginate(tariffs, page, repository.TariffSortKey, func(t models.Tariff) int { return t.ID })
+	return tariffs, next, nil
+}
+
+// Get returns the tariff with the given ID.
+func (r *TariffRepository) Get(ctx context.Context, id int) (models.Tariff, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tariff, ok := r.tariffs[id]
+	if !ok {
+		return models.Tariff{}, repository.ErrNotFound
+	}
+	return tariff, nil
+}
+
+// Default returns the tariff marked as default.
+func (r *TariffRepository) Default(ctx context.Context) (models.Tariff, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, tariff := range r.tariffs {
+		if tariff.Default {
+			return tariff, nil
+		}
+	}
+	return models.Tariff{}, repository.ErrNotFound
+}
+
+// Create stores a new tariff and sets its ID.
+func (r *TariffRepository) Create(ctx context.Context, tariff *models.Tariff) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tariff.ID = r.nextID
+	r.nextID++
+	r.store(ctx, *tariff)
+	return nil
+}
+
+// Update overwrites the tariff identified by tariff.ID.
+// The stored creation timestamp is preserved.
+func (r *TariffRepository) Update(ctx context.Context, tariff *models.Tariff) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.tariffs[tariff.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored := *tariff
+	stored.CreatedAt = existing.CreatedAt
+	r.store(ctx, stored)
+	return nil
+}
+
+// Delete removes the tariff with the given ID.
+func (r *TariffRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tariffs[id]; !ok {
+		return repository.ErrNotFound
+	}
+	saveEntry(ctx, &r.mu, r.tariffs, id)
+	delete(r.tariffs, id)
+	return nil
+}
+
+// store saves a copy of tariff, clearing the default flag on the others if it is the default.
+// The caller must hold the write lock.
+func (r *TariffRepository) store(ctx context.Context, tariff models.Tariff) {
+	if tariff.Default {
+		for id, other := range r.tariffs {
+			if other.Default && id != tariff.ID {
+				saveEntry(ctx, &r.mu, r.tariffs, id)
+				other.Default = false
+				r.tariffs[id] = other
+			}
+		}
+	}
+	tariff.Holidays = slices.Clone(tariff.Holidays)
+	saveEntry(ctx, &r.mu, r.tariffs, tariff.ID)
+	r.tariffs[tariff.ID] = tariff
+}
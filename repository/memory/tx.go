@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+// Transactor is an in-memory repository.Transactor. The memory repositories apply every
+// write immediately and, inside InTx, remember how to undo it, so a failed fn leaves the
+// stored data as it was. Unlike a database transaction it does not isolate concurrent
+// callers: other goroutines see the writes of fn before it returns.
+type Transactor struct{}
+
+// NewTransactor returns a Transactor.
+func NewTransactor() *Transactor {
+	return &Transactor{}
+}
+
+// txKey is the context key of the undo log of the current transaction.
+type txKey struct{}
+
+// undoLog collects the steps that revert the writes made in a transaction.
+type undoLog struct {
+	mu    sync.Mutex
+	steps []func()
+}
+
+// InTx calls fn with a context carrying a new undo log and reverts the writes made
+// through it if fn returns an error. A nested call joins the outer transaction.
+func (t *Transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*undoLog); ok {
+		return fn(ctx)
+	}
+	log := &undoLog{}
+	if err := fn(context.WithValue(ctx, txKey{}, log)); err != nil {
+		log.mu.Lock()
+		defer log.mu.Unlock()
+		for i := len(log.steps) - 1; i >= 0; i-- {
+			log.steps[i]()
+		}
+		return err
+	}
+	return nil
+}
+
+// onRollback registers undo to be called if the transaction of ctx is rolled back.
+// Outside a transaction it does nothing.
+func onRollback(ctx context.Context, undo func()) {
+	if log, ok := ctx.Value(txKey{}).(*undoLog); ok {
+		log.mu.Lock()
+		log.steps = append(log.steps, undo)
+		log.mu.Unlock()
+	}
+}
+
+// saveEntry registers the restoration of m[key] to its current value, or its removal if
+// it is not set, on rollback of the transaction of ctx. It must be called with mu held and
+// before m[key] is changed; the restoration takes mu itself.
+func saveEntry[K comparable, V any](ctx context.Context, mu sync.Locker, m map[K]V, key K) {
+	old, ok := m[key]
+	onRollback(ctx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if ok {
+			m[key] = old
+		} else {
+			delete(m, key)
+		}
+	})
+}
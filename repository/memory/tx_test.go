@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+func TestInTxRollback(t *testing.T) {
+	ctx := context.Background()
+	tx := NewTransactor()
+	drivers, events := NewDriverRepository(), NewAuditRepository()
+	driver := models.Driver{Name: "Ivan", Phone: "+79990000001", LicenseNumber: "77AB123456", Status: models.DriverOffline}
+	if err := drivers.Create(ctx, &driver); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("failed")
+	err := tx.InTx(ctx, func(ctx context.Context) error {
+		if err := drivers.SetStatus(ctx, driver.ID, models.DriverOffline, models.DriverAvailable, time.Now()); err != nil {
+			return err
+		}
+		other := models.Driver{Name: "Petr", Phone: "+79990000002", LicenseNumber: "77AB654321"}
+		if err := drivers.Create(ctx, &other); err != nil {
+			return err
+		}
+		return tx.InTx(ctx, func(ctx context.Context) error {
+			if err := events.Record(ctx, &models.AuditEvent{EntityType: "driver", EntityID: driver.ID, Action: models.AuditUpdate}); err != nil {
+				return err
+			}
+			return failed
+		})
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("InTx = %v, want %v", err, failed)
+	}
+
+	stored, err := drivers.Get(ctx, driver.ID)
+	if err != nil || stored.Status != models.DriverOffline {
+		t.Errorf("driver after rollback = %+v, %v, want offline", stored, err)
+	}
+	if list, _, _ := drivers.List(ctx, repository.DriverFilter{}, repository.Page{}); len(list) != 1 {
+		t.Errorf("drivers after rollback = %+v, want only driver %d", list, driver.ID)
+	}
+	if list, _, _ := events.List(ctx, repository.AuditFilter{}, repository.Page{}); len(list) != 0 {
+		t.Errorf("audit events after rollback = %+v, want none", list)
+	}
+
+	if err := tx.InTx(ctx, func(ctx context.Context) error {
+		return drivers.SetStatus(ctx, driver.ID, models.DriverOffline, models.DriverAvailable, time.Now())
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := drivers.Get(ctx, driver.ID); stored.Status != models.DriverAvailable {
+		t.Errorf("driver after commit = %+v, want available", stored)
+	}
+}
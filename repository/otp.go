@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// OTPRepository stores the latest one-time login code per phone number and role.
+type OTPRepository interface {
+	// Get returns the code for phone and role or ErrNotFound.
+	Get(ctx context.Context, phone, role string) (models.OTPCode, error)
+	// Save stores code, replacing any previous code for the same phone and role.
+	Save(ctx context.Context, code models.OTPCode) error
+	// AddAttempt atomically increments the attempt counter of the code for phone and role
+	// and returns the new count, or ErrNotFound.
+	AddAttempt(ctx context.Context, phone, role string) (int, error)
+	// Delete removes the code for phone and role or returns ErrNotFound.
+	Delete(ctx context.Context, phone, role string) error
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// SortKind is the type of a sortable field. It determines how the field's values are
+// compared and how they are written in a Cursor.
+type SortKind int
+
+// Kinds of sortable fields.
+const (
+	SortInt SortKind = iota
+	SortFloat
+	SortString
+	SortTime
+)
+
+// Format returns the text form of v, which must be of the Go type matching k
+// (int, float64, string or time.Time).
+func (k SortKind) Format(v any) string {
+	switch v := v.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+// Parse is the inverse of Format.
+func (k SortKind) Parse(s string) (any, error) {
+	switch k {
+	case SortInt:
+		return strconv.Atoi(s)
+	case SortFloat:
+		return strconv.ParseFloat(s, 64)
+	case SortTime:
+		return time.Parse(time.RFC3339Nano, s)
+	}
+	return s, nil
+}
+
+// SortFields maps the names of the fields a list can be sorted by to their kinds.
+// The names double as column names in the PostgreSQL implementation, so only
+// whitelisted names ever reach SQL.
+type SortFields map[string]SortKind
+
+// Sortable fields of each list. Every list can be sorted by "id", the default.
+var (
+	ClientSortFields = SortFields{"id": SortInt, "name": SortString, "created_at": SortTime}
+	DriverSortFields = SortFields{"id": SortInt, "name": SortString, "rating": SortFloat, "created_at": SortTime}
+	CarSortFields    = SortFields{"id": SortInt, "brand": SortString, "year": SortInt, "created_at": SortTime}
+	AuditSortFields  = SortFields{"id": SortInt, "created_at": SortTime}
+	RideSortFields   = SortFields{"id": SortInt, "requested_at": SortTime}
+	TariffSortFields = SortFields{"id": SortInt, "name": SortString}
+	ShiftSortFields  = SortFields{"id": SortInt, "started_at": SortTime}
+)
+
+// Page selects a window of a sorted list using keyset pagination: items are ordered by
+// the sort field with the ID as a tie-breaker, and a page starts right after the item
+// its Cursor points to. Unlike offsets, cursors stay valid when items are inserted or
+// deleted between requests and cost the same however deep the page is.
+type Page struct {
+	// Limit is the maximum number of items returned; 0 returns all of them
+	Limit int
+	// Sort is the name of the field to order by; empty means "id"
+	Sort string
+	// Desc orders the items from the highest value to the lowest
+	Desc bool
+	// After is the position of the last item of the previous page, or nil for the first page
+	After *Cursor
+}
+
+// Field returns the sort field name, defaulting to "id".
+func (p Page) Field() string {
+	if p.Sort == "" {
+		return "id"
+	}
+	return p.Sort
+}
+
+// Cursor is the position of an item in a list sorted by some field.
+type Cursor struct {
+	// Value is the item's value of the sort field, of the Go type matching its SortKind
+	Value any
+	// ID is the item's ID
+	ID int
+}
+
+// ClientSortKey returns the value of the sort field of client.
+func ClientSortKey(client models.Client, field string) any {
+	switch field {
+	case "name":
+		return client.Name
+	case "created_at":
+		return client.CreatedAt
+	}
+	return client.ID
+}
+
+// DriverSortKey returns the value of the sort field of driver.
+func DriverSortKey(driver models.Driver, field string) any {
+	switch field {
+	case "name":
+		return driver.Name
+	case "rating":
+		return driver.Rating
+	case "created_at":
+		return driver.CreatedAt
+	}
+	return driver.ID
+}
+
+// CarSortKey returns the value of the sort field of car.
+func CarSortKey(car models.Car, field string) any {
+	switch field {
+	case "brand":
+		return car.Brand
+	case "year":
+		return car.Year
+	case "created_at":
+		return car.CreatedAt
+	}
+	return car.ID
+}
+
+// AuditSortKey returns the value of the sort field of event.
+func AuditSortKey(event models.AuditEvent, field string) any {
+	if field == "created_at" {
+		return event.CreatedAt
+	}
+	return event.ID
+}
+
+// RideSortKey returns the value of the sort field of ride.
+func RideSortKey(ride models.Ride, field string) any {
+	if field == "requested_at" {
+		return ride.RequestedAt
+	}
+	return ride.ID
+}
+
+// TariffSortKey returns the value of the sort field of tariff.
+func TariffSortKey(tariff models.Tariff, field string) any {
+	if field == "name" {
+		return tariff.Name
+	}
+	return tariff.ID
+}
+
+// ShiftSortKey returns the value of the sort field of shift.
+func ShiftSortKey(shift models.Shift, field string) any {
+	if field == "started_at" {
+		return shift.StartedAt
+	}
+	return shift.ID
+}
+
+// CompareSortValues compares two values of the same sort field, returning -1, 0 or +1.
+// Strings compare byte-wise, which may differ from the collation PostgreSQL sorts them by.
+func CompareSortValues(a, b any) int {
+	switch a := a.(type) {
+	case int:
+		return cmp.Compare(a, b.(int))
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case string:
+		return cmp.Compare(a, b.(string))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+// NextPage cuts items, fetched in page order with one item beyond page.Limit if there is one,
+// down to page.Limit and returns the cursor of the next page, or nil if items was the last page.
+// key and id return the sort field value and the ID of an item.
+func NextPage[T any](items []T, page Page, key func(T, string) any, id func(T) int) ([]T, *Cursor) {
+	if page.Limit <= 0 || len(items) <= page.Limit {
+		return items, nil
+	}
+	items = items[:page.Limit]
+	last := items[len(items)-1]
+	return items, &Cursor{Value: key(last, page.Field()), ID: id(last)}
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// auditColumns is the column list shared by all audit event SELECT statements, in scanAuditEvent order.
+const auditColumns = "id, actor_id, actor_role, entity_type, entity_id, action, before, after, request_id, created_at"
+
+// scanAuditEvent reads a row selected with auditColumns into an AuditEvent.
+func scanAuditEvent(row interface{ Scan(...any) error }) (models.AuditEvent, error) {
+	var event models.AuditEvent
+	err := row.Scan(&event.ID, &event.ActorID, &event.ActorRole, &event.EntityType, &event.EntityID, &event.Action,
+		&event.Before, &event.After, &event.RequestID, &event.CreatedAt)
+	return event, err
+}
+
+// AuditRepository is a PostgreSQL-backed repository.AuditRepository.
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository returns an AuditRepository that stores events in the audit_events table.
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Record inserts a new event and sets its ID.
+func (r *AuditRepository) Record(ctx context.Context, event *models.AuditEvent) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, "INSERT INTO audit_events (actor_id, actor_role, entity_type, entity_id, action, before, after, request_id, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id",
+		event.ActorID, event.ActorRole, event.EntityType, event.EntityID, event.Action, jsonb(event.Before), jsonb(event.After), event.RequestID, event.CreatedAt).Scan(&event.ID)
+}
+
+// List returns the page of events matching filter.
+func (r *AuditRepository) List(ctx context.Context, filter repository.AuditFilter, page repository.Page) ([]models.AuditEvent, *repository.Cursor, error) {
+	query, args, err := paginate("SELECT "+auditColumns+" FROM audit_events WHERE ($1 = '' OR entity_type = $1) AND ($2 = 0 OR entity_id = $2)",
+		[]any{filter.EntityType, filter.EntityID}, page, repository.AuditSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	events, next := repository.NextPage(events, page, repository.AuditSortKey, func(e models.AuditEvent) int { return e.ID })
+	return events, next, nil
+}
+
+// jsonb converts raw to a JSONB parameter. lib/pq would send a byte slice as bytea,
+// so it is passed as a string, and a missing document as NULL.
+func jsonb(raw json.RawMessage) any {
+	if raw == nil {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/lib/pq"
+)
+
+// carColumns is the column list shared by all car SELECT statements, in scanCar order.
+const carColumns = "id, driver_id, brand, model, year, license_plate, color, created_at, updated_at, deleted_at"
+
+// scanCar reads a row selected with carColumns into a Car.
+func scanCar(row interface{ Scan(...any) error }) (models.Car, error) {
+	var car models.Car
+	err := row.Scan(&car.ID, &car.DriverID, &car.Brand, &car.Model, &car.Year, &car.LicensePlate, &car.Color, &car.CreatedAt, &car.UpdatedAt, &car.DeletedAt)
+	return car, err
+}
+
+// CarRepository is a PostgreSQL-backed repository.CarRepository.
+type CarRepository struct {
+	db *sql.DB
+}
+
+// NewCarRepository returns a CarRepository that stores cars in the cars table.
+func NewCarRepository(db *sql.DB) *CarRepository {
+	return &CarRepository{db: db}
+}
+
+// List returns the page of cars matching filter.
+func (r *CarRepository) List(ctx context.Context, filter repository.CarFilter, page repository.Page) ([]models.Car, *repository.Cursor, error) {
+	query, args, err := paginate("SELECT "+carColumns+" FROM cars WHERE ($1 = 0 OR driver_id = $1) AND ($2 = '' OR lower(brand) = lower($2)) AND ($3 = 0 OR year = $3) AND ($4 OR deleted_at IS NULL) AND ($5::int[] IS NULL OR driver_id = ANY($5))",
+		[]any{filter.DriverID, filter.Brand, filter.Year, filter.IncludeDeleted, pq.Array(filter.DriverIDs)}, page, repository.CarSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	cars := []models.Car{}
+	for rows.Next() {
+		car, err := scanCar(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		cars = append(cars, car)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	cars, next := repository.NextPage(cars, page, repository.CarSortKey, func(c models.Car) int { return c.ID })
+	return cars, next, nil
+}
+
+// Get returns the car with the given ID unless it is deleted.
+func (r *CarRepository) Get(ctx context.Context, id int) (models.Car, error) {
+	car, err := scanCar(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+carColumns+" FROM cars WHERE id = $1 AND deleted_at IS NULL", id))
+	return car, notFound(err)
+}
+
+// GetIncludingDeleted returns the car with the given ID, deleted or not.
+func (r *CarRepository) GetIncludingDeleted(ctx context.Context, id int) (models.Car, error) {
+	car, err := scanCar(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+carColumns+" FROM cars WHERE id = $1", id))
+	return car, notFound(err)
+}
+
+// Create inserts a new car and sets its ID.
+// The insert fails if car.DriverID does not reference an existing driver.
+func (r *CarRepository) Create(ctx context.Context, car *models.Car) error {
+	return duplicate(conn(ctx, r.db).QueryRowContext(ctx, "INSERT INTO cars (driver_id, brand, model, year, license_plate, color, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		car.DriverID, car.Brand, car.Model, car.Year, car.LicensePlate, car.Color, car.CreatedAt, car.UpdatedAt).Scan(&car.ID))
+}
+
+// Update overwrites the car identified by car.ID if it was last updated at updatedAt.
+func (r *CarRepository) Update(ctx context.Context, car *models.Car, updatedAt time.Time) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE cars SET driver_id = $1, brand = $2, model = $3, year = $4, license_plate = $5, color = $6, updated_at = $7 WHERE id = $8 AND updated_at = $9 AND deleted_at IS NULL",
+		car.DriverID, car.Brand, car.Model, car.Year, car.LicensePlate, car.Color, car.UpdatedAt, car.ID, updatedAt)
+	if err != nil {
+		return duplicate(err)
+	}
+	if err := checkAffected(result); err != repository.ErrNotFound {
+		return err
+	}
+	return liveOrConflict(ctx, conn(ctx, r.db), "cars", car.ID)
+}
+
+// Delete marks the car with the given ID as deleted.
+func (r *CarRepository) Delete(ctx context.Context, id int) error {
+	return softDelete(ctx, conn(ctx, r.db), "cars", id)
+}
+
+// Restore clears the deletion mark of the car with the given ID.
+func (r *CarRepository) Restore(ctx context.Context, id int) error {
+	return restore(ctx, conn(ctx, r.db), "cars", id)
+}
+
+// Purge removes the cars deleted before the given time that no ride refers to.
+func (r *CarRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	return purge(ctx, conn(ctx, r.db), "DELETE FROM cars c WHERE deleted_at < $1 AND NOT EXISTS (SELECT 1 FROM rides WHERE car_id = c.id)", before)
+}
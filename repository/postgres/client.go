@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// clientColumns is the column list shared by all client SELECT statements, in scanClient order.
+const clientColumns = "id, name, phone, email, created_at, updated_at, deleted_at"
+
+// scanClient reads a row selected with clientColumns into a Client.
+func scanClient(row interface{ Scan(...any) error }) (models.Client, error) {
+	var client models.Client
+	err := row.Scan(&client.ID, &client.Name, &client.Phone, &client.Email, &client.CreatedAt, &client.UpdatedAt, &client.DeletedAt)
+	return client, err
+}
+
+// ClientRepository is a PostgreSQL-backed repository.ClientRepository.
+type ClientRepository struct {
+	db *sql.DB
+}
+
+// NewClientRepository returns a ClientRepository that stores clients in the clients table.
+func NewClientRepository(db *sql.DB) *ClientRepository {
+	return &ClientRepository{db: db}
+}
+
+// List returns the page of clients matching filter.
+func (r *ClientRepository) List(ctx context.Context, filter repository.ClientFilter, page repository.Page) ([]models.Client, *repository.Cursor, error) {
+	query, args, err := paginate("SELECT "+clientColumns+" FROM clients WHERE ($1 = '' OR name ILIKE '%' || $1 || '%') AND ($2 = '' OR "+normalizedPhone+" = $2) AND ($3 OR deleted_at IS NULL)",
+		[]any{escapeLike(filter.Name), filter.Phone, filter.IncludeDeleted}, page, repository.ClientSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	clients := []models.Client{}
+	for rows.Next() {
+		client, err := scanClient(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		clients = append(clients, client)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	clients, next := repository.NextPage(clients, page, repository.ClientSortKey, func(c models.Client) int { return c.ID })
+	return clients, next, nil
+}
+
+// Get returns the client with the given ID unless it is deleted.
+func (r *ClientRepository) Get(ctx context.Context, id int) (models.Client, error) {
+	client, err := scanClient(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+clientColumns+" FROM clients WHERE id = $1 AND deleted_at IS NULL", id))
+	return client, notFound(err)
+}
+
+// GetIncludingDeleted returns the client with the given ID, deleted or not.
+func (r *ClientRepository) GetIncludingDeleted(ctx context.Context, id int) (models.Client, error) {
+	client, err := scanClient(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+clientColumns+" FROM clients WHERE id = $1", id))
+	return client, notFound(err)
+}
+
+// GetByPhone returns the client with the given normalized phone number unless it is deleted.
+func (r *ClientRepository) GetByPhone(ctx context.Context, phone string) (models.Client, error) {
+	client, err := scanClient(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+clientColumns+" FROM clients WHERE "+normalizedPhone+" = $1 AND deleted_at IS NULL ORDER BY id LIMIT 1", phone))
+	return client, notFound(err)
+}
+
+// Create inserts a new client and sets its ID.
+func (r *ClientRepository) Create(ctx context.Context, client *models.Client) error {
+	return duplicate(conn(ctx, r.db).QueryRowContext(ctx, "INSERT INTO clients (name, phone, email, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		client.Name, client.Phone, client.Email, client.CreatedAt, client.UpdatedAt).Scan(&client.ID))
+}
+
+// Update overwrites the client identified by client.ID if it was last updated at updatedAt.
+func (r *ClientRepository) Update(ctx context.Context, client *models.Client, updatedAt time.Time) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE clients SET name = $1, phone = $2, email = $3, updated_at = $4 WHERE id = $5 AND updated_at = $6 AND deleted_at IS NULL",
+		client.Name, client.Phone, client.Email, client.UpdatedAt, client.ID, updatedAt)
+	if err != nil {
+		return duplicate(err)
+	}
+	if err := checkAffected(result); err != repository.ErrNotFound {
+		return err
+	}
+	return liveOrConflict(ctx, conn(ctx, r.db), "clients", client.ID)
+}
+
+// Delete marks the client with the given ID as deleted.
+func (r *ClientRepository) Delete(ctx context.Context, id int) error {
+	return softDelete(ctx, conn(ctx, r.db), "clients", id)
+}
+
+// Restore clears the deletion mark of the client with the given ID.
+func (r *ClientRepository) Restore(ctx context.Context, id int) error {
+	return restore(ctx, conn(ctx, r.db), "clients", id)
+}
+
+// Purge removes the clients deleted before the given time that no ride refers to.
+func (r *ClientRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	return purge(ctx, conn(ctx, r.db), "DELETE FROM clients c WHERE deleted_at < $1 AND NOT EXISTS (SELECT 1 FROM rides WHERE client_id = c.id)", before)
+}
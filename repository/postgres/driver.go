@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/lib/pq"
+)
+
+// driverColumns is the column list shared by all driver SELECT statements, in scanDriver order.
+const driverColumns = "id, name, phone, license_number, rating, status, created_at, updated_at, deleted_at"
+
+// scanDriver reads a row selected with driverColumns into a Driver.
+func scanDriver(row interface{ Scan(...any) error }) (models.Driver, error) {
+	var driver models.Driver
+	err := row.Scan(&driver.ID, &driver.Name, &driver.Phone, &driver.LicenseNumber, &driver.Rating, &driver.Status, &driver.CreatedAt, &driver.UpdatedAt, &driver.DeletedAt)
+	return driver, err
+}
+
+// DriverRepository is a PostgreSQL-backed repository.DriverRepository.
+type DriverRepository struct {
+	db *sql.DB
+}
+
+// NewDriverRepository returns a DriverRepository that stores drivers in the drivers table.
+func NewDriverRepository(db *sql.DB) *DriverRepository {
+	return &DriverRepository{db: db}
+}
+
+// List returns the page of drivers matching filter.
+func (r *DriverRepository) List(ctx context.Context, filter repository.DriverFilter, page repository.Page) ([]models.Driver, *repository.Cursor, error) {
+	query, args, err := paginate("SELECT "+driverColumns+" FROM drivers WHERE ($1 = '' OR status = $1) AND rating >= $2 AND ($3 OR deleted_at IS NULL) AND ($4::int[] IS NULL OR id = ANY($4))",
+		[]any{filter.Status, filter.MinRating, filter.IncludeDeleted, pq.Array(filter.IDs)}, page, repository.DriverSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	drivers := []models.Driver{}
+	for rows.Next() {
+		driver, err := scanDriver(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		drivers = append(drivers, driver)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	drivers, next := repository.NextPage(drivers, page, repository.DriverSortKey, func(d models.Driver) int { return d.ID })
+	return drivers, next, nil
+}
+
+// Get returns the driver with the given ID unless it is deleted.
+func (r *DriverRepository) Get(ctx context.Context, id int) (models.Driver, error) {
+	driver, err := scanDriver(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+driverColumns+" FROM drivers WHERE id = $1 AND deleted_at IS NULL", id))
+	return driver, notFound(err)
+}
+
+// GetIncludingDeleted returns the driver with the given ID, deleted or not.
+func (r *DriverRepository) GetIncludingDeleted(ctx context.Context, id int) (models.Driver, error) {
+	driver, err := scanDriver(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+driverColumns+" FROM drivers WHERE id = $1", id))
+	return driver, notFound(err)
+}
+
+// GetByPhone returns the driver with the given normalized phone number unless it is deleted.
+func (r *DriverRepository) GetByPhone(ctx context.Context, phone string) (models.Driver, error) {
+	driver, err := scanDriver(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+driverColumns+" FROM drivers WHERE "+normalizedPhone+" = $1 AND deleted_at IS NULL ORDER BY id LIMIT 1", phone))
+	return driver, notFound(err)
+}
+
+// Create inserts a new driver and sets its ID.
+func (r *DriverRepository) Create(ctx context.Context, driver *models.Driver) error {
+	return duplicate(conn(ctx, r.db).QueryRowContext(ctx, "INSERT INTO drivers (name, phone, license_number, rating, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		driver.Name, driver.Phone, driver.LicenseNumber, driver.Rating, driver.Status, driver.CreatedAt, driver.UpdatedAt).Scan(&driver.ID))
+}
+
+// Update overwrites the profile of the driver identified by driver.ID if it was last updated
+// at updatedAt, and reads back its status.
+func (r *DriverRepository) Update(ctx context.Context, driver *models.Driver, updatedAt time.Time) error {
+	err := conn(ctx, r.db).QueryRowContext(ctx, "UPDATE drivers SET name = $1, phone = $2, license_number = $3, rating = $4, updated_at = $5 WHERE id = $6 AND updated_at = $7 AND deleted_at IS NULL RETURNING status",
+		driver.Name, driver.Phone, driver.LicenseNumber, driver.Rating, driver.UpdatedAt, driver.ID, updatedAt).Scan(&driver.Status)
+	if err == sql.ErrNoRows {
+		return liveOrConflict(ctx, conn(ctx, r.db), "drivers", driver.ID)
+	}
+	return duplicate(err)
+}
+
+// Delete marks the driver with the given ID as deleted.
+func (r *DriverRepository) Delete(ctx context.Context, id int) error {
+	return softDelete(ctx, conn(ctx, r.db), "drivers", id)
+}
+
+// Restore clears the deletion mark of the driver with the given ID.
+func (r *DriverRepository) Restore(ctx context.Context, id int) error {
+	return restore(ctx, conn(ctx, r.db), "drivers", id)
+}
+
+// Purge removes the drivers deleted before the given time that no ride or car refers to,
+// together with their shifts. Foreign keys are checked at the end of the statement, so
+// the shifts can be removed by the same statement as the drivers.
+func (r *DriverRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	var n int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+	WITH purged AS (
+		DELETE FROM drivers d
+		WHERE deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM rides WHERE driver_id = d.id)
+			AND NOT EXISTS (SELECT 1 FROM cars WHERE driver_id = d.id)
+		RETURNING id
+	), shifts AS (
+		DELETE FROM driver_shifts WHERE driver_id IN (SELECT id FROM purged)
+	)
+	SELECT count(*) FROM purged`, before).Scan(&n)
+	return n, err
+}
+
+// SetStatus changes the status of the driver with the given ID from from to to.
+// A deleted driver is reported as missing.
+func (r *DriverRepository) SetStatus(ctx context.Context, id int, from, to models.DriverStatus, updatedAt time.Time) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE drivers SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4 AND deleted_at IS NULL", to, updatedAt, id, from)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != repository.ErrNotFound {
+		return err
+	}
+	return liveOrConflict(ctx, conn(ctx, r.db), "drivers", id)
+}
+
+// CountByStatus returns the number of live drivers in each status.
+func (r *DriverRepository) CountByStatus(ctx context.Context) (map[models.DriverStatus]int, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT status, count(*) FROM drivers WHERE deleted_at IS NULL GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	return scanCounts[models.DriverStatus](rows)
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/geo"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// LocationRepository is a PostgreSQL-backed repository.LocationRepository.
+// Unlike the in-memory implementation it is shared by all replicas and survives restarts.
+type LocationRepository struct {
+	db *sql.DB
+}
+
+// NewLocationRepository returns a LocationRepository that stores positions in the driver_locations table.
+func NewLocationRepository(db *sql.DB) *LocationRepository {
+	return &LocationRepository{db: db}
+}
+
+// Save upserts loc as the driver's latest position unless a newer position is already stored.
+func (r *LocationRepository) Save(ctx context.Context, loc models.DriverLocation) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `INSERT INTO driver_locations (driver_id, lat, lon, heading, speed, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (driver_id) DO UPDATE
+			SET lat = EXCLUDED.lat, lon = EXCLUDED.lon, heading = EXCLUDED.heading,
+				speed = EXCLUDED.speed, recorded_at = EXCLUDED.recorded_at
+			WHERE driver_locations.recorded_at <= EXCLUDED.recorded_at`,
+		loc.DriverID, loc.Lat, loc.Lon, loc.Heading, loc.Speed, loc.RecordedAt)
+	return err
+}
+
+// Get returns the latest position of the given driver.
+func (r *LocationRepository) Get(ctx context.Context, driverID int) (models.DriverLocation, error) {
+	var loc models.DriverLocation
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT driver_id, lat, lon, heading, speed, recorded_at FROM driver_locations WHERE driver_id = $1", driverID).
+		Scan(&loc.DriverID, &loc.Lat, &loc.Lon, &loc.Heading, &loc.Speed, &loc.RecordedAt)
+	return loc, notFound(err)
+}
+
+// Nearby returns the positions matching query ordered by distance, nearest first.
+// The bounding box condition lets PostgreSQL use the (lat, lon) index before the exact
+// haversine distance is computed.
+func (r *LocationRepository) Nearby(ctx context.Context, query repository.NearbyQuery) ([]repository.NearbyLocation, error) {
+	box := geo.BoundingBox(geo.Point{Lat: query.Lat, Lon: query.Lon}, query.Radius)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT driver_id, lat, lon, heading, speed, recorded_at, distance FROM (
+			SELECT driver_id, lat, lon, heading, speed, recorded_at,
+				2 * $1::float8 * asin(least(1, sqrt(
+					power(sin(radians(lat - $2) / 2), 2) +
+					cos(radians($2)) * cos(radians(lat)) * power(sin(radians(lon - $3) / 2), 2)
+				))) AS distance
+			FROM driver_locations
+			WHERE lat BETWEEN $4 AND $5 AND lon BETWEEN $6 AND $7 AND recorded_at >= $8
+		) candidates
+		WHERE distance <= $9
+		ORDER BY distance, driver_id`,
+		geo.EarthRadius, query.Lat, query.Lon, box.MinLat, box.MaxLat, box.MinLon, box.MaxLon, query.Since, query.Radius)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []repository.NearbyLocation{}
+	for rows.Next() {
+		var loc repository.NearbyLocation
+		if err := rows.Scan(&loc.DriverID, &loc.Lat, &loc.Lon, &loc.Heading, &loc.Speed, &loc.RecordedAt, &loc.Distance); err != nil {
+			return nil, err
+		}
+		result = append(result, loc)
+	}
+	return result, rows.Err()
+}
+
+// Recent returns every position recorded at or after since, ordered by driver ID.
+func (r *LocationRepository) Recent(ctx context.Context, since time.Time) ([]models.DriverLocation, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT driver_id, lat, lon, heading, speed, recorded_at FROM driver_locations
+		WHERE recorded_at >= $1 ORDER BY driver_id`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.DriverLocation{}
+	for rows.Next() {
+		var loc models.DriverLocation
+		if err := rows.Scan(&loc.DriverID, &loc.Lat, &loc.Lon, &loc.Heading, &loc.Speed, &loc.RecordedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, loc)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryObserver receives every statement run by the repositories once it has been executed:
+// its operation and table, e.g. "select" and "clients", how long it took and its error.
+// For queries the time covers the execution, not reading the rows.
+type QueryObserver func(operation, table string, duration time.Duration, err error)
+
+// observeQuery is the QueryObserver set by ObserveQueries.
+var observeQuery QueryObserver
+
+// ObserveQueries makes the repositories report every statement to observe.
+// It must be called before the repositories are used.
+func ObserveQueries(observe QueryObserver) {
+	observeQuery = observe
+}
+
+// tracer creates a span for every statement, a child of the span in the statement's context,
+// through the global tracer provider; it does nothing until tracing is set up.
+var tracer = otel.Tracer("github.com/hse-trpo-taxi/backend/repository/postgres")
+
+// observed is a querier that traces its statements and reports them to observeQuery.
+type observed struct {
+	q querier
+}
+
+func (o observed) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, done := observe(ctx, query)
+	result, err := o.q.ExecContext(ctx, query, args...)
+	done(err)
+	return result, err
+}
+
+func (o observed) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, done := observe(ctx, query)
+	rows, err := o.q.QueryContext(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (o observed) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, done := observe(ctx, query)
+	row := o.q.QueryRowContext(ctx, query, args...)
+	// sql.ErrNoRows is a normal outcome of a lookup, so it only surfaces in Scan
+	done(row.Err())
+	return row
+}
+
+// observe starts a span for query and returns the context to run it with and a function
+// that ends the span and reports the statement to observeQuery once it has run.
+func observe(ctx context.Context, query string) (context.Context, func(err error)) {
+	stmt := parseStatement(query)
+	ctx, span := tracer.Start(ctx, stmt.name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(stmt.operation),
+			semconv.DBCollectionName(stmt.table),
+			semconv.DBQueryText(stmt.text),
+		))
+	start := time.Now()
+	return ctx, func(err error) {
+		if observeQuery != nil {
+			observeQuery(stmt.operation, stmt.table, time.Since(start), err)
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// statement describes a statement for metrics and traces.
+type statement struct {
+	// operation and table label the statement, e.g. "select" and "clients"
+	operation, table string
+	// name is the span name, e.g. "SELECT clients"
+	name string
+	// text is the statement with its whitespace collapsed; arguments are never part of it
+	text string
+}
+
+// statementCache maps statements to their descriptions. The repositories only run
+// statements built from constants, so it stays small.
+var statementCache sync.Map
+
+// parseStatement describes query by its lower-cased first keyword and the first table it
+// names after FROM, INTO or UPDATE, or "" if it names none.
+func parseStatement(query string) statement {
+	if cached, ok := statementCache.Load(query); ok {
+		return cached.(statement)
+	}
+	words := strings.Fields(query)
+	var s statement
+	if len(words) > 0 {
+		s.operation = strings.ToLower(words[0])
+	}
+	for i := 0; i+1 < len(words); i++ {
+		switch strings.ToUpper(words[i]) {
+		case "FROM", "INTO", "UPDATE":
+			s.table = strings.Trim(words[i+1], "(),;")
+		}
+		if s.table != "" {
+			break
+		}
+	}
+	s.name = strings.TrimSpace(strings.ToUpper(s.operation) + " " + s.table)
+	s.text = strings.Join(words, " ")
+	statementCache.Store(query, s)
+	return s
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// OTPRepository is a PostgreSQL-backed repository.OTPRepository, so a code requested
+// through one replica can be verified through another.
+type OTPRepository struct {
+	db *sql.DB
+}
+
+// NewOTPRepository returns an OTPRepository that stores codes in the otp_codes table.
+func NewOTPRepository(db *sql.DB) *OTPRepository {
+	return &OTPRepository{db: db}
+}
+
+// Get returns the code for phone and role.
+func (r *OTPRepository) Get(ctx context.Context, phone, role string) (models.OTPCode, error) {
+	var code models.OTPCode
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT phone, role, code_hash, expires_at, attempts, sent_at, send_count, window_started_at
+		FROM otp_codes WHERE phone = $1 AND role = $2`, phone, role).
+		Scan(&code.Phone, &code.Role, &code.CodeHash, &code.ExpiresAt, &code.Attempts, &code.SentAt, &code.SendCount, &code.WindowStartedAt)
+	return code, notFound(err)
+}
+
+// Save upserts code.
+func (r *OTPRepository) Save(ctx context.Context, code models.OTPCode) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `INSERT INTO otp_codes (phone, role, code_hash, expires_at, attempts, sent_at, send_count, window_started_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (phone, role) DO UPDATE
+			SET code_hash = EXCLUDED.code_hash, expires_at = EXCLUDED.expires_at, attempts = EXCLUDED.attempts,
+				sent_at = EXCLUDED.sent_at, send_count = EXCLUDED.send_count, window_started_at = EXCLUDED.window_started_at`,
+		code.Phone, code.Role, code.CodeHash, code.ExpiresAt, code.Attempts, code.SentAt, code.SendCount, code.WindowStartedAt)
+	return err
+}
+
+// AddAttempt increments the attempt counter of the code for phone and role and returns the new count.
+func (r *OTPRepository) AddAttempt(ctx context.Context, phone, role string) (int, error) {
+	var attempts int
+	err := conn(ctx, r.db).QueryRowContext(ctx, "UPDATE otp_codes SET attempts = attempts + 1 WHERE phone = $1 AND role = $2 RETURNING attempts",
+		phone, role).Scan(&attempts)
+	return attempts, notFound(err)
+}
+
+// Delete removes the code for phone and role.
+func (r *OTPRepository) Delete(ctx context.Context, phone, role string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM otp_codes WHERE phone = $1 AND role = $2", phone, role)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
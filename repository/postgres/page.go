@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// paginate appends the keyset condition, ORDER BY and LIMIT of page to query, a SELECT ending
+// in a WHERE clause, and returns it together with args extended by the page's arguments.
+// It asks for one row beyond page.Limit so repository.NextPage can tell whether another page follows.
+// The sort field must be one of fields; its name is used as the column name.
+func paginate(query string, args []any, page repository.Page, fields repository.SortFields) (string, []any, error) {
+	column := page.Field()
+	if _, ok := fields[column]; !ok {
+		return "", nil, fmt.Errorf("unknown sort field %q", column)
+	}
+	op, dir := ">", "ASC"
+	if page.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	if page.After != nil {
+		query += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", column, op, len(args)+1, len(args)+2)
+		args = append(args, page.After.Value, page.After.ID)
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", column, dir, dir)
+	if page.Limit > 0 {
+		query += " LIMIT " + strconv.Itoa(page.Limit+1)
+	}
+	return query, args, nil
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike escapes s for use as a literal inside a LIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,148 @@
+// Package postgres implements the repository interfaces on top of a PostgreSQL database.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/lib/pq"
+)
+
+// normalizedPhone is the SQL equivalent of validate.NormalizePhone applied to the phone column:
+// separators are removed and a Russian number with the trunk prefix 8 gets +7.
+// It matches the expression of the unique phone indexes (migrations 0009 and 0010), so lookups by phone use them.
+const normalizedPhone = `regexp_replace(regexp_replace(phone, '[\s().-]', '', 'g'), '^8(\d{10})$', '+7\1')`
+
+// uniqueIndexes maps the unique indexes on live rows (migrations 0009 and 0010) to the record field they protect.
+var uniqueIndexes = map[string]repository.DuplicateError{
+	"clients_phone_key":          {Entity: "client", Field: "phone"},
+	"clients_email_key":          {Entity: "client", Field: "email"},
+	"drivers_phone_key":          {Entity: "driver", Field: "phone"},
+	"drivers_license_number_key": {Entity: "driver", Field: "license_number"},
+	"cars_license_plate_key":     {Entity: "car", Field: "license_plate"},
+}
+
+// checkAffected converts an UPDATE or DELETE result that touched no rows into repository.ErrNotFound.
+func checkAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// notFound converts sql.ErrNoRows into repository.ErrNotFound and passes other errors through.
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
+// existsOrConflict is called after a conditional UPDATE touched no rows. It returns
+// repository.ErrNotFound if the row with the given ID is missing from table, and
+// repository.ErrConflict if the row exists but did not satisfy the condition.
+func existsOrConflict(ctx context.Context, db querier, table string, id int) error {
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return repository.ErrNotFound
+	}
+	return repository.ErrConflict
+}
+
+// liveOrConflict is existsOrConflict for the tables with soft deletion: a deleted row
+// counts as missing, so it returns repository.ErrNotFound for it.
+func liveOrConflict(ctx context.Context, db querier, table string, id int) error {
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return repository.ErrNotFound
+	}
+	return repository.ErrConflict
+}
+
+// softDelete marks the row with the given ID in table as deleted. It returns
+// repository.ErrNotFound if the row is missing or already deleted.
+func softDelete(ctx context.Context, db querier, table string, id int) error {
+	result, err := db.ExecContext(ctx, "UPDATE "+table+" SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// restore clears the deletion mark of the row with the given ID in table. It returns
+// repository.ErrNotFound if the row is missing, repository.ErrConflict if it is not
+// deleted, and a *repository.DuplicateError if a live row has taken one of its unique values.
+func restore(ctx context.Context, db querier, table string, id int) error {
+	result, err := db.ExecContext(ctx, "UPDATE "+table+" SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return duplicate(err)
+	}
+	if err := checkAffected(result); err != repository.ErrNotFound {
+		return err
+	}
+	return existsOrConflict(ctx, db, table, id)
+}
+
+// purge runs a DELETE statement whose only parameter is the deletion cutoff and
+// returns the number of rows it removed.
+func purge(ctx context.Context, db querier, query string, before time.Time) (int, error) {
+	result, err := db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// scanCounts reads (key, count) rows, such as those of a GROUP BY status query, into a map and closes them.
+func scanCounts[K ~string](rows *sql.Rows) (map[K]int, error) {
+	defer rows.Close()
+	counts := make(map[K]int)
+	for rows.Next() {
+		var key K
+		var n int
+		if err := rows.Scan(&key, &n); err != nil {
+			return nil, err
+		}
+		counts[key] = n
+	}
+	return counts, rows.Err()
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation"
+}
+
+// duplicate converts a violation of one of uniqueIndexes into a *repository.DuplicateError
+// and passes other errors through.
+func duplicate(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code.Name() != "unique_violation" {
+		return err
+	}
+	if dup, ok := uniqueIndexes[pqErr.Constraint]; ok {
+		return &dup
+	}
+	return err
+}
+
+// isForeignKeyViolation reports whether err is a PostgreSQL foreign key constraint violation.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation"
+}
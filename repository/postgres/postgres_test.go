@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/hse-trpo-taxi/backend/database"
+	"github.com/hse-trpo-taxi/backend/geo"
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/otp"
+	"github.com/hse-trpo-taxi/backend/pricing"
+)
+
+// openTestDB connects to the database named by the TEST_DATABASE_URL environment variable and
+// migrates a fresh schema in it, which is dropped when the test ends. Without TEST_DATABASE_URL
+// the test is skipped. The service runs in Europe/Moscow and the database session in
+// America/New_York, so a timestamp that loses its time zone on the way is off by hours.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := time.Local
+	time.Local = moscow
+	t.Cleanup(func() { time.Local = local })
+	t.Setenv("PGTZ", "America/New_York")
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	db, err := sql.Open("postgres", withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// withSearchPath adds the search_path run-time parameter to dsn, given as a URL or as key=value pairs.
+func withSearchPath(dsn, schema string) string {
+	if u, err := url.Parse(dsn); err == nil && strings.Contains(dsn, "://") {
+		query := u.Query()
+		query.Set("search_path", schema)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+	return dsn + " search_path=" + schema
+}
+
+func TestRideTimestamps(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	client := models.Client{Name: "Anna", Phone: "+79991234567"}
+	if err := NewClientRepository(db).Create(ctx, &client); err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Date(2024, 1, 1, 23, 30, 0, 0, time.Local)
+	requested := started.Add(-10 * time.Minute)
+	rides := NewRideRepository(db)
+	ride := models.Ride{ClientID: client.ID, Status: models.RideRequested, SurgeMultiplier: 1,
+		RequestedAt: requested, CreatedAt: requested, UpdatedAt: requested}
+	if err := rides.Create(ctx, &ride); err != nil {
+		t.Fatal(err)
+	}
+	ride.Status, ride.StartedAt, ride.UpdatedAt = models.RideInProgress, &started, started
+	if err := rides.Transition(ctx, &ride, models.RideRequested); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := rides.Get(ctx, ride.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.RequestedAt.Equal(requested) || stored.StartedAt == nil || !stored.StartedAt.Equal(started) {
+		t.Errorf("stored requested_at %v and started_at %v, want %v and %v", stored.RequestedAt, stored.StartedAt, requested, started)
+	}
+	if d := started.Add(25 * time.Minute).Sub(*stored.StartedAt); d != 25*time.Minute {
+		t.Errorf("duration from the stored start = %v, want 25m", d)
+	}
+	tariff := models.Tariff{Timezone: "Europe/Moscow", NightStartHour: 22, NightEndHour: 6, NightMultiplier: 1.5, HolidayMultiplier: 1}
+	if m := pricing.Multiplier(tariff, *stored.StartedAt); m != 1.5 {
+		t.Errorf("multiplier of a ride started at 23:30 in Moscow = %v, want the night multiplier 1.5", m)
+	}
+}
+
+func TestSurgeDemand(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	client := models.Client{Name: "Anna", Phone: "+79991234567"}
+	if err := NewClientRepository(db).Create(ctx, &client); err != nil {
+		t.Fatal(err)
+	}
+	rides := NewRideRepository(db)
+	now := time.Now()
+	pickup := geo.Point{Lat: 55.7558, Lon: 37.6173}
+	for _, requested := range []time.Time{now.Add(-time.Minute), now.Add(-time.Hour)} {
+		ride := models.Ride{ClientID: client.ID, PickupLat: pickup.Lat, PickupLon: pickup.Lon, Status: models.RideRequested,
+			SurgeMultiplier: 1, RequestedAt: requested, CreatedAt: requested, UpdatedAt: requested}
+		if err := rides.Create(ctx, &ride); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := pricing.SurgeConfig{ZoneSize: 0.02, Window: 10 * time.Minute, Interval: time.Minute, Sensitivity: 0.5, Cap: 3, Smoothing: 1}
+	requests, err := pricing.OpenRequests(rides, cfg.Window)(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	surge := pricing.NewSurge(cfg)
+	surge.Update(time.Now(), requests, nil)
+	if got := surge.Zone(pickup); got.Demand != 1 {
+		t.Errorf("surge zone = %+v, want demand 1 from the ride requested a minute ago", got)
+	}
+}
+
+// lastMessage is an otp.Sender that keeps the last message sent.
+type lastMessage struct {
+	text string
+}
+
+func (s *lastMessage) Send(ctx context.Context, phone, message string) error {
+	s.text = message
+	return nil
+}
+
+func TestOTPTimestamps(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	sms := &lastMessage{}
+	codes := otp.NewService(NewOTPRepository(db), sms, otp.Config{
+		TTL: 5 * time.Minute, ResendInterval: time.Minute, MaxSends: 5, SendWindow: time.Hour, MaxAttempts: 5,
+	})
+	phone := "+79991234567"
+
+	if err := codes.Request(ctx, phone, "client"); err != nil {
+		t.Fatal(err)
+	}
+	var limit *otp.RateLimitError
+	if err := codes.Request(ctx, phone, "client"); !errors.As(err, &limit) || limit.RetryAfter > time.Minute || limit.RetryAfter < 50*time.Second {
+		t.Errorf("second request = %v, want to retry within the one-minute resend interval", err)
+	}
+	code := regexp.MustCompile(`\d{6}`).FindString(sms.text)
+	if err := codes.Verify(ctx, phone, "client", code); err != nil {
+		t.Errorf("Verify with the code just sent = %v, want it accepted before it expires", err)
+	}
+}
+
+func TestPurgeCutoff(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	clients := NewClientRepository(db)
+	client := models.Client{Name: "Anna", Phone: "+79991234567"}
+	if err := clients.Create(ctx, &client); err != nil {
+		t.Fatal(err)
+	}
+	if err := clients.Delete(ctx, client.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := clients.Purge(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("Purge of clients deleted over an hour ago = %d, %v; want the client just deleted kept", n, err)
+	}
+	if n, err := clients.Purge(ctx, time.Now().Add(time.Minute)); err != nil || n != 1 {
+		t.Errorf("Purge of clients deleted before a minute from now = %d, %v; want the client removed", n, err)
+	}
+}
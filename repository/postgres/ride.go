@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/lib/pq"
+)
+
+// rideColumns is the column list shared by all ride SELECT statements, in scanRide order.
+const rideColumns = "id, client_id, driver_id, car_id, pickup_lat, pickup_lon, dropoff_lat, dropoff_lon, status, tariff_id, surge_multiplier, fare, distance_m, duration_s, requested_at, accepted_at, started_at, completed_at, cancelled_at, created_at, updated_at"
+
+// RideRepository is a PostgreSQL-backed repository.RideRepository.
+type RideRepository struct {
+	db *sql.DB
+}
+
+// NewRideRepository returns a RideRepository that stores rides in the rides table.
+func NewRideRepository(db *sql.DB) *RideRepository {
+	return &RideRepository{db: db}
+}
+
+// scanRide reads a row selected with rideColumns into a Ride.
+func scanRide(row interface{ Scan(...any) error }) (models.Ride, error) {
+	var ride models.Ride
+	err := row.Scan(&ride.ID, &ride.ClientID, &ride.DriverID, &ride.CarID,
+		&ride.PickupLat, &ride.PickupLon, &ride.DropoffLat, &ride.DropoffLon,
+		&ride.Status, &ride.TariffID, &ride.SurgeMultiplier, &ride.Fare, &ride.Distance, &ride.Duration, &ride.RequestedAt, &ride.AcceptedAt, &ride.StartedAt,
+		&ride.CompletedAt, &ride.CancelledAt, &ride.CreatedAt, &ride.UpdatedAt)
+	return ride, err
+}
+
+// List returns the page of rides matching filter.
+func (r *RideRepository) List(ctx context.Context, filter repository.RideFilter, page repository.Page) ([]models.Ride, *repository.Cursor, error) {
+	query, args, err := paginate("SELECT "+rideColumns+" FROM rides WHERE ($1 = '' OR status = $1)",
+		[]any{filter.Status}, page, repository.RideSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	rides := []models.Ride{}
+	for rows.Next() {
+		ride, err := scanRide(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		rides = append(rides, ride)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	rides, next := repository.NextPage(rides, page, repository.RideSortKey, func(r models.Ride) int { return r.ID })
+	return rides, next, nil
+}
+
+// Requested returns the rides still waiting for a driver that were requested at or after since.
+func (r *RideRepository) Requested(ctx context.Context, since time.Time) ([]models.Ride, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT "+rideColumns+" FROM rides WHERE status = $1 AND requested_at >= $2 ORDER BY id",
+		models.RideRequested, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rides := []models.Ride{}
+	for rows.Next() {
+		ride, err := scanRide(rows)
+		if err != nil {
+			return nil, err
+		}
+		rides = append(rides, ride)
+	}
+	return rides, rows.Err()
+}
+
+// Get returns the ride with the given ID.
+func (r *RideRepository) Get(ctx context.Context, id int) (models.Ride, error) {
+	ride, err := scanRide(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+rideColumns+" FROM rides WHERE id = $1", id))
+	return ride, notFound(err)
+}
+
+// Create inserts a new ride and sets its ID.
+func (r *RideRepository) Create(ctx context.Context, ride *models.Ride) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `INSERT INTO rides (client_id, pickup_lat, pickup_lon, dropoff_lat, dropoff_lon, status, tariff_id, surge_multiplier, requested_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+		ride.ClientID, ride.PickupLat, ride.PickupLon, ride.DropoffLat, ride.DropoffLon,
+		ride.Status, ride.TariffID, ride.SurgeMultiplier, ride.RequestedAt, ride.CreatedAt, ride.UpdatedAt).Scan(&ride.ID)
+}
+
+// Transition overwrites the lifecycle fields of the ride identified by ride.ID
+// if its stored status is still from.
+func (r *RideRepository) Transition(ctx context.Context, ride *models.Ride, from models.RideStatus) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE rides SET driver_id = $1, car_id = $2, status = $3, fare = $4,
+		distance_m = $5, duration_s = $6, accepted_at = $7, started_at = $8, completed_at = $9,
+		cancelled_at = $10, updated_at = $11
+		WHERE id = $12 AND status = $13`,
+		ride.DriverID, ride.CarID, ride.Status, ride.Fare, ride.Distance, ride.Duration,
+		ride.AcceptedAt, ride.StartedAt, ride.CompletedAt, ride.CancelledAt, ride.UpdatedAt,
+		ride.ID, from)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != repository.ErrNotFound {
+		return err
+	}
+	return existsOrConflict(ctx, conn(ctx, r.db), "rides", ride.ID)
+}
+
+// CountByStatus returns the number of rides in each of statuses.
+func (r *RideRepository) CountByStatus(ctx context.Context, statuses ...models.RideStatus) (map[models.RideStatus]int, error) {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT status, count(*) FROM rides WHERE status = ANY($1) GROUP BY status", pq.StringArray(names))
+	if err != nil {
+		return nil, err
+	}
+	return scanCounts[models.RideStatus](rows)
+}
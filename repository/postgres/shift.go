@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+)
+
+// ShiftRepository is a PostgreSQL-backed repository.ShiftRepository.
+type ShiftRepository struct {
+	db *sql.DB
+}
+
+// NewShiftRepository returns a ShiftRepository that stores shifts in the driver_shifts table.
+func NewShiftRepository(db *sql.DB) *ShiftRepository {
+	return &ShiftRepository{db: db}
+}
+
+// Open inserts a new open shift and sets its ID.
+// The driver_shifts_open_idx unique index rejects a second open shift for the same driver.
+func (r *ShiftRepository) Open(ctx context.Context, shift *models.Shift) error {
+	err := conn(ctx, r.db).QueryRowContext(ctx, "INSERT INTO driver_shifts (driver_id, started_at) VALUES ($1, $2) RETURNING id",
+		shift.DriverID, shift.StartedAt).Scan(&shift.ID)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	return err
+}
+
+// Close ends the open shift of the given driver at endedAt and returns it.
+func (r *ShiftRepository) Close(ctx context.Context, driverID int, endedAt time.Time) (models.Shift, error) {
+	var shift models.Shift
+	err := conn(ctx, r.db).QueryRowContext(ctx, "UPDATE driver_shifts SET ended_at = $1 WHERE driver_id = $2 AND ended_at IS NULL RETURNING id, driver_id, started_at, ended_at",
+		endedAt, driverID).Scan(&shift.ID, &shift.DriverID, &shift.StartedAt, &shift.EndedAt)
+	return shift, notFound(err)
+}
+
+// ListByDriver returns the page of the driver's shifts overlapping the filter interval.
+func (r *ShiftRepository) ListByDriver(ctx context.Context, driverID int, filter repository.ShiftFilter, page repository.Page) ([]models.Shift, *repository.Cursor, error) {
+	var from, to *time.Time
+	if !filter.From.IsZero() {
+		from = &filter.From
+	}
+	if !filter.To.IsZero() {
+		to = &filter.To
+	}
+
+	query, args, err := paginate(`SELECT id, driver_id, started_at, ended_at FROM driver_shifts
+		WHERE driver_id = $1
+			AND ($2::timestamptz IS NULL OR ended_at IS NULL OR ended_at > $2)
+			AND ($3::timestamptz IS NULL OR started_at < $3)`,
+		[]any{driverID, from, to}, page, repository.ShiftSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	shifts := []models.Shift{}
+	for rows.Next() {
+		var shift models.Shift
+		if err := rows.Scan(&shift.ID, &shift.DriverID, &shift.StartedAt, &shift.EndedAt); err != nil {
+			return nil, nil, err
+		}
+		shifts = append(shifts, shift)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	shifts, next := repository.NextPage(shifts, page, repository.ShiftSortKey, func(s models.Shift) int { return s.ID })
+	return shifts, next, nil
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hse-trpo-taxi/backend/models"
+	"github.com/hse-trpo-taxi/backend/repository"
+	"github.com/lib/pq"
+)
+
+// tariffColumns is the column list shared by all tariff SELECT statements, in scanTariff order.
+const tariffColumns = `id, name, base_fare, per_km, per_minute, minimum_fare, waiting_per_minute, free_waiting_minutes,
+	night_multiplier, night_start_hour, night_end_hour, holiday_multiplier, holidays::TEXT[], timezone, is_default,
+	created_at, updated_at`
+
+// TariffRepository is a PostgreSQL-backed repository.TariffRepository.
+type TariffRepository struct {
+	db *sql.DB
+}
+
+// NewTariffRepository returns a TariffRepository that stores tariffs in the tariffs table.
+func NewTariffRepository(db *sql.DB) *TariffRepository {
+	return &TariffRepository{db: db}
+}
+
+// scanTariff reads a row selected with tariffColumns into a Tariff.
+func scanTariff(row interface{ Scan(...any) error }) (models.Tariff, error) {
+	var tariff models.Tariff
+	var holidays pq.StringArray
+	err := row.Scan(&tariff.ID, &tariff.Name, &tariff.BaseFare, &tariff.PerKm, &tariff.PerMinute,
+		&tariff.MinimumFare, &tariff.WaitingPerMinute, &tariff.FreeWaitingMinutes,
+		&tariff.NightMultiplier, &tariff.NightStartHour, &tariff.NightEndHour, &tariff.HolidayMultiplier,
+		&holidays, &tariff.Timezone, &tariff.Default, &tariff.CreatedAt, &tariff.UpdatedAt)
+	tariff.Holidays = []string(holidays)
+	return tariff, err
+}
+
+// List returns a page of tariffs.
+func (r *TariffRepository) List(ctx context.Context, page repository.Page) ([]models.Tariff, *repository.Cursor, error) {
+	query, args, err := paginate("SELECT "+tariffColumns+" FROM tariffs WHERE TRUE", nil, page, repository.TariffSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	tariffs := []models.Tariff{}
+	for rows.Next() {
+		tariff, err := scanTariff(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		tariffs = append(tariffs, tariff)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	tariffs, next := repository.NextPage(tariffs, page, repository.TariffSortKey, func(t models.Tariff) int { return t.ID })
+	return tariffs, next, nil
+}
+
+// Get returns the tariff with the given ID.
+func (r *TariffRepository) Get(ctx context.Context, id int) (models.Tariff, error) {
+	tariff, err := scanTariff(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+tariffColumns+" FROM tariffs WHERE id = $1", id))
+	return tariff, notFound(err)
+}
+
+// Default returns the tariff marked as default.
+func (r *TariffRepository) Default(ctx context.Context) (models.Tariff, error) {
+	tariff, err := scanTariff(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+tariffColumns+" FROM tariffs WHERE is_default"))
+	return tariff, notFound(err)
+}
+
+// Create inserts a new tariff and sets its ID.
+func (r *TariffRepository) Create(ctx context.Context, tariff *models.Tariff) error {
+	return r.inTx(ctx, tariff, func(tx querier) error {
+		return tx.QueryRowContext(ctx, `INSERT INTO tariffs (name, base_fare, per_km, per_minute, minimum_fare,
+			waiting_per_minute, free_waiting_minutes, night_multiplier, night_start_hour, night_end_hour,
+			holiday_multiplier, holidays, timezone, is_default, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id`,
+			tariff.Name, tariff.BaseFare, tariff.PerKm, tariff.PerMinute, tariff.MinimumFare,
+			tariff.WaitingPerMinute, tariff.FreeWaitingMinutes, tariff.NightMultiplier, tariff.NightStartHour,
+			tariff.NightEndHour, tariff.HolidayMultiplier, pq.StringArray(tariff.Holidays), tariff.Timezone,
+			tariff.Default, tariff.CreatedAt, tariff.UpdatedAt).Scan(&tariff.ID)
+	})
+}
+
+// Update overwrites the tariff identified by tariff.ID.
+func (r *TariffRepository) Update(ctx context.Context, tariff *models.Tariff) error {
+	return r.inTx(ctx, tariff, func(tx querier) error {
+		result, err := tx.ExecContext(ctx, `UPDATE tariffs SET name = $1, base_fare = $2, per_km = $3, per_minute = $4,
+			minimum_fare = $5, waiting_per_minute = $6, free_waiting_minutes = $7, night_multiplier = $8,
+			night_start_hour = $9, night_end_hour = $10, holiday_multiplier = $11, holidays = $12, timezone = $13,
+			is_default = $14, updated_at = $15 WHERE id = $16`,
+			tariff.Name, tariff.BaseFare, tariff.PerKm, tariff.PerMinute, tariff.MinimumFare,
+			tariff.WaitingPerMinute, tariff.FreeWaitingMinutes, tariff.NightMultiplier, tariff.NightStartHour,
+			tariff.NightEndHour, tariff.HolidayMultiplier, pq.StringArray(tariff.Holidays), tariff.Timezone,
+			tariff.Default, tariff.UpdatedAt, tariff.ID)
+		if err != nil {
+			return err
+		}
+		return checkAffected(result)
+	})
+}
+
+// Delete removes the tariff with the given ID.
+func (r *TariffRepository) Delete(ctx context.Context, id int) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM tariffs WHERE id = $1", id)
+	if isForeignKeyViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// inTx runs write in a transaction, joining the caller's one if ctx has it. If tariff is the default,
+// the flag is first cleared on every other tariff so the unique default index is never violated.
+func (r *TariffRepository) inTx(ctx context.Context, tariff *models.Tariff, write func(tx querier) error) error {
+	return NewTransactor(r.db).InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, r.db)
+		if tariff.Default {
+			if _, err := tx.ExecContext(ctx, "UPDATE tariffs SET is_default = FALSE WHERE is_default AND id <> $1", tariff.ID); err != nil {
+				return err
+			}
+		}
+		return write(tx)
+	})
+}
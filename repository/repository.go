@@ -0,0 +1,30 @@
+// Package repository defines the storage interfaces used by the HTTP handlers.
+// Handlers depend only on these interfaces, so the same handler code can run against
+// the PostgreSQL implementation in the postgres subpackage or the in-memory
+// implementation in the memory subpackage.
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned by repository methods when the requested record does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict is returned when a conditional write fails because the record was changed concurrently.
+var ErrConflict = errors.New("record was modified concurrently")
+
+// DuplicateError is returned by Create and Update when a record would have the same value
+// as an existing one in a field that must be unique. Values are compared in normalized
+// form, e.g. phone numbers without formatting and email addresses ignoring case.
+type DuplicateError struct {
+	// Entity is the kind of record, e.g. "driver"
+	Entity string
+	// Field is the JSON name of the duplicated field, e.g. "license_number"
+	Field string
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%s with this %s already exists", e.Entity, e.Field)
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// RideFilter narrows the rides returned by RideRepository.List.
+// Zero-valued fields do not filter.
+type RideFilter struct {
+	// Status limits the result to rides in this status
+	Status models.RideStatus
+}
+
+// RideRepository provides persistent storage for rides.
+type RideRepository interface {
+	// List returns the page of rides matching filter, sorted by one of RideSortFields,
+	// and the cursor of the next page, which is nil if this is the last one.
+	List(ctx context.Context, filter RideFilter, page Page) ([]models.Ride, *Cursor, error)
+	// Requested returns the rides still waiting for a driver that were requested at or after since.
+	Requested(ctx context.Context, since time.Time) ([]models.Ride, error)
+	// Get returns the ride with the given ID or ErrNotFound.
+	Get(ctx context.Context, id int) (models.Ride, error)
+	// Create stores a new ride and sets its ID.
+	Create(ctx context.Context, ride *models.Ride) error
+	// Transition overwrites the ride identified by ride.ID only if its stored status is still from.
+	// It returns ErrNotFound if the ride does not exist and ErrConflict if its status has changed,
+	// so two concurrent transitions of the same ride cannot both succeed.
+	Transition(ctx context.Context, ride *models.Ride, from models.RideStatus) error
+	// CountByStatus returns the number of rides in each of statuses.
+	CountByStatus(ctx context.Context, statuses ...models.RideStatus) (map[models.RideStatus]int, error)
+}
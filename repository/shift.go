@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// ShiftFilter narrows the shifts returned by ShiftRepository.ListByDriver to those
+// overlapping the half-open interval [From, To). A zero From or To leaves that side unbounded.
+type ShiftFilter struct {
+	// From is the inclusive start of the interval
+	From time.Time
+	// To is the exclusive end of the interval
+	To time.Time
+}
+
+// ShiftRepository provides persistent storage for driver shifts.
+type ShiftRepository interface {
+	// Open stores a new open shift for shift.DriverID and sets its ID.
+	// It returns ErrConflict if the driver already has an open shift.
+	Open(ctx context.Context, shift *models.Shift) error
+	// Close ends the open shift of the given driver at endedAt and returns it.
+	// It returns ErrNotFound if the driver has no open shift.
+	Close(ctx context.Context, driverID int, endedAt time.Time) (models.Shift, error)
+	// ListByDriver returns the page of the driver's shifts matching filter, sorted by one of
+	// ShiftSortFields, and the cursor of the next page, which is nil if this is the last one.
+	ListByDriver(ctx context.Context, driverID int, filter ShiftFilter, page Page) ([]models.Shift, *Cursor, error)
+}
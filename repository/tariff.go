@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hse-trpo-taxi/backend/models"
+)
+
+// TariffRepository provides persistent storage for tariffs.
+// Storing a tariff with Default set clears the flag on every other tariff.
+type TariffRepository interface {
+	// List returns the page of tariffs sorted by one of TariffSortFields and the cursor
+	// of the next page, which is nil if this is the last one.
+	List(ctx context.Context, page Page) ([]models.Tariff, *Cursor, error)
+	// Get returns the tariff with the given ID or ErrNotFound.
+	Get(ctx context.Context, id int) (models.Tariff, error)
+	// Default returns the default tariff or ErrNotFound if none is marked as default.
+	Default(ctx context.Context) (models.Tariff, error)
+	// Create stores a new tariff and sets its ID.
+	Create(ctx context.Context, tariff *models.Tariff) error
+	// Update overwrites the tariff identified by tariff.ID or returns ErrNotFound.
+	Update(ctx context.Context, tariff *models.Tariff) error
+	// Delete removes the tariff with the given ID. It returns ErrNotFound if the tariff
+	// does not exist and ErrConflict if rides still reference it.
+	Delete(ctx context.Context, id int) error
+}
@@ -0,0 +1,11 @@
+package repository
+
+import "context"
+
+// Transactor runs a group of repository calls atomically.
+type Transactor interface {
+	// InTx calls fn with a context that makes the repository methods it is passed to
+	// take part in one transaction, which is committed if fn returns nil and rolled back
+	// otherwise. A call nested inside another InTx joins the outer transaction.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,62 @@
+// Package requestid tags every HTTP request with an ID that is echoed in the response,
+// so a client can quote it and the matching server log lines can be found.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Header is the request and response header carrying the request ID.
+const Header = "X-Request-ID"
+
+// maxLength bounds the length of a request ID accepted from a client.
+const maxLength = 128
+
+// contextKey is the context key under which the request ID is stored.
+type contextKey struct{}
+
+// New returns a random 16-byte request ID in hex.
+func New() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// FromContext returns the ID of the request ctx belongs to, or "" if it has none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// WithID returns a copy of ctx carrying the request ID id.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// Middleware reuses the X-Request-ID header of an incoming request, such as one set by a
+// load balancer, or generates a new ID. The ID is stored in the request context and set on
+// the response.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if id == "" || len(id) > maxLength || !printable(id) {
+			id = New()
+		}
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(WithID(r.Context(), id)))
+	})
+}
+
+// printable reports whether s consists of printable ASCII characters only,
+// so a client-supplied ID cannot inject anything into logs or headers.
+func printable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
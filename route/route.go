@@ -0,0 +1,56 @@
+// Package route tells which route serves a request and records the response the handler
+// writes, for the access log, the request spans and the HTTP metrics.
+package route
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Unmatched is the route template reported for requests that match no route.
+const Unmatched = "unmatched"
+
+// Template returns the path template of the route of router that r matches, such as
+// /api/drivers/{id}, or Unmatched. Unlike the path, the template does not vary with the
+// IDs in it, so it can label metrics and name spans.
+func Template(router *mux.Router, r *http.Request) string {
+	var match mux.RouteMatch
+	if router.Match(r, &match) && match.Route != nil {
+		if template, err := match.Route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return Unmatched
+}
+
+// Recorder is an http.ResponseWriter that remembers the status code and counts the body
+// bytes written through it to the ResponseWriter it wraps.
+type Recorder struct {
+	http.ResponseWriter
+	// Status is the status code written, http.StatusOK if the handler did not set one
+	Status int
+	// Bytes is the number of body bytes written
+	Bytes int64
+}
+
+// NewRecorder returns a Recorder wrapping w.
+func NewRecorder(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (r *Recorder) WriteHeader(status int) {
+	r.Status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *Recorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.Bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter.
+func (r *Recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
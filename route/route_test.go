@@ -0,0 +1,50 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/drivers/{id}", func(http.ResponseWriter, *http.Request) {}).Methods("GET")
+	router.HandleFunc("/api/drivers", func(http.ResponseWriter, *http.Request) {}).Methods("GET")
+
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{"GET", "/api/drivers/12", "/api/drivers/{id}"},
+		{"GET", "/api/drivers", "/api/drivers"},
+		{"GET", "/api/unknown", Unmatched},
+		{"POST", "/api/drivers/12", Unmatched},
+	}
+	for _, tt := range tests {
+		if got := Template(router, httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+			t.Errorf("Template(%s %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	w := httptest.NewRecorder()
+	recorder := NewRecorder(w)
+	recorder.Write([]byte("hello"))
+	if recorder.Status != http.StatusOK || recorder.Bytes != 5 {
+		t.Errorf("implicit status: Status = %d, Bytes = %d, want 200 and 5", recorder.Status, recorder.Bytes)
+	}
+
+	w = httptest.NewRecorder()
+	recorder = NewRecorder(w)
+	recorder.WriteHeader(http.StatusNotFound)
+	recorder.Write([]byte("not found"))
+	if recorder.Status != http.StatusNotFound || recorder.Bytes != 9 || w.Code != http.StatusNotFound {
+		t.Errorf("Status = %d, Bytes = %d, written %d, want 404 and 9", recorder.Status, recorder.Bytes, w.Code)
+	}
+	if http.NewResponseController(recorder).Flush() != nil {
+		t.Error("Flush does not reach the wrapped ResponseWriter")
+	}
+}
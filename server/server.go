@@ -0,0 +1,103 @@
+// Package server runs the HTTP server with timeouts and shuts it down gracefully:
+// on a stop signal it first reports itself as not ready, so the load balancer stops
+// sending new requests, and then drains the requests in flight within a grace period.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Config holds the HTTP server settings.
+type Config struct {
+	// ReadTimeout bounds reading a whole request, including the body
+	ReadTimeout time.Duration
+	// WriteTimeout bounds the time from the end of the request headers to the end of the response
+	WriteTimeout time.Duration
+	// IdleTimeout is how long a keep-alive connection may wait for the next request
+	IdleTimeout time.Duration
+	// ShutdownDelay is how long the server keeps serving while reporting itself as not ready
+	// before it stops accepting connections, so load balancers notice and route traffic elsewhere
+	ShutdownDelay time.Duration
+	// ShutdownTimeout is how long requests in flight may take to finish once the server stops
+	// accepting connections; those still running after it are cut off
+	ShutdownTimeout time.Duration
+}
+
+// Validate reports the first setting that would make the server misbehave.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.ReadTimeout <= 0, cfg.WriteTimeout <= 0, cfg.IdleTimeout <= 0:
+		return errors.New("HTTP timeouts must be positive")
+	case cfg.ShutdownDelay < 0:
+		return errors.New("shutdown delay must not be negative")
+	case cfg.ShutdownTimeout <= 0:
+		return errors.New("shutdown grace period must be positive")
+	}
+	return nil
+}
+
+// Server is an HTTP server that tracks whether it is ready to receive traffic.
+type Server struct {
+	cfg   Config
+	srv   *http.Server
+	ready atomic.Bool
+}
+
+// New returns a Server that will listen on addr.
+func New(addr string, cfg Config) *Server {
+	return &Server{cfg: cfg, srv: &http.Server{
+		Addr:         addr,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}}
+}
+
+// Ready reports whether the server is serving and not shutting down.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// Run serves handler until ctx is done and then shuts the server down: it reports itself as
+// not ready, keeps serving for ShutdownDelay, stops accepting connections and waits up to
+// ShutdownTimeout for requests in flight. It returns an error only if the server could not
+// start; problems during shutdown are logged.
+func (s *Server) Run(ctx context.Context, handler http.Handler) error {
+	s.srv.Handler = handler
+	listener, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.srv.Serve(listener)
+	}()
+	s.ready.Store(true)
+
+	select {
+	case err := <-errs:
+		s.ready.Store(false)
+		return err
+	case <-ctx.Done():
+	}
+
+	s.ready.Store(false)
+	slog.Info("Shutting down: not ready, draining", "delay", s.cfg.ShutdownDelay)
+	time.Sleep(s.cfg.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	if err := s.srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Requests still running at the shutdown timeout were cut off", "timeout", s.cfg.ShutdownTimeout, "error", err)
+		s.srv.Close()
+	}
+	<-errs
+	slog.Info("Server stopped")
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/hse-trpo-taxi/backend/auth"
+	"github.com/hse-trpo-taxi/backend/config"
+)
+
+// tokenUsage describes the arguments accepted by the token subcommand.
+const tokenUsage = `usage: backend token [-ttl duration] <role> [subject]
+
+Issues a signed access token, e.g. for the first admin or a dispatcher account.
+role is one of client, driver, dispatcher or admin; subject is the account ID (default 0).
+JWT_KEYS must be set, otherwise the token would not be accepted by the server.`
+
+// runToken implements the "token" subcommand, printing a token signed with the configured keys.
+func runToken(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("token", flag.ContinueOnError)
+	ttl := flags.Duration("ttl", cfg.JWTTTL, "token lifetime")
+	flags.Usage = func() { fmt.Fprintln(flags.Output(), tokenUsage) }
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if len(args) < 1 || len(args) > 2 || !auth.Role(args[0]).Valid() {
+		return errors.New(tokenUsage)
+	}
+	p := auth.Principal{Role: auth.Role(args[0])}
+	if len(args) == 2 {
+		subject, err := strconv.Atoi(args[1])
+		if err != nil {
+			return errors.New(tokenUsage)
+		}
+		p.Subject = subject
+	}
+
+	keys, signingKey, err := auth.ParseKeys(cfg.JWTKeys)
+	if err != nil {
+		return fmt.Errorf("JWT_KEYS: %v", err)
+	}
+	tokens, err := auth.NewTokens(keys, signingKey, cfg.JWTTTL)
+	if err != nil {
+		return err
+	}
+	token, _, err := tokens.IssueFor(p, *ttl)
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package tracing sets up OpenTelemetry distributed tracing: a span for every HTTP request,
+// continuing the trace of the caller's W3C traceparent header, under which the repositories
+// add a span for every SQL statement.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/hse-trpo-taxi/backend/requestid"
+	"github.com/hse-trpo-taxi/backend/route"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The span exporters Config.Exporter can select.
+const (
+	// ExporterOTLP sends spans to an OpenTelemetry collector over OTLP/HTTP
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes spans to stdout as JSON, for local debugging
+	ExporterStdout = "stdout"
+	// ExporterNone disables tracing; traceparent headers are still passed on
+	ExporterNone = "none"
+)
+
+// tracer creates the request spans.
+var tracer = otel.Tracer("github.com/hse-trpo-taxi/backend/tracing")
+
+// Config holds the tracing settings.
+type Config struct {
+	// Exporter selects where spans are sent: ExporterOTLP, ExporterStdout or ExporterNone
+	Exporter string
+	// Endpoint is the URL of the OTLP/HTTP collector, e.g. http://collector:4318; if empty,
+	// the standard OTEL_EXPORTER_OTLP_* environment variables or http://localhost:4318 apply
+	Endpoint string
+	// SampleRatio is the fraction of new traces that are recorded; traces continued from
+	// a caller follow the caller's sampling decision
+	SampleRatio float64
+	// ServiceName names the service in the traces
+	ServiceName string
+}
+
+// Validate reports the first setting that would make tracing misbehave.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.Exporter != ExporterOTLP && cfg.Exporter != ExporterStdout && cfg.Exporter != ExporterNone:
+		return fmt.Errorf("unknown exporter %q", cfg.Exporter)
+	case cfg.SampleRatio < 0 || cfg.SampleRatio > 1:
+		return errors.New("sample ratio must be between 0 and 1")
+	case cfg.ServiceName == "":
+		return errors.New("service name must not be empty")
+	}
+	return nil
+}
+
+// NewExporter returns the span exporter selected by cfg, or nil for ExporterNone.
+// The OTLP exporter connects lazily, so a collector that is down does not stop the service.
+func NewExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		return stdouttrace.New()
+	default:
+		return nil, nil
+	}
+}
+
+// Setup installs W3C trace context and baggage propagation and, unless exporter is nil,
+// a global tracer provider that sends spans in batches to exporter. Tests can pass an
+// in-memory exporter such as tracetest.NewInMemoryExporter and read its spans after
+// flushing the provider with ForceFlush. The returned function flushes the remaining
+// spans and stops the provider.
+func Setup(exporter sdktrace.SpanExporter, cfg Config, version string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Middleware returns next wrapped to record a server span for every request, named after its
+// method and route template, such as "GET /api/drivers/{id}". The span continues the trace
+// of the request's traceparent header, if any, and is the parent of the spans started while
+// serving the request. It must run inside requestid.Middleware to tag spans with the request ID.
+func Middleware(router *mux.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		template := route.Template(router, r)
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+template,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(template),
+				semconv.URLPath(r.URL.Path),
+				semconv.UserAgentOriginal(r.UserAgent()),
+				attribute.String("request.id", requestid.FromContext(r.Context())),
+			))
+		defer span.End()
+
+		recorder := route.NewRecorder(w)
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.Status))
+		if recorder.Status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.Status))
+		}
+	})
+}
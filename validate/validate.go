@@ -0,0 +1,210 @@
+// Package validate checks request payloads against declarative field rules and reports
+// every failing field at once.
+//
+// A model lists its rules in a Validate method:
+//
+//	return validate.All(
+//		validate.Required("name", c.Name),
+//		validate.Phone("phone", &c.Phone),
+//	)
+//
+// Rules that take a pointer also normalize the value in place, e.g. a phone number is
+// rewritten in E.164 form. Only the first failing rule of each field is reported.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes why the value of one field was rejected.
+type FieldError struct {
+	// Field is the JSON name of the field
+	Field string `json:"field"`
+	// Message explains what a valid value looks like
+	Message string `json:"message"`
+}
+
+// Errors is the list of fields that failed validation. It implements error.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Rule checks one field, returning nil if the value is valid.
+type Rule func() *FieldError
+
+// All runs rules in order and returns the failures as Errors, or nil if every rule passed.
+// Once a rule for a field fails, later rules for the same field are skipped.
+func All(rules ...Rule) error {
+	var errs Errors
+	failed := map[string]bool{}
+	for _, rule := range rules {
+		if fe := rule(); fe != nil && !failed[fe.Field] {
+			failed[fe.Field] = true
+			errs = append(errs, *fe)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// fail returns a FieldError for field with a formatted message.
+func fail(field, format string, args ...any) *FieldError {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// Required rejects an empty or blank value.
+func Required(field, value string) Rule {
+	return func() *FieldError {
+		if strings.TrimSpace(value) == "" {
+			return fail(field, "is required")
+		}
+		return nil
+	}
+}
+
+// MaxLength rejects a value longer than max characters.
+func MaxLength(field, value string, max int) Rule {
+	return func() *FieldError {
+		if utf8.RuneCountInString(value) > max {
+			return fail(field, "must be at most %d characters", max)
+		}
+		return nil
+	}
+}
+
+// Positive rejects a value below 1, such as a missing ID.
+func Positive(field string, value int) Rule {
+	return func() *FieldError {
+		if value < 1 {
+			return fail(field, "is required")
+		}
+		return nil
+	}
+}
+
+// Range rejects a value outside [min, max].
+func Range[T int | float64](field string, value, min, max T) Rule {
+	return func() *FieldError {
+		if value < min || value > max {
+			return fail(field, "must be between %v and %v", min, max)
+		}
+		return nil
+	}
+}
+
+// OneOf rejects a value that is not one of allowed.
+func OneOf(field, value string, allowed ...string) Rule {
+	return func() *FieldError {
+		for _, a := range allowed {
+			if value == a {
+				return nil
+			}
+		}
+		return fail(field, "must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+// Phone rejects a value that is not a phone number and rewrites a valid one in E.164 form.
+func Phone(field string, value *string) Rule {
+	return func() *FieldError {
+		phone, ok := NormalizePhone(*value)
+		if !ok {
+			return fail(field, "must be a phone number in international format, e.g. +79991234567")
+		}
+		*value = phone
+		return nil
+	}
+}
+
+// Email rejects a value that is not a bare RFC 5322 address such as "user@example.com".
+// An empty value is accepted, since email is optional; combine with Required otherwise.
+// Surrounding whitespace is trimmed.
+func Email(field string, value *string) Rule {
+	return func() *FieldError {
+		*value = strings.TrimSpace(*value)
+		if *value == "" {
+			return nil
+		}
+		addr, err := mail.ParseAddress(*value)
+		if err != nil || addr.Address != *value || !strings.Contains(addr.Address[strings.LastIndexByte(addr.Address, '@'):], ".") {
+			return fail(field, "must be an email address, e.g. user@example.com")
+		}
+		return nil
+	}
+}
+
+// LicensePlate rejects a value that is not a Russian registration plate and rewrites a
+// valid one in canonical form; see NormalizeLicensePlate.
+func LicensePlate(field string, value *string) Rule {
+	return func() *FieldError {
+		plate, ok := NormalizeLicensePlate(*value)
+		if !ok {
+			return fail(field, "must be a Russian license plate, e.g. А123ВС77 or ТХ12377 for a taxi")
+		}
+		*value = plate
+		return nil
+	}
+}
+
+// separators matches the characters people use to format phone numbers and plates.
+var separators = regexp.MustCompile(`[\s()\-.]`)
+
+// e164 matches a phone number in E.164 form: a plus sign and up to 15 digits, not starting with 0.
+var e164 = regexp.MustCompile(`^\+[1-9]\d{7,14}$`)
+
+// NormalizePhone strips formatting from phone and returns it in E.164 form ("+79991234567").
+// A Russian number written with the trunk prefix 8 ("8 999 123-45-67") is converted to +7.
+// It reports false if phone is not a valid number.
+func NormalizePhone(phone string) (string, bool) {
+	phone = separators.ReplaceAllString(strings.TrimSpace(phone), "")
+	if len(phone) == 11 && phone[0] == '8' {
+		phone = "+7" + phone[1:]
+	}
+	if !e164.MatchString(phone) {
+		return "", false
+	}
+	return phone, true
+}
+
+// plateLetters maps the Latin letters that look like the twelve Cyrillic letters allowed
+// on Russian plates to those Cyrillic letters.
+var plateLetters = strings.NewReplacer(
+	"A", "А", "B", "В", "E", "Е", "K", "К", "M", "М", "H", "Н",
+	"O", "О", "P", "Р", "C", "С", "T", "Т", "Y", "У", "X", "Х",
+)
+
+// Russian registration plate formats, in canonical Cyrillic form: the standard private
+// plate (letter, three digits, two letters, region) and the yellow taxi plate
+// (two letters, three digits, region). The region code has two or three digits.
+var (
+	privatePlate = regexp.MustCompile(`^[АВЕКМНОРСТУХ]\d{3}[АВЕКМНОРСТУХ]{2}\d{2,3}$`)
+	taxiPlate    = regexp.MustCompile(`^[АВЕКМНОРСТУХ]{2}\d{3}\d{2,3}$`)
+)
+
+// NormalizeLicensePlate returns plate in canonical form: upper case, without spaces or
+// separators, and with Latin look-alike letters replaced by Cyrillic ones, so "a 123 bc 77"
+// and "А123ВС77" are stored the same way. It reports false if plate is not a Russian
+// private or taxi plate or its number is 000.
+func NormalizeLicensePlate(plate string) (string, bool) {
+	plate = strings.ToUpper(separators.ReplaceAllString(plate, ""))
+	plate = plateLetters.Replace(plate)
+	if !privatePlate.MatchString(plate) && !taxiPlate.MatchString(plate) {
+		return "", false
+	}
+	if strings.HasPrefix(strings.TrimLeft(plate, "АВЕКМНОРСТУХ"), "000") {
+		return "", false
+	}
+	return plate, true
+}